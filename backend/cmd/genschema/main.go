@@ -0,0 +1,49 @@
+// genschema 依 protocol 套件的型別產生 WebSocket 協定的 JSON Schema
+//
+//	go generate ./protocol
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"game/protocol"
+)
+
+func main() {
+	out := flag.String("out", "protocol/schema", "輸出目錄")
+	flag.Parse()
+
+	schemas := protocol.Schemas()
+	paths := make([]string, 0, len(schemas))
+	for path := range schemas {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// 先清掉舊檔，避免已移除的事件留下過期的 Schema
+	for _, dir := range []string{protocol.DirectionClient, protocol.DirectionServer} {
+		if err := os.RemoveAll(filepath.Join(*out, dir)); err != nil {
+			log.Fatalf("清除舊的 Schema 失敗: %v", err)
+		}
+	}
+
+	for _, path := range paths {
+		data, err := json.MarshalIndent(schemas[path], "", "  ")
+		if err != nil {
+			log.Fatalf("序列化 %s 失敗: %v", path, err)
+		}
+		target := filepath.Join(*out, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			log.Fatalf("建立目錄失敗: %v", err)
+		}
+		if err := os.WriteFile(target, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("寫入 %s 失敗: %v", target, err)
+		}
+	}
+	log.Printf("已產生 %d 個 Schema 到 %s", len(paths), *out)
+}
@@ -10,8 +10,15 @@ import (
 )
 
 type Config struct {
-	MySQL MySQL `yaml:"mysql"`
-	Redis Redis `yaml:"redis"`
+	MySQL      MySQL      `yaml:"mysql"`
+	Redis      Redis      `yaml:"redis"`
+	Daily      Daily      `yaml:"daily"`
+	RateLimit  RateLimit  `yaml:"rate_limit"`
+	Moderation Moderation `yaml:"moderation"`
+	Chat       Chat       `yaml:"chat"`
+	Storage    Storage    `yaml:"storage"`
+	JWT        JWT        `yaml:"jwt"`
+	Mail       Mail       `yaml:"mail"`
 }
 
 type MySQL struct {
@@ -29,6 +36,66 @@ type Redis struct {
 	DB       int    `yaml:"db"`
 }
 
+type Daily struct {
+	Secret string `yaml:"secret"` // 推導每日答案用的伺服器密鑰
+}
+
+// RateLimit WebSocket 事件的限流設定，規則格式為「事件=次數/時間」，以逗號分隔，* 為其他事件的預設值，
+// 例如 chat=5/5s,player_ready=3/5s,*=10/1s；留空時使用預設規則
+type RateLimit struct {
+	Connection      string `yaml:"connection"`       // 每個連線
+	User            string `yaml:"user"`             // 同一位玩家的所有連線合計
+	MaxViolations   int    `yaml:"max_violations"`   // 在 ViolationWindow 內被限流達此次數即斷線
+	ViolationWindow int    `yaml:"violation_window"` // 秒
+}
+
+// Moderation 聊天審核設定
+type Moderation struct {
+	MaxChatLength int    `yaml:"max_chat_length"` // 聊天訊息長度上限（字元數），0 使用預設值
+	BannedWords   string `yaml:"banned_words"`    // 內建詞庫以外的禁用詞，以逗號分隔
+	AdminEmails   string `yaml:"admin_emails"`    // 可以審查檢舉的管理員 email（需已驗證），以逗號分隔
+}
+
+// Chat 聊天紀錄設定
+type Chat struct {
+	HistorySize int  `yaml:"history_size"` // 每個房間保留的聊天則數，0 使用預設值
+	Archive     bool `yaml:"archive"`      // 排名局結束時將聊天紀錄封存到 MySQL
+}
+
+// Storage 上傳檔案（頭像）的儲存設定
+type Storage struct {
+	Driver      string `yaml:"driver"`     // local（預設）或 s3
+	LocalDir    string `yaml:"local_dir"`  // local：存放目錄，預設 uploads
+	PublicURL   string `yaml:"public_url"` // 對外網址前綴；local 預設 /uploads，s3 預設 {endpoint}/{bucket}
+	S3Endpoint  string `yaml:"s3_endpoint"`
+	S3Region    string `yaml:"s3_region"`
+	S3Bucket    string `yaml:"s3_bucket"`
+	S3AccessKey string `yaml:"s3_access_key"`
+	S3SecretKey string `yaml:"s3_secret_key"`
+}
+
+// JWT Game JWT 的簽章設定，金鑰格式為「kid=值」，以逗號分隔；
+// HS256 的值為密鑰，RS256/EdDSA 的值為 PEM 檔案路徑或 PEM 內容，只有公鑰的金鑰僅用於驗證（輪替中的舊金鑰）
+type JWT struct {
+	Alg        string `yaml:"alg"`         // HS256（預設）、RS256 或 EdDSA
+	Keys       string `yaml:"keys"`        // 所有有效的金鑰
+	ActiveKid  string `yaml:"active_kid"`  // 用來簽發的金鑰，預設為第一把
+	HMACSecret string `yaml:"hmac_secret"` // HS256 只有一把密鑰時的簡寫
+
+	AllowDevSecret bool `yaml:"allow_dev_secret"` // 沒有設定金鑰時改用隨機密鑰（重啟後 token 全部失效），只用於本機開發
+}
+
+// Mail 寄送驗證信與重設密碼信的設定
+type Mail struct {
+	Driver       string `yaml:"driver"` // log（預設，只寫入日誌）或 smtp
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`     // 預設 25
+	SMTPUsername string `yaml:"smtp_username"` // 留空時不驗證（MailHog）
+	SMTPPassword string `yaml:"smtp_password"`
+	From         string `yaml:"from"`    // 寄件人
+	AppURL       string `yaml:"app_url"` // 信中連結的網址前綴，例如 https://example.com
+}
+
 func LoadConfig() (Config, error) {
 	var appConfig Config
 	data, err := os.ReadFile("config/config.yaml")
@@ -67,5 +134,42 @@ func LoadConfigEnv() (Config, error) {
 	appConfig.Redis.Password = os.Getenv("REDIS_PASSWORD")
 	appConfig.Redis.DB, _ = strconv.Atoi(os.Getenv("REDIS_DB"))
 
+	appConfig.Daily.Secret = os.Getenv("DAILY_SECRET")
+
+	appConfig.RateLimit.Connection = os.Getenv("WS_RATE_LIMITS")
+	appConfig.RateLimit.User = os.Getenv("WS_USER_RATE_LIMITS")
+	appConfig.RateLimit.MaxViolations, _ = strconv.Atoi(os.Getenv("WS_RATE_MAX_VIOLATIONS"))
+	appConfig.RateLimit.ViolationWindow, _ = strconv.Atoi(os.Getenv("WS_RATE_VIOLATION_WINDOW"))
+
+	appConfig.Moderation.MaxChatLength, _ = strconv.Atoi(os.Getenv("CHAT_MAX_LENGTH"))
+	appConfig.Moderation.BannedWords = os.Getenv("CHAT_BANNED_WORDS")
+	appConfig.Moderation.AdminEmails = os.Getenv("ADMIN_EMAILS")
+
+	appConfig.Chat.HistorySize, _ = strconv.Atoi(os.Getenv("CHAT_HISTORY_SIZE"))
+	appConfig.Chat.Archive, _ = strconv.ParseBool(os.Getenv("CHAT_ARCHIVE"))
+
+	appConfig.Storage.Driver = os.Getenv("STORAGE_DRIVER")
+	appConfig.Storage.LocalDir = os.Getenv("STORAGE_LOCAL_DIR")
+	appConfig.Storage.PublicURL = os.Getenv("STORAGE_PUBLIC_URL")
+	appConfig.Storage.S3Endpoint = os.Getenv("S3_ENDPOINT")
+	appConfig.Storage.S3Region = os.Getenv("S3_REGION")
+	appConfig.Storage.S3Bucket = os.Getenv("S3_BUCKET")
+	appConfig.Storage.S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	appConfig.Storage.S3SecretKey = os.Getenv("S3_SECRET_KEY")
+
+	appConfig.JWT.Alg = os.Getenv("JWT_ALG")
+	appConfig.JWT.Keys = os.Getenv("JWT_KEYS")
+	appConfig.JWT.ActiveKid = os.Getenv("JWT_ACTIVE_KID")
+	appConfig.JWT.HMACSecret = os.Getenv("JWT_HMAC_SECRET")
+	appConfig.JWT.AllowDevSecret, _ = strconv.ParseBool(os.Getenv("JWT_ALLOW_DEV_SECRET"))
+
+	appConfig.Mail.Driver = os.Getenv("MAIL_DRIVER")
+	appConfig.Mail.SMTPHost = os.Getenv("SMTP_HOST")
+	appConfig.Mail.SMTPPort, _ = strconv.Atoi(os.Getenv("SMTP_PORT"))
+	appConfig.Mail.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	appConfig.Mail.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	appConfig.Mail.From = os.Getenv("MAIL_FROM")
+	appConfig.Mail.AppURL = os.Getenv("APP_URL")
+
 	return appConfig, nil
 }
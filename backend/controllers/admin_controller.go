@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"game/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReqReviewReport struct {
+	Status string `json:"status" binding:"required"` // reviewed 或 dismissed
+}
+
+type AdminController struct {
+	mysqlManager *services.GameManagerMysql
+	chatHistory  *services.ChatHistoryManager
+}
+
+func NewAdminController(mysqlManager *services.GameManagerMysql, chatHistory *services.ChatHistoryManager) *AdminController {
+	return &AdminController{
+		mysqlManager: mysqlManager,
+		chatHistory:  chatHistory,
+	}
+}
+
+// 列出聊天檢舉，可用 ?status=open 篩選、?limit= 限制筆數
+func (a *AdminController) ReportsController(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	reports, err := a.mysqlManager.ListChatReports(c.Query("status"), limit)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"reports": reports})
+}
+
+// 審查一筆檢舉
+func (a *AdminController) ReviewReportController(c *gin.Context) {
+	var req ReqReviewReport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	report, err := a.mysqlManager.ReviewChatReport(c.Param("id"), req.Status, c.GetString("email"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+// 查詢某一輪封存的聊天紀錄，?game_id=&round=
+func (a *AdminController) ChatArchiveController(c *gin.Context) {
+	gameID := c.Query("game_id")
+	round, err := strconv.Atoi(c.Query("round"))
+	if gameID == "" || err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	archive, err := a.chatHistory.GetChatArchive(gameID, round)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, archive)
+}
@@ -1,7 +1,8 @@
 package controllers
 
 import (
-	"game/middleware"
+	"errors"
+	"game/models"
 	"game/services"
 	"log"
 	"regexp"
@@ -11,7 +12,9 @@ import (
 )
 
 type AuthController struct {
-	gameManager *services.GameManagerMysql
+	gameManager    *services.GameManagerMysql
+	tokenManager   *services.TokenManager
+	accountManager *services.AccountManager
 }
 
 type LoginRequest struct {
@@ -31,15 +34,41 @@ type RegisterRequest struct {
 }
 
 type LoginResponse struct {
-	Token    string `json:"token"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Message  string `json:"message"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // token 的有效秒數
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Message      string `json:"message"`
 }
 
-func NewAuthController(gameManager *services.GameManagerMysql) *AuthController {
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // 選填，一併撤銷這次登入的 refresh token
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token" binding:"required"`
+	Password        string `json:"password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func NewAuthController(gameManager *services.GameManagerMysql, tokenManager *services.TokenManager, accountManager *services.AccountManager) *AuthController {
 	return &AuthController{
-		gameManager: gameManager,
+		gameManager:    gameManager,
+		tokenManager:   tokenManager,
+		accountManager: accountManager,
 	}
 }
 
@@ -85,20 +114,64 @@ func (ac *AuthController) LoginController(c *gin.Context) {
 		return
 	}
 
-	// 生成 JWT Token
-	token, err := middleware.GenerateJWTGame(req.Email, username, uuid)
+	// 生成 JWT Token 與 refresh token
+	tokens, err := ac.tokenManager.Issue(uuid, req.Email, username, clientInfo(c))
 	if err != nil {
+		log.Println("Issue token error:", err)
 		c.JSON(500, gin.H{"error": "生成token失敗"})
 		return
 	}
 
 	c.JSON(200, LoginResponse{
-		Token:    token,
-		Username: username,
-		Message:  "登入成功",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		Username:     username,
+		Message:      "登入成功",
 	})
 }
 
+// 以 refresh token 換發新的 token，舊的 refresh token 隨即失效
+func (ac *AuthController) RefreshController(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "缺少 refresh_token"})
+		return
+	}
+
+	tokens, err := ac.tokenManager.Refresh(req.RefreshToken, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Refresh token error:", err)
+		c.JSON(500, gin.H{"error": "換發token失敗"})
+		return
+	}
+
+	c.JSON(200, tokens)
+}
+
+// 登出：目前的 token 立即失效，帶 refresh_token 時一併撤銷
+func (ac *AuthController) LogoutController(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	err := ac.tokenManager.Logout(c.GetString("uuid"), c.GetString("jti"), c.GetString("sid"), c.GetTime("tokenExpiresAt"), req.RefreshToken)
+	if err != nil {
+		log.Println("Logout error:", err)
+		c.JSON(500, gin.H{"error": "登出失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "已登出"})
+}
+
 func (ac *AuthController) RegisterController(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -144,7 +217,92 @@ func (ac *AuthController) RegisterController(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "註冊成功"})
+	// 寄出驗證信，失敗時可登入後重寄
+	if err := ac.accountManager.SendVerificationByEmail(req.Email); err != nil {
+		log.Println("Send verification error:", err)
+	}
+
+	c.JSON(200, gin.H{"message": "註冊成功，請至信箱完成驗證"})
+}
+
+// 忘記密碼：寄出重設密碼信，email 是否註冊都回傳相同訊息
+func (ac *AuthController) ForgotPasswordController(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Email不得為空"})
+		return
+	}
+	if err := ac.accountManager.RequestPasswordReset(req.Email); err != nil {
+		log.Println("Request password reset error:", err)
+		c.JSON(500, gin.H{"error": "寄送重設密碼信失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "若此 Email 已註冊，將收到重設密碼信"})
+}
+
+// 以重設密碼信中的 token 設定新密碼，成功後所有裝置都需重新登入
+func (ac *AuthController) ResetPasswordController(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "重設資訊不得為空"})
+		return
+	}
+	// 檢查密碼長度
+	if len(req.Password) < 6 || len(req.Password) > 15 {
+		c.JSON(400, gin.H{"error": "Password長度必須在6到15個字元之間"})
+		return
+	}
+	if req.Password != req.ConfirmPassword {
+		c.JSON(400, gin.H{"error": "Password不一致"})
+		return
+	}
+
+	err := ac.accountManager.ResetPassword(req.Token, req.Password)
+	if errors.Is(err, services.ErrInvalidAccountToken) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Reset password error:", err)
+		c.JSON(500, gin.H{"error": "重設密碼失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "密碼已重設，請重新登入"})
+}
+
+// 以驗證信中的 token 完成 email 驗證
+func (ac *AuthController) VerifyEmailController(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "缺少 token"})
+		return
+	}
+	err := ac.accountManager.VerifyEmail(req.Token)
+	if errors.Is(err, services.ErrInvalidAccountToken) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Verify email error:", err)
+		c.JSON(500, gin.H{"error": "驗證 Email 失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Email 驗證成功"})
+}
+
+// 重寄驗證信給目前登入的使用者
+func (ac *AuthController) ResendVerificationController(c *gin.Context) {
+	err := ac.accountManager.SendVerification(c.GetString("uuid"))
+	if errors.Is(err, services.ErrEmailAlreadyVerified) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrMailCooldown) {
+		c.JSON(429, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Resend verification error:", err)
+		c.JSON(500, gin.H{"error": "寄送驗證信失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "驗證信已寄出"})
 }
 
 // 產生驗證碼
@@ -161,3 +319,8 @@ func (ac *AuthController) GetCaptchaController(c *gin.Context) {
 		"captcha_img": b64s,
 	})
 }
+
+// 登入裝置資訊，記錄在 session 中
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"game/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReqDailyGuess struct {
+	GuessNum int `json:"guess_num" binding:"required"`
+}
+
+type DailyController struct {
+	dailyManager *services.DailyChallengeManager
+}
+
+func NewDailyController(dailyManager *services.DailyChallengeManager) *DailyController {
+	return &DailyController{
+		dailyManager: dailyManager,
+	}
+}
+
+// 開始今天的每日挑戰
+func (d *DailyController) StartController(c *gin.Context) {
+	attempt, err := d.dailyManager.Start(c.GetString("uuid"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"date":       attempt.Date,
+		"min_range":  attempt.MinRange,
+		"max_range":  attempt.MaxRange,
+		"guesses":    attempt.Guesses,
+		"started_at": attempt.StartedAt,
+	})
+}
+
+// 每日挑戰猜數字
+func (d *DailyController) GuessController(c *gin.Context) {
+	var req ReqDailyGuess
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	result, err := d.dailyManager.Guess(c.GetString("uuid"), req.GuessNum)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// 每日挑戰排行榜，可用 ?date=2006-01-02 查詢過去的日期
+func (d *DailyController) LeaderboardController(c *gin.Context) {
+	date := c.Query("date")
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(400, gin.H{"error": "日期格式錯誤"})
+			return
+		}
+	}
+
+	leaderboard, err := d.dailyManager.GetLeaderboard(date, 10)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "獲取每日挑戰排行榜失敗"})
+		return
+	}
+
+	c.JSON(200, leaderboard)
+}
+
+// 查詢自己的連續挑戰天數
+func (d *DailyController) StreakController(c *gin.Context) {
+	streak, err := d.dailyManager.GetStreak(c.GetString("uuid"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "獲取連續天數失敗"})
+		return
+	}
+
+	c.JSON(200, streak)
+}
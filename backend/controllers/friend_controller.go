@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"game/protocol"
+	"game/services"
+	"game/ws"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReqFriendRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type ReqJoinByInvite struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type FriendController struct {
+	friendManager *services.FriendManager
+	gameManager   *services.RedisGameManager
+}
+
+func NewFriendController(friendManager *services.FriendManager, gameManager *services.RedisGameManager) *FriendController {
+	return &FriendController{
+		friendManager: friendManager,
+		gameManager:   gameManager,
+	}
+}
+
+// 好友列表，附上在線狀態（offline / online / in_game）
+func (f *FriendController) FriendsController(c *gin.Context) {
+	friends, err := f.friendManager.Friends(c.GetString("uuid"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "查詢好友列表失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"friends": friends})
+}
+
+// 收到且尚未回覆的好友邀請
+func (f *FriendController) RequestsController(c *gin.Context) {
+	requests, err := f.friendManager.PendingRequests(c.GetString("uuid"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "查詢好友邀請失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"requests": requests})
+}
+
+// 以使用者名稱送出好友邀請
+func (f *FriendController) SendRequestController(c *gin.Context) {
+	var req ReqFriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	friendship, err := f.friendManager.SendRequest(c.GetString("uuid"), req.Username)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, friendship)
+}
+
+func (f *FriendController) AcceptController(c *gin.Context) {
+	f.respond(c, true)
+}
+
+func (f *FriendController) DeclineController(c *gin.Context) {
+	f.respond(c, false)
+}
+
+func (f *FriendController) respond(c *gin.Context, accept bool) {
+	friendship, err := f.friendManager.Respond(c.Param("id"), c.GetString("uuid"), accept)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, friendship)
+}
+
+// 解除好友
+func (f *FriendController) RemoveController(c *gin.Context) {
+	if err := f.friendManager.Remove(c.GetString("uuid"), c.Param("userId")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "已解除好友"})
+}
+
+// 以遊戲邀請的 token 一鍵加入好友的房間
+func (f *FriendController) JoinByInviteController(c *gin.Context) {
+	var req ReqJoinByInvite
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	gameID, err := f.friendManager.JoinByInvite(req.Token, c.GetString("uuid"), c.GetString("username"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error(), "code": protocol.CodeOf(err)})
+		return
+	}
+
+	game, err := f.gameManager.GetAGameStatus(gameID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Game not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"game_id": gameID,
+		"game":    ws.PublicGame(game),
+		"message": "Player joined successfully",
+	})
+}
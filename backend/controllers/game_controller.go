@@ -2,16 +2,25 @@ package controllers
 
 import (
 	"game/game"
+	"game/models"
+	"game/protocol"
 	"game/services"
+	"game/ws"
 	"log"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ReqCreate struct {
-	NumOfPeople int `json:"num_of_people"`
-	MinRange    int `json:"min_range"`
-	MaxRange    int `json:"max_range"`
+	NumOfPeople  int    `json:"num_of_people"`
+	MinRange     int    `json:"min_range"`
+	MaxRange     int    `json:"max_range"`
+	Mode         string `json:"mode"`          // classic、simultaneous、team 或 elimination，預設 classic
+	RoundSeconds int    `json:"round_seconds"` // 同時猜測模式每回合秒數
+	TeamCount    int    `json:"team_count"`    // 隊伍模式的隊伍數，預設 2
+	PowerUps     bool   `json:"power_ups"`     // 啟用道具
+	FeedbackMode string `json:"feedback_mode"` // direction、proximity_answer 或 proximity_previous
 }
 
 type ReqJoin struct {
@@ -53,19 +62,44 @@ func NewGameHandlerWithManager(redisGameManager *services.RedisGameManager, mysq
 // 創建遊戲控制器
 func (g *GameHandler) CreateGameController(c *gin.Context) {
 
+	// 房間設定為選填，未帶 body 時使用預設值
+	var reqCreate ReqCreate
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&reqCreate); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	// 房間人數預設 5 人，淘汰模式等大型房間最多 20 人
+	numOfPeople := 5
+	if reqCreate.NumOfPeople != 0 {
+		if reqCreate.NumOfPeople < 2 || reqCreate.NumOfPeople > 20 {
+			c.JSON(400, gin.H{"error": "房間人數必須在 2 到 20 之間"})
+			return
+		}
+		numOfPeople = reqCreate.NumOfPeople
+	}
+
 	// 生成唯一遊戲ID
 	gameID := game.GenerateGameID()
 
-	err := g.redisGameManager.CreateGame(gameID, 5)
+	err := g.redisGameManager.CreateGame(gameID, numOfPeople, models.GameOptions{
+		Mode:         reqCreate.Mode,
+		RoundSeconds: reqCreate.RoundSeconds,
+		TeamCount:    reqCreate.TeamCount,
+		PowerUps:     reqCreate.PowerUps,
+		FeedbackMode: reqCreate.FeedbackMode,
+	})
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": err.Error(), "code": protocol.CodeOf(err)})
 		return
 	}
 	username := c.GetString("username")
 	uuid := c.GetString("uuid")
 	err = g.redisGameManager.AddPlayer(gameID, uuid, username)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": err.Error(), "code": protocol.CodeOf(err)})
 		return
 	}
 
@@ -89,7 +123,7 @@ func (g *GameHandler) JoinGameController(c *gin.Context) {
 	log.Println("Username from context:", username)
 	err := g.redisGameManager.AddPlayer(reqJoin.GameId, c.GetString("uuid"), username)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(400, gin.H{"error": err.Error(), "code": protocol.CodeOf(err)})
 		return
 	}
 
@@ -101,7 +135,7 @@ func (g *GameHandler) JoinGameController(c *gin.Context) {
 
 	c.JSON(200, gin.H{
 		"game_id": reqJoin.GameId,
-		"game":    game,
+		"game":    ws.PublicGame(game),
 		"message": "Player joined successfully",
 	})
 
@@ -115,8 +149,13 @@ func (g *GameHandler) AllGamesController(c *gin.Context) {
 		return
 	}
 
+	// 答案與鹽值不能在遊戲進行中送出，只回傳公開的快照
+	publicGames := make(map[string]protocol.GameSnapshot, len(games))
+	for key, game := range games {
+		publicGames[key] = ws.PublicGame(game)
+	}
 	c.JSON(200, gin.H{
-		"games": games,
+		"games": publicGames,
 	})
 }
 
@@ -132,6 +171,32 @@ func (g *GameHandler) LeaderboardController(c *gin.Context) {
 	c.JSON(200, topPlayers)
 }
 
+// 驗證過去某一輪的答案承諾值
+func (g *GameHandler) VerifyRoundController(c *gin.Context) {
+	gameID := c.Query("game_id")
+	round, err := strconv.Atoi(c.Query("round"))
+	if gameID == "" || err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	gameResult, verified, err := g.mysqlGameManager.VerifyRound(gameID, round)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"game_id":    gameResult.GameID,
+		"round":      gameResult.Round,
+		"answer":     gameResult.Answer,
+		"salt":       gameResult.Salt,
+		"commitment": gameResult.Commitment,
+		"scheme":     game.CommitmentScheme,
+		"verified":   verified,
+	})
+}
+
 // 專門的除錯控制器
 type DebugController struct {
 	wsService *services.NewStruWebSocketService
@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"game/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct{}
+
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+// 公開 Game JWT 的驗證公鑰，HS256 時為空集合
+func (j *JWKSController) JWKSController(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, middleware.GameJWKS())
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"game/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageController struct {
+	directMessageManager *services.DirectMessageManager
+}
+
+func NewMessageController(directMessageManager *services.DirectMessageManager) *MessageController {
+	return &MessageController{
+		directMessageManager: directMessageManager,
+	}
+}
+
+// 讀取離線期間收到的私訊，不會清空收件匣
+func (m *MessageController) InboxController(c *gin.Context) {
+	messages, err := m.directMessageManager.Inbox(c.GetString("uuid"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "讀取收件匣失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"messages": messages})
+}
+
+// 確認已讀取到 until 指定的私訊，將它與更早的私訊從收件匣移除
+func (m *MessageController) AckInboxController(c *gin.Context) {
+	until := c.Query("until")
+	if until == "" {
+		c.JSON(400, gin.H{"error": "請指定 until"})
+		return
+	}
+
+	removed, err := m.directMessageManager.AckInbox(c.GetString("uuid"), until)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "更新收件匣失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"removed": removed})
+}
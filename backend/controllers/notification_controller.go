@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"game/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReqMarkRead struct {
+	Ids []string `json:"ids"` // 為空時全部標為已讀
+}
+
+type NotificationController struct {
+	notificationManager *services.NotificationManager
+}
+
+func NewNotificationController(notificationManager *services.NotificationManager) *NotificationController {
+	return &NotificationController{
+		notificationManager: notificationManager,
+	}
+}
+
+// 列出通知，可用 ?unread=true 只列未讀、?before=RFC3339 往前翻頁、?limit= 限制筆數
+func (n *NotificationController) ListController(c *gin.Context) {
+	uuid := c.GetString("uuid")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	var before time.Time
+	if c.Query("before") != "" {
+		t, err := time.Parse(time.RFC3339, c.Query("before"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "before 必須是 RFC3339 時間格式"})
+			return
+		}
+		before = t
+	}
+
+	notifications, err := n.notificationManager.List(uuid, c.Query("unread") == "true", before, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "查詢通知失敗"})
+		return
+	}
+	unread, err := n.notificationManager.UnreadCount(uuid)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "查詢通知失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"notifications": notifications, "unread": unread})
+}
+
+// 未讀通知數
+func (n *NotificationController) UnreadCountController(c *gin.Context) {
+	unread, err := n.notificationManager.UnreadCount(c.GetString("uuid"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "查詢通知失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"unread": unread})
+}
+
+// 將通知標為已讀，不帶 ids 時全部標為已讀
+func (n *NotificationController) MarkReadController(c *gin.Context) {
+	var req ReqMarkRead
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	uuid := c.GetString("uuid")
+	updated, err := n.notificationManager.MarkRead(uuid, req.Ids)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "更新通知失敗"})
+		return
+	}
+	unread, err := n.notificationManager.UnreadCount(uuid)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "查詢通知失敗"})
+		return
+	}
+
+	c.JSON(200, gin.H{"updated": updated, "unread": unread})
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"game/protocol"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProtocolController struct{}
+
+func NewProtocolController() *ProtocolController {
+	return &ProtocolController{}
+}
+
+// WebSocket 協定總覽：版本、錯誤碼與所有事件的 Schema 路徑
+func (p *ProtocolController) IndexController(c *gin.Context) {
+	c.JSON(200, protocol.Index())
+}
+
+// 取得單一事件的 JSON Schema，例如 /protocol/schema/server/player_guess.json
+func (p *ProtocolController) SchemaController(c *gin.Context) {
+	path := c.Param("direction") + "/" + c.Param("type")
+	schema, ok := protocol.Schemas()[path]
+	if !ok {
+		c.JSON(404, gin.H{"error": "找不到此事件的 Schema"})
+		return
+	}
+	c.JSON(200, schema)
+}
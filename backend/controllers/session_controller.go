@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"errors"
+	"game/services"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	tokenManager *services.TokenManager
+}
+
+func NewSessionController(tokenManager *services.TokenManager) *SessionController {
+	return &SessionController{
+		tokenManager: tokenManager,
+	}
+}
+
+// 目前登入中的裝置，current 標示發出此請求的裝置
+func (s *SessionController) ListController(c *gin.Context) {
+	sessions, err := s.tokenManager.Sessions(c.GetString("uuid"), c.GetString("sid"))
+	if err != nil {
+		log.Println("List sessions error:", err)
+		c.JSON(500, gin.H{"error": "查詢登入裝置失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"sessions": sessions})
+}
+
+// 登出指定裝置，該裝置的 token 立即失效並中斷它的 WebSocket 連線
+func (s *SessionController) RevokeController(c *gin.Context) {
+	err := s.tokenManager.RevokeSession(c.GetString("uuid"), c.Param("id"))
+	if errors.Is(err, services.ErrSessionNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Revoke session error:", err)
+		c.JSON(500, gin.H{"error": "登出裝置失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "已登出此裝置"})
+}
+
+// 登出目前裝置以外的所有裝置
+func (s *SessionController) RevokeOthersController(c *gin.Context) {
+	sid := c.GetString("sid")
+	if sid == "" {
+		// 舊版 token 沒有 sid，無法分辨目前的裝置
+		c.JSON(400, gin.H{"error": "請重新登入後再試"})
+		return
+	}
+	count, err := s.tokenManager.RevokeOtherSessions(c.GetString("uuid"), sid)
+	if err != nil {
+		log.Println("Revoke sessions error:", err)
+		c.JSON(500, gin.H{"error": "登出裝置失敗"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "已登出其他裝置", "revoked": count})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"game/middleware"
+	"game/services"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReqUpdateProfile struct {
+	Username  *string `json:"username"`
+	AvatarURL *string `json:"avatar_url"` // 空字串表示移除頭像
+}
+
+type UserController struct {
+	userManager        *services.UserManager
+	achievementManager *services.AchievementManager
+	avatarManager      *services.AvatarManager
+}
+
+func NewUserController(userManager *services.UserManager, achievementManager *services.AchievementManager, avatarManager *services.AvatarManager) *UserController {
+	return &UserController{
+		userManager:        userManager,
+		achievementManager: achievementManager,
+		avatarManager:      avatarManager,
+	}
+}
+
+// 其他玩家的個人頁面
+func (u *UserController) ProfileController(c *gin.Context) {
+	id := c.Param("id")
+	profile, err := u.userManager.Profile(id, id == c.GetString("uuid"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, profile)
+}
+
+// 自己的個人頁面，附上 email
+func (u *UserController) MeController(c *gin.Context) {
+	profile, err := u.userManager.Profile(c.GetString("uuid"), true)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, profile)
+}
+
+// 修改使用者名稱或頭像；名稱改變時回傳新的 token（舊 token 中的名稱不會更新）
+func (u *UserController) UpdateMeController(c *gin.Context) {
+	var req ReqUpdateProfile
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	profile, err := u.userManager.UpdateProfile(c.GetString("uuid"), req.Username, req.AvatarURL)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"profile": profile}
+	if profile.Username != c.GetString("username") {
+		token, err := middleware.GenerateJWTGame(c.GetString("email"), profile.Username, profile.ID, c.GetString("sid"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "生成token失敗"})
+			return
+		}
+		response["token"] = token
+	}
+	c.JSON(200, response)
+}
+
+// 上傳頭像（multipart 欄位 avatar），伺服器裁切縮放後更新 avatar_url
+func (u *UserController) UploadAvatarController(c *gin.Context) {
+	// 預留 multipart 表頭的空間，實際檔案大小由 AvatarManager 檢查
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAvatarBytes+64<<10)
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(400, gin.H{"error": "請以 avatar 欄位上傳不超過 2 MB 的圖片"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "無法讀取上傳的檔案"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxAvatarBytes+1))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "無法讀取上傳的檔案"})
+		return
+	}
+
+	avatarURL, err := u.avatarManager.Upload(c.GetString("uuid"), data)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"avatar_url": avatarURL})
+}
+
+// 玩家的成就列表，包含尚未達成的
+func (u *UserController) AchievementsController(c *gin.Context) {
+	achievements, err := u.achievementManager.Achievements(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"achievements": achievements})
+}
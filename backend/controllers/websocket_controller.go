@@ -1,16 +1,16 @@
 package controllers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
-	"time"
 
 	"game/models"
+	"game/protocol"
 	"game/services"
 	"game/ws"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 type WebSocketController struct {
@@ -42,10 +42,15 @@ func (wsc *WebSocketController) HandleWebSocket2(c *gin.Context) {
 
 	playerUuid := c.GetString("uuid")
 
+	protocolVersion, encoding, responseHeader, ok := negotiateConnection(c)
+	if !ok {
+		return
+	}
+
 	log.Printf("玩家 %s 嘗試連接到遊戲 %s", username, gameID)
 
 	// 升級 HTTP 連接為 WebSocket
-	conn, err := models.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := models.Upgrader.Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
 		log.Printf("WebSocket 升級失敗: %v", err)
 		return
@@ -67,103 +72,74 @@ func (wsc *WebSocketController) HandleWebSocket2(c *gin.Context) {
 	}
 
 	// 創建客戶端
-	client := &ws.Client{
-		ChatHub:    wsc.wsService.GetChatHub(),
-		Conn:       conn,
-		Send:       make(chan []byte, 256),
-		RoomID:     gameID,
-		PlayerUuid: playerUuid,
-		PlayerName: username,
-	}
+	client := ws.NewClient(wsc.wsService.GetChatHub(), conn, gameID, playerUuid, username, c.GetString("sid"), protocolVersion, encoding)
 
 	log.Printf("客戶端創建成功，準備加入聊天室房間...")
 
 	// 玩家加入遊戲
 	client.ChatHub.Join <- client
 
-	// WebSocket 連接成功後發送房間狀態
-	go func() {
-		time.Sleep(200 * time.Millisecond)
-		err := wsc.broadcastRoomStatus(gameID, fmt.Sprintf("玩家 %s 加入了房間", username))
-		if err != nil {
-			log.Printf("廣播房間狀態失敗: %v", err)
-		}
-	}()
+	// 房間狀態由 ChatHub 在加入後送出：新連線收到 sync，房間內其他人收到 room_status_update
 
 	// 啟動讀寫協程
 	go client.WritePump()
 	go client.ReadPump()
 
-	log.Printf("WebSocket 連接建立成功: %s 加入聊天室 %s", username, gameID)
+	log.Printf("WebSocket 連接建立成功: %s 加入聊天室 %s（協定 v%d，%s）", username, gameID, protocolVersion, encoding)
 }
 
-// 廣播房間狀態給所有玩家
-func (wsc *WebSocketController) broadcastRoomStatus(gameID string, eventMessage string) error {
-	// 使用 Redis GameManager (services.GameManager)
-	gameManager := wsc.wsService.GetRedisGameManager()
-	if gameManager == nil {
-		return fmt.Errorf("Redis遊戲管理器未初始化")
+// 大廳連線：不加入任何房間，用來接收好友的遊戲邀請並標記為在線
+func (wsc *WebSocketController) HandleLobbyWebSocket(c *gin.Context) {
+	protocolVersion, encoding, responseHeader, ok := negotiateConnection(c)
+	if !ok {
+		return
 	}
 
-	// 獲取遊戲狀態 - 使用 services.GameManager 的方法
-	gameState, err := gameManager.GetAGameStatus(gameID)
+	conn, err := models.Upgrader.Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
-		log.Printf("獲取遊戲狀態失敗: %v", err)
-		return err
+		log.Printf("WebSocket 升級失敗: %v", err)
+		return
 	}
 
-	// 準備玩家列表數據
-	players := make([]map[string]interface{}, 0)
-	readyCount := 0
-	totalPlayers := len(gameState.Players)
-
-	for _, player := range gameState.Players {
-		players = append(players, map[string]interface{}{
-			"uuid":    player.Uuid,
-			"name":    player.Name,
-			"isReady": player.Ready,
-		})
+	client := &ws.LobbyClient{
+		ChatHub:    wsc.wsService.GetChatHub(),
+		Conn:       conn,
+		Send:       make(chan []byte, 64),
+		PlayerUuid: c.GetString("uuid"),
+		PlayerName: c.GetString("username"),
+		SessionID:  c.GetString("sid"),
 
-		if player.Ready {
-			readyCount++
-		}
+		ProtocolVersion: protocolVersion,
+		Encoding:        encoding,
 	}
+	client.ChatHub.JoinLobby(client)
 
-	// 發送系統訊息
-	if eventMessage != "" {
-		eventMsg := models.GameMessage{
-			Type:      "system",
-			GameId:    gameID,
-			Message:   eventMessage,
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		wsc.wsService.GetChatHub().BroadcastGameMessage(gameID, &eventMsg)
-		time.Sleep(50 * time.Millisecond)
-	}
+	go client.WritePump()
+	go client.ReadPump()
+}
 
-	// 廣播完整房間狀態
-	roomStatusMsg := models.GameMessage{
-		Type:    "room_status_update",
-		GameId:  gameID,
-		Message: fmt.Sprintf("房間狀態更新: %d/%d 玩家，%d/%d 已準備", totalPlayers, gameState.NumOfPeople, readyCount, totalPlayers),
-		From:    "系統",
-		Players: players,
-		GameInfo: map[string]interface{}{
-			"maxPlayers":     gameState.NumOfPeople,
-			"currentPlayers": totalPlayers,
-			"readyCount":     readyCount,
-			"gameStatus":     gameState.Status,
-			"minRange":       gameState.MinRange,
-			"maxRange":       gameState.MaxRange,
-		},
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+// 協商協定版本與編碼；版本不支援時回傳 400，ok 為 false
+func negotiateConnection(c *gin.Context) (int, string, http.Header, bool) {
+	// 協商協定版本，未指定時使用舊版格式
+	protocolVersion, err := protocol.Negotiate(c.Query(protocol.VersionQueryParam))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             err.Error(),
+			"code":              protocol.CodeOf(err),
+			"supportedVersions": protocol.SupportedVersions(),
+		})
+		return 0, "", nil, false
 	}
 
-	wsc.wsService.GetChatHub().BroadcastGameMessage(gameID, &roomStatusMsg)
-	log.Printf("✅ 成功廣播房間狀態到房間 %s", gameID)
-
-	return nil
+	// 以 subprotocol 協商編碼，依客戶端列出的順序選第一個支援的；未要求時使用 JSON 且不回傳 subprotocol
+	var responseHeader http.Header
+	encoding := protocol.NegotiateEncoding(websocket.Subprotocols(c.Request))
+	if encoding != "" {
+		responseHeader = http.Header{"Sec-WebSocket-Protocol": []string{encoding}}
+	} else {
+		encoding = protocol.EncodingJSON
+	}
+	return protocolVersion, encoding, responseHeader, true
 }
 
 // 保留舊的 WebSocketHandler 作為備用
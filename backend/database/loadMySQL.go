@@ -31,6 +31,16 @@ func InitMySQL(cfg *config.MySQL) (*gorm.DB, error) {
 		&models.Users{},
 		&models.GameResults{},
 		&models.GamePlayers{},
+		&models.DailyResults{},
+		&models.DailyStreaks{},
+		&models.ChatReports{},
+		&models.ChatArchives{},
+		&models.Friendships{},
+		&models.Notifications{},
+		&models.UserAchievements{},
+		&models.RefreshTokens{},
+		&models.Sessions{},
+		&models.UserTokens{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to migrate tables: %w", err)
 	}
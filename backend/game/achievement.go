@@ -0,0 +1,86 @@
+package game
+
+// AchievementStats 評估成就時玩家的生涯資料，已包含剛結束的這一輪
+type AchievementStats struct {
+	GamesPlayed  int64
+	Wins         int64
+	WinStreak    int  // 目前連勝場數
+	Won          bool // 本輪是否獲勝
+	GuessCount   int  // 本輪猜測次數
+	TotalPlayers int  // 本輪房間人數
+}
+
+// Achievement 一項成就；Unlocked 只依 AchievementStats 判斷，新增成就只要在 Achievements 加一筆
+type Achievement struct {
+	Code        string
+	Name        string
+	Description string
+	Unlocked    func(stats AchievementStats) bool
+}
+
+// Achievements 所有成就，依顯示順序排列；Code 寫入資料庫，不可更改
+var Achievements = []Achievement{
+	{
+		Code:        "first_win",
+		Name:        "初試啼聲",
+		Description: "贏得第一場遊戲",
+		Unlocked:    func(s AchievementStats) bool { return s.Wins >= 1 },
+	},
+	{
+		Code:        "one_guess_win",
+		Name:        "一發入魂",
+		Description: "只猜一次就獲勝",
+		Unlocked:    func(s AchievementStats) bool { return s.Won && s.GuessCount == 1 },
+	},
+	{
+		Code:        "win_streak_10",
+		Name:        "十連勝",
+		Description: "連續贏得 10 場遊戲",
+		Unlocked:    func(s AchievementStats) bool { return s.WinStreak >= 10 },
+	},
+	{
+		Code:        "full_house_win",
+		Name:        "技壓全場",
+		Description: "在 10 人以上的房間中獲勝",
+		Unlocked:    func(s AchievementStats) bool { return s.Won && s.TotalPlayers >= 10 },
+	},
+	{
+		Code:        "games_played_100",
+		Name:        "百戰老將",
+		Description: "累計參與 100 場遊戲",
+		Unlocked:    func(s AchievementStats) bool { return s.GamesPlayed >= 100 },
+	},
+}
+
+// FindAchievement 依代碼找出成就定義
+func FindAchievement(code string) (Achievement, bool) {
+	for _, achievement := range Achievements {
+		if achievement.Code == code {
+			return achievement, true
+		}
+	}
+	return Achievement{}, false
+}
+
+// EvaluateAchievements 回傳這次新達成的成就，unlocked 為玩家已擁有的成就代碼
+func EvaluateAchievements(stats AchievementStats, unlocked map[string]bool) []Achievement {
+	var earned []Achievement
+	for _, achievement := range Achievements {
+		if !unlocked[achievement.Code] && achievement.Unlocked(stats) {
+			earned = append(earned, achievement)
+		}
+	}
+	return earned
+}
+
+// CurrentStreak 由新到舊的勝負紀錄中目前的連勝場數
+func CurrentStreak(outcomes []bool) int {
+	streak := 0
+	for _, won := range outcomes {
+		if !won {
+			break
+		}
+		streak++
+	}
+	return streak
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCurrentStreak(t *testing.T) {
+	tests := []struct {
+		outcomes []bool
+		want     int
+	}{
+		{nil, 0},
+		{[]bool{false}, 0},
+		{[]bool{true}, 1},
+		{[]bool{true, true, true}, 3},
+		{[]bool{true, true, false, true, true, true}, 2}, // 只算最新的連勝
+		{[]bool{false, true, true}, 0},
+	}
+	for _, tt := range tests {
+		if got := CurrentStreak(tt.outcomes); got != tt.want {
+			t.Errorf("CurrentStreak(%v) = %d，預期 %d", tt.outcomes, got, tt.want)
+		}
+	}
+}
+
+func codes(achievements []Achievement) []string {
+	var result []string
+	for _, achievement := range achievements {
+		result = append(result, achievement.Code)
+	}
+	return result
+}
+
+func TestEvaluateAchievements(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    AchievementStats
+		unlocked map[string]bool
+		want     []string
+	}{
+		{
+			name:  "輸掉第一場",
+			stats: AchievementStats{GamesPlayed: 1, GuessCount: 3, TotalPlayers: 2},
+			want:  nil,
+		},
+		{
+			name:  "一猜就贏得第一場",
+			stats: AchievementStats{GamesPlayed: 1, Wins: 1, WinStreak: 1, Won: true, GuessCount: 1, TotalPlayers: 2},
+			want:  []string{"first_win", "one_guess_win"},
+		},
+		{
+			name:     "已擁有的成就不會重複給",
+			stats:    AchievementStats{GamesPlayed: 5, Wins: 2, WinStreak: 1, Won: true, GuessCount: 1, TotalPlayers: 2},
+			unlocked: map[string]bool{"first_win": true, "one_guess_win": true},
+			want:     nil,
+		},
+		{
+			name:     "第十連勝",
+			stats:    AchievementStats{GamesPlayed: 20, Wins: 12, WinStreak: 10, Won: true, GuessCount: 4, TotalPlayers: 3},
+			unlocked: map[string]bool{"first_win": true},
+			want:     []string{"win_streak_10"},
+		},
+		{
+			name:     "大房間獲勝與第 100 場",
+			stats:    AchievementStats{GamesPlayed: 100, Wins: 30, WinStreak: 1, Won: true, GuessCount: 5, TotalPlayers: 10},
+			unlocked: map[string]bool{"first_win": true},
+			want:     []string{"full_house_win", "games_played_100"},
+		},
+		{
+			name:     "大房間輸了不算",
+			stats:    AchievementStats{GamesPlayed: 50, Wins: 30, Won: false, GuessCount: 1, TotalPlayers: 12},
+			unlocked: map[string]bool{"first_win": true},
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		if got := codes(EvaluateAchievements(tt.stats, tt.unlocked)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: EvaluateAchievements = %v，預期 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindAchievement(t *testing.T) {
+	for _, achievement := range Achievements {
+		found, ok := FindAchievement(achievement.Code)
+		if !ok || found.Name != achievement.Name {
+			t.Errorf("FindAchievement(%s) = (%v, %v)", achievement.Code, found.Name, ok)
+		}
+	}
+	if _, ok := FindAchievement("unknown"); ok {
+		t.Error("不存在的成就不應找到")
+	}
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+	"time"
+)
+
+// AnswerGenerator 產生遊戲答案，讓 RedisGameManager 可以注入不同的出題方式
+type AnswerGenerator interface {
+	Generate(min int, max int) int
+}
+
+// RandomAnswerGenerator 使用 math/rand 隨機出題（一般房間預設）
+type RandomAnswerGenerator struct{}
+
+func NewRandomAnswerGenerator() *RandomAnswerGenerator {
+	return &RandomAnswerGenerator{}
+}
+
+func (r *RandomAnswerGenerator) Generate(min int, max int) int {
+	if max <= min {
+		return min
+	}
+	return rand.Intn(max-min+1) + min
+}
+
+// DailyAnswerGenerator 由伺服器密鑰與日期推導答案，同一天所有玩家拿到相同的數字
+type DailyAnswerGenerator struct {
+	secret []byte
+}
+
+func NewDailyAnswerGenerator(secret string) *DailyAnswerGenerator {
+	return &DailyAnswerGenerator{secret: []byte(secret)}
+}
+
+// AnswerFor 回傳指定日期（格式 2006-01-02）的答案
+func (d *DailyAnswerGenerator) AnswerFor(date string, min int, max int) int {
+	if max <= min {
+		return min
+	}
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte(date))
+	sum := mac.Sum(nil)
+	n := binary.BigEndian.Uint64(sum[:8])
+	return int(n%uint64(max-min+1)) + min
+}
+
+// Generate 以今天的日期出題，實作 AnswerGenerator
+func (d *DailyAnswerGenerator) Generate(min int, max int) int {
+	return d.AnswerFor(DailyDate(time.Now()), min, max)
+}
+
+// DailyDate 回傳每日挑戰使用的日期字串
+func DailyDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyAnswerGeneratorDeterministic(t *testing.T) {
+	a := NewDailyAnswerGenerator("secret")
+	b := NewDailyAnswerGenerator("secret")
+	for _, date := range []string{"2024-01-01", "2024-02-29", "2025-12-31"} {
+		if got, want := a.AnswerFor(date, 1, 100), b.AnswerFor(date, 1, 100); got != want {
+			t.Errorf("AnswerFor(%s) = %d, 另一個實例為 %d", date, got, want)
+		}
+	}
+
+	// 不同密鑰或日期應該得到不同的答案序列
+	other := NewDailyAnswerGenerator("other")
+	same := true
+	for day := 0; day < 30; day++ {
+		date := DailyDate(time.Date(2024, 1, 1+day, 0, 0, 0, 0, time.UTC))
+		if a.AnswerFor(date, 1, 1000) != other.AnswerFor(date, 1, 1000) {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Error("不同密鑰 30 天的答案完全相同")
+	}
+}
+
+func TestDailyAnswerGeneratorRange(t *testing.T) {
+	gen := NewDailyAnswerGenerator("secret")
+	tests := []struct {
+		min, max int
+	}{
+		{1, 100},
+		{1, 2},
+		{-50, 50},
+		{7, 7},
+		{10, 3}, // max 小於 min 時回傳 min
+	}
+	for _, tt := range tests {
+		for day := 0; day < 365; day++ {
+			date := DailyDate(time.Date(2024, 1, 1+day, 0, 0, 0, 0, time.UTC))
+			got := gen.AnswerFor(date, tt.min, tt.max)
+			if tt.max <= tt.min {
+				if got != tt.min {
+					t.Fatalf("AnswerFor(%s, %d, %d) = %d, want %d", date, tt.min, tt.max, got, tt.min)
+				}
+				continue
+			}
+			if got < tt.min || got > tt.max {
+				t.Fatalf("AnswerFor(%s, %d, %d) = %d 超出範圍", date, tt.min, tt.max, got)
+			}
+		}
+	}
+}
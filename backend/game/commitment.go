@@ -0,0 +1,34 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+)
+
+// CommitmentScheme 說明承諾值的計算方式，提供給客戶端自行驗證
+const CommitmentScheme = "sha256(answer:salt)"
+
+// NewCommitment 為答案產生隨機鹽值與承諾值，遊戲開始時公開承諾值、結束時公開答案與鹽值
+func NewCommitment(answer int) (string, string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("產生鹽值失敗: %v", err)
+	}
+	salt := hex.EncodeToString(buf)
+	return salt, Commit(answer, salt), nil
+}
+
+// Commit 計算 sha256("答案:鹽值") 的十六進位字串
+func Commit(answer int, salt string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", answer, salt)))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyCommitment 檢查公開的答案與鹽值是否符合先前的承諾值
+func VerifyCommitment(answer int, salt string, commitment string) bool {
+	expected := Commit(answer, salt)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(commitment)) == 1
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"fmt"
+
+	"game/models"
+)
+
+// 距離區間，以與答案的距離佔數字範圍的百分比判斷，由近到遠
+var proximityBands = []struct {
+	percent int
+	band    string
+	label   string
+}{
+	{2, models.BandBurning, "快燒起來了"},
+	{5, models.BandHot, "很燙"},
+	{10, models.BandWarm, "溫暖"},
+	{20, models.BandCool, "涼涼的"},
+	{40, models.BandCold, "很冷"},
+}
+
+// BuildFeedback 依房間的回饋模式產生結構化的猜測回饋，previous 為同一題上一個猜測（沒有則為 nil）
+func BuildFeedback(mode string, guess int, answer int, min int, max int, previous *int) *models.GuessFeedback {
+	feedback := &models.GuessFeedback{
+		Mode:    mode,
+		Guess:   guess,
+		Correct: guess == answer,
+	}
+	if feedback.Correct {
+		return feedback
+	}
+
+	switch mode {
+	case models.FeedbackProximityAnswer:
+		feedback.Band = Band(guess, answer, min, max)
+	case models.FeedbackProximityPrevious:
+		if previous == nil {
+			// 第一個猜測沒有比較對象，先給出與答案的距離區間
+			feedback.Band = Band(guess, answer, min, max)
+			break
+		}
+		feedback.PreviousGuess = previous
+		current, last := distance(guess, answer), distance(*previous, answer)
+		switch {
+		case current < last:
+			feedback.Trend = models.TrendWarmer
+		case current > last:
+			feedback.Trend = models.TrendColder
+		default:
+			feedback.Trend = models.TrendSame
+		}
+	default:
+		feedback.Mode = models.FeedbackDirection
+		if guess > answer {
+			feedback.Direction = models.DirectionTooBig
+		} else {
+			feedback.Direction = models.DirectionTooSmall
+		}
+	}
+	return feedback
+}
+
+// Band 回傳猜測與答案的距離區間
+func Band(guess int, answer int, min int, max int) string {
+	width := max - min + 1
+	if width <= 0 {
+		width = 1
+	}
+	d := distance(guess, answer)
+	for _, b := range proximityBands {
+		if d*100 <= b.percent*width {
+			return b.band
+		}
+	}
+	return models.BandFreezing
+}
+
+// FeedbackMessage 將回饋轉成顯示給玩家的中文句子
+func FeedbackMessage(feedback *models.GuessFeedback) string {
+	if feedback.Correct {
+		return "恭喜你猜對了！"
+	}
+	switch {
+	case feedback.Direction == models.DirectionTooBig:
+		return fmt.Sprintf("猜的數字 %d 太大了", feedback.Guess)
+	case feedback.Direction == models.DirectionTooSmall:
+		return fmt.Sprintf("猜的數字 %d 太小了", feedback.Guess)
+	case feedback.Trend == models.TrendWarmer:
+		return fmt.Sprintf("%d 比上一個猜測 %d 更接近了", feedback.Guess, *feedback.PreviousGuess)
+	case feedback.Trend == models.TrendColder:
+		return fmt.Sprintf("%d 比上一個猜測 %d 更遠了", feedback.Guess, *feedback.PreviousGuess)
+	case feedback.Trend == models.TrendSame:
+		return fmt.Sprintf("%d 和上一個猜測 %d 一樣遠", feedback.Guess, *feedback.PreviousGuess)
+	}
+	for _, b := range proximityBands {
+		if b.band == feedback.Band {
+			return fmt.Sprintf("猜的數字 %d：%s", feedback.Guess, b.label)
+		}
+	}
+	return fmt.Sprintf("猜的數字 %d：冷到結冰", feedback.Guess)
+}
+
+func distance(a int, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
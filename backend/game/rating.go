@@ -0,0 +1,37 @@
+package game
+
+import "math"
+
+// 新玩家的初始積分與每場的 K 值
+const (
+	DefaultRating = 1000
+	ratingK       = 32
+)
+
+// EloDeltas 多人對戰的 Elo 積分變化：每位玩家與其他每位玩家各算一場，
+// 勝者對敗者為勝、同為勝者或同為敗者為和局，K 值依對手數平分，避免人多的房間積分波動過大
+func EloDeltas(ratings map[string]int, winners map[string]bool) map[string]int {
+	deltas := make(map[string]int, len(ratings))
+	if len(ratings) < 2 {
+		return deltas
+	}
+	k := float64(ratingK) / float64(len(ratings)-1)
+	for player, rating := range ratings {
+		change := 0.0
+		for opponent, opponentRating := range ratings {
+			if opponent == player {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, float64(opponentRating-rating)/400))
+			actual := 0.5
+			if winners[player] && !winners[opponent] {
+				actual = 1
+			} else if !winners[player] && winners[opponent] {
+				actual = 0
+			}
+			change += k * (actual - expected)
+		}
+		deltas[player] = int(math.Round(change))
+	}
+	return deltas
+}
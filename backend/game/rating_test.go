@@ -0,0 +1,63 @@
+package game
+
+import "testing"
+
+func TestEloDeltasTwoPlayers(t *testing.T) {
+	deltas := EloDeltas(map[string]int{"a": 1000, "b": 1000}, map[string]bool{"a": true})
+	if deltas["a"] != 16 || deltas["b"] != -16 {
+		t.Errorf("同分對戰 = %v，預期勝者 +16、敗者 -16", deltas)
+	}
+
+	// 積分差距越大，爆冷的一方得分越多，但兩邊的變化仍然對稱
+	upset := EloDeltas(map[string]int{"a": 1000, "b": 1400}, map[string]bool{"a": true})
+	expected := EloDeltas(map[string]int{"a": 1000, "b": 1400}, map[string]bool{"b": true})
+	if upset["a"] <= 16 || upset["a"] != -upset["b"] {
+		t.Errorf("低分者獲勝 = %v，預期得分超過 16 且兩邊對稱", upset)
+	}
+	if expected["b"] >= 16 || expected["b"] != -expected["a"] {
+		t.Errorf("高分者獲勝 = %v，預期得分少於 16 且兩邊對稱", expected)
+	}
+}
+
+func TestEloDeltasSymmetric(t *testing.T) {
+	ratings := map[string]int{"a": 1200, "b": 1000, "c": 950, "d": 1100, "e": 1000}
+	for winner := range ratings {
+		deltas := EloDeltas(ratings, map[string]bool{winner: true})
+		sum := 0
+		for _, delta := range deltas {
+			sum += delta
+		}
+		// 積分只在玩家之間移轉，四捨五入最多造成每人 1 分的誤差
+		if sum < -len(ratings) || sum > len(ratings) {
+			t.Errorf("%s 獲勝時積分總變化 %d，預期接近 0: %v", winner, sum, deltas)
+		}
+		if deltas[winner] <= 0 {
+			t.Errorf("%s 獲勝卻沒有加分: %v", winner, deltas)
+		}
+	}
+}
+
+func TestEloDeltasTeams(t *testing.T) {
+	ratings := map[string]int{"a1": 1000, "a2": 1000, "b1": 1000, "b2": 1000}
+	deltas := EloDeltas(ratings, map[string]bool{"a1": true, "a2": true})
+
+	// 隊友之間算和局，同分時只有對上敗隊的兩場有積分變化：2 × (32/3) × 0.5 ≈ 11
+	for player, want := range map[string]int{"a1": 11, "a2": 11, "b1": -11, "b2": -11} {
+		if deltas[player] != want {
+			t.Errorf("隊伍模式 %s = %d，預期 %d（%v）", player, deltas[player], want, deltas)
+		}
+	}
+}
+
+func TestEloDeltasEdgeCases(t *testing.T) {
+	if deltas := EloDeltas(map[string]int{"a": 1000}, map[string]bool{"a": true}); len(deltas) != 0 {
+		t.Errorf("只有一位玩家時不應有積分變化: %v", deltas)
+	}
+	// 沒有勝者時全部視為和局，同分不會有變化
+	deltas := EloDeltas(map[string]int{"a": 1000, "b": 1000, "c": 1000}, nil)
+	for player, delta := range deltas {
+		if delta != 0 {
+			t.Errorf("全部和局時 %s 的積分變化為 %d", player, delta)
+		}
+	}
+}
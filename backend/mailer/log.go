@@ -0,0 +1,18 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer 不寄出信件，只把內容寫入日誌，供開發環境使用
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("[mail] 收件人: %s，主旨: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
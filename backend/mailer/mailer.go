@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"game/config"
+)
+
+// 未設定 MAIL_FROM 時的寄件人
+const DefaultFrom = "no-reply@localhost"
+
+// Message 純文字信件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 寄送驗證信與重設密碼信
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer 依設定建立寄信方式，未設定 MAIL_DRIVER 時只寫入日誌
+func NewMailer(cfg config.Mail) (Mailer, error) {
+	from := cfg.From
+	if from == "" {
+		from = DefaultFrom
+	}
+	switch strings.ToLower(cfg.Driver) {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, from)
+	default:
+		return nil, fmt.Errorf("不支援的寄信方式: %s", cfg.Driver)
+	}
+}
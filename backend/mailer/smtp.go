@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// 未設定 SMTP_PORT 時使用的連接埠
+const defaultSMTPPort = 25
+
+// SMTPMailer 透過 SMTP 寄信；伺服器支援時使用 STARTTLS，未設定帳號時不驗證（MailHog 等本機測試伺服器）
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port int, username string, password string, from string) (*SMTPMailer, error) {
+	if host == "" {
+		return nil, fmt.Errorf("SMTP 需要設定 SMTP_HOST")
+	}
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	// smtp.SendMail 不支援 context，改在另一個 goroutine 寄送並等待逾時
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, auth, m.from, []string{msg.To}, m.compose(msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 組成 UTF-8 純文字信件，主旨以 MIME encoded-word 編碼
+func (m *SMTPMailer) compose(msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(msg.Body)
+	return buf.Bytes()
+}
@@ -28,7 +28,7 @@ func main() {
 	}
 	defer database.CloseRedis(rds)
 
-	r := routes.SetupRoutes(db, rds)
+	r := routes.SetupRoutes(db, rds, &appConfig)
 	if err := r.Run(":8080"); err != nil {
 		panic(err)
 	}
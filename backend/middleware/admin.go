@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"game/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserStore 查詢使用者目前的 email 與驗證狀態
+type AdminUserStore interface {
+	GetUserProfile(id string) (models.Users, error)
+}
+
+// 管理員中間件：使用者目前的 email 必須在 ADMIN_EMAILS 名單內且已完成驗證，需放在 JWTAuthGame 之後。
+// 以資料庫中的資料為準，避免未驗證或已變更的 email 取得管理員權限
+func AdminOnly(adminEmails string, users AdminUserStore) gin.HandlerFunc {
+	admins := make(map[string]bool)
+	for _, email := range strings.Split(adminEmails, ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			admins[email] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		if !isAdmin(admins, users, c.GetString("uuid")) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "需要管理員權限",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func isAdmin(admins map[string]bool, users AdminUserStore, uuid string) bool {
+	if len(admins) == 0 || uuid == "" {
+		return false
+	}
+	user, err := users.GetUserProfile(uuid)
+	if err != nil {
+		log.Printf("查詢管理員 %s 失敗: %v", uuid, err)
+		return false
+	}
+	return user.Email != nil && user.EmailVerifiedAt != nil && admins[strings.ToLower(*user.Email)]
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"game/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAdminUsers map[string]models.Users
+
+func (f fakeAdminUsers) GetUserProfile(id string) (models.Users, error) {
+	user, ok := f[id]
+	if !ok {
+		return user, errors.New("找不到使用者")
+	}
+	return user, nil
+}
+
+func TestAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verified := time.Now()
+	email := func(s string) *string { return &s }
+	users := fakeAdminUsers{
+		"admin":      {ID: "admin", Email: email("Admin@Example.com"), EmailVerifiedAt: &verified},
+		"unverified": {ID: "unverified", Email: email("admin@example.com")},
+		"player":     {ID: "player", Email: email("player@example.com"), EmailVerifiedAt: &verified},
+		"no-email":   {ID: "no-email", EmailVerifiedAt: &verified},
+	}
+
+	tests := []struct {
+		name   string
+		admins string
+		uuid   string
+		want   int
+	}{
+		{"已驗證的管理員，email 不分大小寫", "admin@example.com", "admin", http.StatusOK},
+		{"email 在名單內但尚未驗證", "admin@example.com", "unverified", http.StatusForbidden},
+		{"不在名單內", "admin@example.com", "player", http.StatusForbidden},
+		{"沒有 email", "admin@example.com", "no-email", http.StatusForbidden},
+		{"使用者不存在", "admin@example.com", "ghost", http.StatusForbidden},
+		{"未設定管理員", "", "admin", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.GET("/admin", func(c *gin.Context) {
+			c.Set("uuid", tt.uuid)
+		}, AdminOnly(tt.admins, users), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin", nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: 狀態碼 = %d，預期 %d", tt.name, recorder.Code, tt.want)
+		}
+	}
+}
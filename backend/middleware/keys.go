@@ -0,0 +1,311 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"game/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支援的簽章演算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const defaultKid = "default"
+
+// RSA 金鑰長度下限
+const minRSABits = 2048
+
+// HS256 密鑰長度下限（bytes），與雜湊輸出等長
+const minHMACBytes = 32
+
+// 單一金鑰；private 為 nil 代表已退役、只用來驗證輪替前簽發的 token
+type signingKey struct {
+	kid     string
+	private interface{}
+	public  interface{}
+}
+
+// KeySet Game JWT 的金鑰組，以 active 金鑰簽發，token header 的 kid 決定用哪把金鑰驗證，
+// 輪替時新舊金鑰同時存在，舊 token 在過期前仍然有效
+type KeySet struct {
+	method jwt.SigningMethod
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// JWK JWKS 中的單一公鑰（RFC 7517）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP 曲線
+	X   string `json:"x,omitempty"`   // OKP 公鑰
+}
+
+// JWKS 對外公開的公鑰集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// 目前使用的金鑰組，啟動時由 SetKeySet 設定；尚未設定時無法簽發或驗證 token
+var gameKeys *KeySet
+
+var errNoKeySet = errors.New("尚未設定 JWT 金鑰")
+
+// SetKeySet 設定簽發與驗證 Game JWT 的金鑰組，需在開始處理請求前呼叫
+func SetKeySet(keys *KeySet) {
+	gameKeys = keys
+}
+
+// NewKeySet 依設定建立金鑰組：
+// HS256 的 JWT_KEYS 值為密鑰本身，RS256/EdDSA 的值為 PEM 檔案路徑或 PEM 內容（換行可寫成 \n）；
+// 私鑰可用來簽發，只有公鑰的金鑰只用來驗證
+func NewKeySet(cfg config.JWT) (*KeySet, error) {
+	alg := cfg.Alg
+	if alg == "" {
+		alg = AlgHS256
+	}
+
+	entries, err := parseKeyEntries(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 && alg == AlgHS256 {
+		secret := cfg.HMACSecret
+		if secret == "" {
+			if !cfg.AllowDevSecret {
+				return nil, fmt.Errorf("未設定 JWT_KEYS 或 JWT_HMAC_SECRET；本機開發可設定 JWT_ALLOW_DEV_SECRET=true 改用隨機密鑰")
+			}
+			log.Println("未設定 JWT 金鑰，使用隨機的開發用密鑰，重啟後所有 token 都會失效，請勿用於正式環境")
+			random := make([]byte, 32)
+			if _, err := rand.Read(random); err != nil {
+				return nil, err
+			}
+			secret = base64.RawURLEncoding.EncodeToString(random)
+		}
+		kid := cfg.ActiveKid
+		if kid == "" {
+			kid = defaultKid
+		}
+		entries = [][2]string{{kid, secret}}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s 需要在 JWT_KEYS 設定至少一把金鑰", alg)
+	}
+
+	keySet := &KeySet{keys: make(map[string]*signingKey)}
+	switch alg {
+	case AlgHS256:
+		keySet.method = jwt.SigningMethodHS256
+	case AlgRS256:
+		keySet.method = jwt.SigningMethodRS256
+	case AlgEdDSA:
+		keySet.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("不支援的 JWT_ALG: %s", alg)
+	}
+
+	for _, entry := range entries {
+		kid, value := entry[0], entry[1]
+		if _, ok := keySet.keys[kid]; ok {
+			return nil, fmt.Errorf("重複的 kid: %s", kid)
+		}
+		key, err := loadKey(alg, kid, value)
+		if err != nil {
+			return nil, err
+		}
+		keySet.keys[kid] = key
+	}
+
+	// 未指定 JWT_ACTIVE_KID 時以第一把金鑰簽發
+	activeKid := cfg.ActiveKid
+	if activeKid == "" {
+		activeKid = entries[0][0]
+	}
+	active, ok := keySet.keys[activeKid]
+	if !ok {
+		return nil, fmt.Errorf("JWT_ACTIVE_KID %s 不在 JWT_KEYS 中", activeKid)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("金鑰 %s 只有公鑰，無法用來簽發", activeKid)
+	}
+	keySet.active = active
+
+	return keySet, nil
+}
+
+// 解析「kid=值」並以逗號分隔的清單
+func parseKeyEntries(raw string) ([][2]string, error) {
+	var entries [][2]string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kid, value, ok := strings.Cut(item, "=")
+		kid, value = strings.TrimSpace(kid), strings.TrimSpace(value)
+		if !ok || kid == "" || value == "" {
+			return nil, fmt.Errorf("JWT_KEYS 格式錯誤，應為 kid=值: %s", item)
+		}
+		entries = append(entries, [2]string{kid, value})
+	}
+	return entries, nil
+}
+
+func loadKey(alg, kid, value string) (*signingKey, error) {
+	if alg == AlgHS256 {
+		if len(value) < minHMACBytes {
+			return nil, fmt.Errorf("金鑰 %s 長度不足 %d bytes", kid, minHMACBytes)
+		}
+		return &signingKey{kid: kid, private: []byte(value), public: []byte(value)}, nil
+	}
+
+	data := []byte(strings.ReplaceAll(value, `\n`, "\n"))
+	if !strings.Contains(value, "-----BEGIN") {
+		file, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("讀取金鑰 %s 失敗: %w", kid, err)
+		}
+		data = file
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("金鑰 %s 不是 PEM 格式", kid)
+	}
+
+	var private, public interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析金鑰 %s 失敗: %w", kid, err)
+		}
+		private = parsed
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析金鑰 %s 失敗: %w", kid, err)
+		}
+		private = parsed
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析金鑰 %s 失敗: %w", kid, err)
+		}
+		public = parsed
+	default:
+		return nil, fmt.Errorf("金鑰 %s 的 PEM 類型 %s 不支援", kid, block.Type)
+	}
+
+	// 依演算法檢查金鑰類型，並由私鑰推出公鑰
+	switch alg {
+	case AlgRS256:
+		if key, ok := private.(*rsa.PrivateKey); ok {
+			public = &key.PublicKey
+		}
+		key, ok := public.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("金鑰 %s 不是 RSA 金鑰", kid)
+		}
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("金鑰 %s 長度不足 %d bits", kid, minRSABits)
+		}
+	case AlgEdDSA:
+		if key, ok := private.(ed25519.PrivateKey); ok {
+			public = key.Public()
+		}
+		if _, ok := public.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("金鑰 %s 不是 Ed25519 金鑰", kid)
+		}
+	}
+
+	return &signingKey{kid: kid, private: private, public: public}, nil
+}
+
+// Sign 以 active 金鑰簽發，header 帶 kid
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k == nil {
+		return "", errNoKeySet
+	}
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.active.kid
+	return token.SignedString(k.active.private)
+}
+
+// Parse 驗證 token，只接受設定的演算法，避免以公鑰當 HMAC 密鑰的演算法混淆攻擊
+func (k *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	if k == nil {
+		return nil, errNoKeySet
+	}
+	return jwt.ParseWithClaims(tokenString, claims, k.keyFunc, jwt.WithValidMethods([]string{k.method.Alg()}))
+}
+
+// 依 kid 選擇驗證金鑰；沒有 kid 的舊 token 以 active 金鑰驗證
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return k.active.public, nil
+	}
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("未知的 kid: %s", kid)
+	}
+	return key.public, nil
+}
+
+// JWKS 公開驗證用的公鑰，HS256 的密鑰不會公開
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	if k == nil {
+		return jwks
+	}
+	for _, key := range k.keys {
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: AlgRS256,
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: AlgEdDSA,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// GameJWKS 目前金鑰組的公鑰
+func GameJWKS() JWKS {
+	return gameKeys.JWKS()
+}
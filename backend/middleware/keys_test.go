@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"game/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecretA = "0123456789abcdef0123456789abcdef"
+	testSecretB = "fedcba9876543210fedcba9876543210"
+)
+
+// 將金鑰編成 JWT_KEYS 可用的單行 PEM 內容
+func pemValue(t *testing.T, blockType string, der []byte, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("編碼金鑰失敗: %v", err)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	return strings.ReplaceAll(strings.TrimSpace(string(encoded)), "\n", `\n`)
+}
+
+func privatePEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	return pemValue(t, "PRIVATE KEY", der, err)
+}
+
+func publicPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	return pemValue(t, "PUBLIC KEY", der, err)
+}
+
+func newTestKeySet(t *testing.T, cfg config.JWT) *KeySet {
+	t.Helper()
+	keys, err := NewKeySet(cfg)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	return keys
+}
+
+func testClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{Subject: "u1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+}
+
+func TestNewKeySetConfig(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		cfg     config.JWT
+		wantErr bool
+	}{
+		{"沒有金鑰時拒絕啟動", config.JWT{}, true},
+		{"開發模式使用隨機密鑰", config.JWT{AllowDevSecret: true}, false},
+		{"JWT_HMAC_SECRET", config.JWT{HMACSecret: testSecretA}, false},
+		{"JWT_HMAC_SECRET 不足 32 bytes", config.JWT{HMACSecret: "short-secret"}, true},
+		{"開發模式也不接受過短的密鑰", config.JWT{HMACSecret: "short-secret", AllowDevSecret: true}, true},
+		{"JWT_KEYS 中的 HS256 密鑰不足 32 bytes", config.JWT{Keys: "a=" + testSecretA + ",b=short-secret"}, true},
+		{"重複的 kid", config.JWT{Keys: "a=" + testSecretA + ",a=" + testSecretB}, true},
+		{"JWT_ACTIVE_KID 不存在", config.JWT{Keys: "a=" + testSecretA, ActiveKid: "b"}, true},
+		{"JWT_KEYS 格式錯誤", config.JWT{Keys: "a"}, true},
+		{"不支援的演算法", config.JWT{Alg: "HS512", Keys: "a=" + testSecretA}, true},
+		{"RS256 需要 JWT_KEYS", config.JWT{Alg: AlgRS256, HMACSecret: testSecretA}, true},
+		{"RS256", config.JWT{Alg: AlgRS256, Keys: "a=" + privatePEM(t, rsaKey)}, false},
+		{"RSA 金鑰長度不足", config.JWT{Alg: AlgRS256, Keys: "a=" + privatePEM(t, weakRSA)}, true},
+		{"RS256 不接受 Ed25519 金鑰", config.JWT{Alg: AlgRS256, Keys: "a=" + privatePEM(t, edKey)}, true},
+		{"EdDSA", config.JWT{Alg: AlgEdDSA, Keys: "a=" + privatePEM(t, edKey)}, false},
+		{"只有公鑰的金鑰不能用來簽發", config.JWT{Alg: AlgEdDSA, Keys: "a=" + publicPEM(t, edKey.Public())}, true},
+		{"不是 PEM 內容也不是檔案", config.JWT{Alg: AlgEdDSA, Keys: "a=/nonexistent/key.pem"}, true},
+	}
+	for _, tt := range tests {
+		if _, err := NewKeySet(tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: NewKeySet 錯誤 = %v，預期錯誤 %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestKeySetSelectsKeyByKid(t *testing.T) {
+	rotated := newTestKeySet(t, config.JWT{Keys: "old=" + testSecretA + ",new=" + testSecretB, ActiveKid: "new"})
+	old := newTestKeySet(t, config.JWT{Keys: "old=" + testSecretA})
+	unknown := newTestKeySet(t, config.JWT{Keys: "other=" + testSecretA})
+	wrongSecret := newTestKeySet(t, config.JWT{Keys: "old=" + testSecretB})
+
+	// 沒有 kid 的舊 token 以 active 金鑰驗證
+	noKid := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	noKidToken, err := noKid.SignedString([]byte(testSecretB))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		signer  *KeySet
+		token   string
+		wantErr bool
+	}{
+		{"以 active 金鑰簽發", rotated, "", false},
+		{"輪替前以舊金鑰簽發", old, "", false},
+		{"未知的 kid", unknown, "", true},
+		{"kid 相同但密鑰不同", wrongSecret, "", true},
+		{"沒有 kid", nil, noKidToken, false},
+	}
+	for _, tt := range tests {
+		token := tt.token
+		if tt.signer != nil {
+			if token, err = tt.signer.Sign(testClaims()); err != nil {
+				t.Fatalf("%s: Sign: %v", tt.name, err)
+			}
+		}
+		if _, err := rotated.Parse(token, &jwt.RegisteredClaims{}); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Parse 錯誤 = %v，預期錯誤 %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	signed, _ := rotated.Sign(testClaims())
+	parsed, _, _ := jwt.NewParser().ParseUnverified(signed, &jwt.RegisteredClaims{})
+	if kid := parsed.Header["kid"]; kid != "new" {
+		t.Errorf("簽發時的 kid = %v，預期 new", kid)
+	}
+}
+
+func TestKeySetRetiredPublicKey(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	before := newTestKeySet(t, config.JWT{Alg: AlgEdDSA, Keys: "old=" + privatePEM(t, oldKey)})
+	after := newTestKeySet(t, config.JWT{Alg: AlgEdDSA, Keys: "new=" + privatePEM(t, newKey) + ",old=" + publicPEM(t, oldKey.Public())})
+
+	// 輪替前簽發的 token 仍能以退役的公鑰驗證
+	token, err := before.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := after.Parse(token, &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("退役的公鑰應仍能驗證舊 token: %v", err)
+	}
+
+	// 退役的公鑰不能再用來簽發
+	if _, err := NewKeySet(config.JWT{Alg: AlgEdDSA, Keys: "new=" + privatePEM(t, newKey) + ",old=" + publicPEM(t, oldKey.Public()), ActiveKid: "old"}); err == nil {
+		t.Error("只有公鑰的金鑰不應被設為 active")
+	}
+	if token, _ := after.Sign(testClaims()); token == "" {
+		t.Error("應以新金鑰簽發")
+	}
+}
+
+func TestKeySetRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaPublic, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	tests := []struct {
+		name   string
+		keys   *KeySet
+		secret []byte // 攻擊者以公開的公鑰當作 HMAC 密鑰
+	}{
+		{"RS256 公鑰 PEM", newTestKeySet(t, config.JWT{Alg: AlgRS256, Keys: "k=" + privatePEM(t, rsaKey)}),
+			pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublic})},
+		{"RS256 公鑰 DER", newTestKeySet(t, config.JWT{Alg: AlgRS256, Keys: "k=" + privatePEM(t, rsaKey)}), rsaPublic},
+		{"Ed25519 公鑰", newTestKeySet(t, config.JWT{Alg: AlgEdDSA, Keys: "k=" + privatePEM(t, edKey)}), edKey.Public().(ed25519.PublicKey)},
+	}
+	for _, tt := range tests {
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		forged.Header["kid"] = "k"
+		token, err := forged.SignedString(tt.secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tt.keys.Parse(token, &jwt.RegisteredClaims{}); err == nil {
+			t.Errorf("%s: 以公鑰簽的 HS256 token 不應通過驗證", tt.name)
+		}
+
+		// 同一組金鑰正常簽發的 token 可以驗證
+		valid, _ := tt.keys.Sign(testClaims())
+		if _, err := tt.keys.Parse(valid, &jwt.RegisteredClaims{}); err != nil {
+			t.Errorf("%s: 正常簽發的 token 驗證失敗: %v", tt.name, err)
+		}
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	if jwks := newTestKeySet(t, config.JWT{Keys: "a=" + testSecretA + ",b=" + testSecretB}).JWKS(); len(jwks.Keys) != 0 {
+		t.Errorf("HS256 的密鑰不應公開: %+v", jwks.Keys)
+	}
+	if jwks := (*KeySet)(nil).JWKS(); jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("尚未設定金鑰時應回傳空集合: %+v", jwks)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := newTestKeySet(t, config.JWT{Alg: AlgRS256, Keys: "z-new=" + privatePEM(t, rsaKey) + ",a-old=" + publicPEM(t, &retired.PublicKey)})
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != "a-old" || jwks.Keys[1].Kid != "z-new" {
+		t.Fatalf("JWKS = %+v，預期依 kid 排序的兩把公鑰", jwks.Keys)
+	}
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || key.Alg != AlgRS256 || key.Use != "sig" || key.N == "" || key.E != "AQAB" {
+			t.Errorf("JWK 內容錯誤: %+v", key)
+		}
+	}
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	jwks = newTestKeySet(t, config.JWT{Alg: AlgEdDSA, Keys: "k=" + privatePEM(t, edKey)}).JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].Crv != "Ed25519" || jwks.Keys[0].X == "" {
+		t.Errorf("Ed25519 JWKS = %+v", jwks.Keys)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// UserAchievements 玩家達成的成就，Code 對應 game.Achievements
+type UserAchievements struct {
+	ID         string    `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	UserID     string    `gorm:"column:user_id;type:varchar(36);not null;index:uq_user_achievement,unique" json:"user_id"`
+	Code       string    `gorm:"column:code;type:varchar(50);not null;index:uq_user_achievement,unique" json:"code"`
+	GameID     string    `gorm:"column:game_id;type:varchar(36)" json:"game_id"` // 達成時的房間
+	Round      int       `gorm:"column:round" json:"round"`
+	UnlockedAt time.Time `gorm:"column:unlocked_at;autoCreateTime" json:"unlocked_at"`
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+// AchievementInfo 個人頁面上的一項成就，未達成時 UnlockedAt 為空
+type AchievementInfo struct {
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+}
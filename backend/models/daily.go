@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// DailyAttempt 每日挑戰進行中的狀態（存放於 Redis）
+type DailyAttempt struct {
+	UserID     string     `json:"user_id"`
+	Date       string     `json:"date"`
+	MinRange   int        `json:"min_range"`
+	MaxRange   int        `json:"max_range"`
+	Guesses    []int      `json:"guesses"`
+	Solved     bool       `json:"solved"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// DailyGuessResult 每日挑戰單次猜測的結果
+type DailyGuessResult struct {
+	Date       string        `json:"date"`
+	Guess      int           `json:"guess"`
+	Result     string        `json:"result"` // too_big, too_small, correct
+	Message    string        `json:"message"`
+	GuessCount int           `json:"guess_count"`
+	Solved     bool          `json:"solved"`
+	DurationMs int64         `json:"duration_ms,omitempty"`
+	Streak     *DailyStreaks `json:"streak,omitempty"`
+}
+
+type DailyResults struct {
+	ID         string    `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	UserID     string    `gorm:"column:user_id;type:varchar(36);not null;index:uq_daily_user_date,unique" json:"user_id"`
+	Date       string    `gorm:"column:date;type:varchar(10);not null;index:uq_daily_user_date,unique;index" json:"date"`
+	GuessCount int       `gorm:"column:guess_count;not null" json:"guess_count"`
+	DurationMs int64     `gorm:"column:duration_ms;not null" json:"duration_ms"`
+	FinishedAt time.Time `gorm:"column:finished_at;autoCreateTime" json:"finished_at"`
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+type DailyStreaks struct {
+	UserID        string `gorm:"column:user_id;primaryKey;type:varchar(36)" json:"user_id"`
+	CurrentStreak int    `gorm:"column:current_streak;not null;default:0" json:"current_streak"`
+	BestStreak    int    `gorm:"column:best_streak;not null;default:0" json:"best_streak"`
+	LastDate      string `gorm:"column:last_date;type:varchar(10)" json:"last_date"`
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+type DailyLeaderboard struct {
+	Username   string `json:"username"`
+	GuessCount int    `json:"guess_count"`
+	DurationMs int64  `json:"duration_ms"`
+}
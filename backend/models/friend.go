@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// 好友邀請的狀態
+const (
+	FriendPending  = "pending"
+	FriendAccepted = "accepted"
+	FriendDeclined = "declined"
+)
+
+// 在線狀態
+const (
+	PresenceOffline = "offline"
+	PresenceOnline  = "online"  // 在大廳
+	PresenceInGame  = "in_game" // 在遊戲房間中
+)
+
+// Friendships 好友關係；RequesterID 送出邀請，AddresseeID 接受或拒絕
+type Friendships struct {
+	ID          string     `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	RequesterID string     `gorm:"column:requester_id;type:varchar(36);not null;index:uq_friend_pair,unique" json:"requester_id"`
+	AddresseeID string     `gorm:"column:addressee_id;type:varchar(36);not null;index:uq_friend_pair,unique;index" json:"addressee_id"`
+	Status      string     `gorm:"column:status;type:varchar(20);not null;default:pending" json:"status"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	RespondedAt *time.Time `gorm:"column:responded_at" json:"responded_at,omitempty"`
+
+	// Relations
+	Requester Users `gorm:"foreignKey:RequesterID;references:ID" json:"-"`
+	Addressee Users `gorm:"foreignKey:AddresseeID;references:ID" json:"-"`
+}
+
+// Presence 玩家的在線狀態（存放於 Redis）
+type Presence struct {
+	Status    string    `json:"status"`
+	GameID    string    `json:"game_id,omitempty"` // in_game 時所在的房間
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FriendInfo 好友列表的一筆資料
+type FriendInfo struct {
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	Since    string   `json:"since"`
+	Presence Presence `json:"presence"`
+}
+
+// FriendRequest 尚未回覆的好友邀請
+type FriendRequest struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GameInvite 遊戲邀請（存放於 Redis），受邀者以 Token 一鍵加入
+type GameInvite struct {
+	Token     string    `json:"token"`
+	GameID    string    `json:"game_id"`
+	FromUuid  string    `json:"from_uuid"`
+	FromName  string    `json:"from_name"`
+	ToUuid    string    `json:"to_uuid"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -1,15 +1,103 @@
 package models
 
+import "time"
+
+// 遊戲模式
+const (
+	ModeClassic      = "classic"      // 依序輪流猜測
+	ModeSimultaneous = "simultaneous" // 每回合所有玩家同時密封出價，一起揭曉
+	ModeTeam         = "team"         // 分隊輪流猜測，隊伍共用回合
+	ModeElimination  = "elimination"  // 每輪淘汰離答案最遠的玩家，最後存活者獲勝
+)
+
+// 猜測回饋模式
+const (
+	FeedbackDirection         = "direction"          // 太大 / 太小（預設）
+	FeedbackProximityAnswer   = "proximity_answer"   // 與答案的距離區間
+	FeedbackProximityPrevious = "proximity_previous" // 比上一個猜測更接近或更遠
+)
+
+// 距離區間，由近到遠
+const (
+	BandBurning  = "burning"
+	BandHot      = "hot"
+	BandWarm     = "warm"
+	BandCool     = "cool"
+	BandCold     = "cold"
+	BandFreezing = "freezing"
+)
+
+// 與上一個猜測相比的趨勢
+const (
+	TrendWarmer = "warmer"
+	TrendColder = "colder"
+	TrendSame   = "same"
+)
+
+const (
+	DirectionTooBig   = "too_big"
+	DirectionTooSmall = "too_small"
+)
+
+// GuessFeedback 結構化的猜測回饋，客戶端與機器人可以直接使用
+type GuessFeedback struct {
+	Mode          string `json:"mode"`
+	Guess         int    `json:"guess"`
+	Correct       bool   `json:"correct"`
+	Direction     string `json:"direction,omitempty"`     // direction 模式：too_big / too_small
+	Band          string `json:"band,omitempty"`          // proximity 模式：freezing ~ burning
+	Trend         string `json:"trend,omitempty"`         // proximity_previous 模式：warmer / colder / same
+	PreviousGuess *int   `json:"previousGuess,omitempty"` // proximity_previous 模式比較的猜測
+}
+
+// GuessRecord 本局的猜測紀錄
+type GuessRecord struct {
+	Uuid     string         `json:"uuid"`
+	Name     string         `json:"name"`
+	Guess    int            `json:"guess"`
+	Rotation int            `json:"rotation"` // 淘汰模式每輪會換題，只和同一輪比較
+	Feedback *GuessFeedback `json:"feedback"`
+}
+
+// 道具
+const (
+	ItemPeek        = "peek"         // 得知答案是奇數或偶數（只有使用者看得到）
+	ItemSkip        = "skip"         // 跳過自己的回合
+	ItemDoubleGuess = "double_guess" // 下一次猜測不會結束回合，可以連猜兩次
+	ItemShield      = "shield"       // 只能在淘汰模式使用，免於下一次被淘汰
+)
+
+// AllItems 每位玩家每局開始時各獲得一個
+var AllItems = []string{ItemPeek, ItemSkip, ItemDoubleGuess, ItemShield}
+
+// GameOptions 建立房間時可選的設定
+type GameOptions struct {
+	Mode         string
+	RoundSeconds int    // 同時猜測模式每回合的秒數
+	TeamCount    int    // 隊伍模式的隊伍數
+	PowerUps     bool   // 啟用道具
+	FeedbackMode string // 猜測回饋模式，預設 direction
+}
+
 type Player struct {
-	Uuid      string
-	Name      string
-	GuessNum  int
-	Score     int
-	TurnOrder int
-	Guessed   bool
-	Ready     bool
+	Uuid       string
+	Name       string
+	Avatar     string // 加入房間時的頭像網址
+	GuessNum   int
+	GuessCount int
+	Score      int
+	TurnOrder  int
+	Guessed    bool
+	Ready      bool
+	Team       int  // 隊伍編號，從 1 開始，0 表示尚未分隊
+	Eliminated bool // 淘汰模式中已被淘汰，轉為觀戰
+
+	Items        map[string]int // 本局剩餘道具
+	ExtraGuesses int            // double_guess 尚未用掉的額外猜測
+	Shielded     bool           // shield 生效中
 }
 
+// Game 房間的完整狀態，以 JSON 存在 Redis；含答案與鹽值，不可直接回傳給客戶端（REST API 使用 ws.PublicGame）
 type Game struct {
 	NumOfPeople    int
 	Answer         int
@@ -20,6 +108,90 @@ type Game struct {
 	Players        []Player
 	CurrentTurn    int
 	PlayersGuessed map[string]bool
+	Salt           string // 承諾用的鹽值，遊戲結束才公開
+	Commitment     string // sha256(答案:鹽值)，遊戲開始時公開
+	Mode           string
+	RoundSeconds   int
+	GuessRound     int       // 同時猜測模式目前的回合，從 1 開始
+	RoundDeadline  time.Time // 同時猜測模式本回合截止時間
+	TeamCount      int
+	WinningTeam    int // 隊伍模式獲勝的隊伍
+	Rotation       int // 淘汰模式已完成的輪數
+	PowerUps       bool
+	ItemLog        []ItemUsage // 本局道具使用紀錄
+	FeedbackMode   string
+	GuessHistory   []GuessRecord        // 本局的猜測紀錄
+	Muted          map[string]time.Time // 被房主禁言的玩家 UUID 與解除時間
+	StartedAt      time.Time            // 本局開始時間
+}
+
+// 同一題的上一個猜測；uuid 不為空時只找該玩家的猜測
+func (g *Game) LastGuess(uuid string) *int {
+	for i := len(g.GuessHistory) - 1; i >= 0; i-- {
+		record := g.GuessHistory[i]
+		if record.Rotation == g.Rotation && (uuid == "" || record.Uuid == uuid) {
+			guess := record.Guess
+			return &guess
+		}
+	}
+	return nil
+}
+
+// ItemUsage 道具使用紀錄
+type ItemUsage struct {
+	Uuid   string    `json:"uuid"`
+	Name   string    `json:"name"`
+	Item   string    `json:"item"`
+	UsedAt time.Time `json:"usedAt"`
+}
+
+// ItemResult 使用道具的結果
+type ItemResult struct {
+	Item    string
+	Message string // 公告給房間的訊息
+	Private string // 只給使用者看的結果（例如 peek）
+}
+
+// GuessResult 一次猜測的結果
+type GuessResult struct {
+	Correct     bool   // 猜中答案
+	Finished    bool   // 遊戲因此結束
+	WinnerUuid  string // 遊戲結束時的獲勝者
+	Message     string
+	Feedback    *GuessFeedback
+	Elimination *Elimination // 淘汰模式本次猜測結束了一輪
+	ExtraGuess  bool         // 使用 double_guess 的額外猜測，回合尚未結束
+}
+
+// Elimination 淘汰模式一輪結束的結果，同時揭露該輪答案並公開下一題的承諾值
+type Elimination struct {
+	Rotation       int    `json:"rotation"`
+	Uuid           string `json:"uuid,omitempty"` // 被淘汰的玩家，全員猜中時為空
+	Name           string `json:"name,omitempty"`
+	Guess          int    `json:"guess,omitempty"`
+	Answer         int    `json:"answer"`
+	Salt           string `json:"salt"`
+	Commitment     string `json:"commitment"`
+	NextCommitment string `json:"nextCommitment,omitempty"`
+	ShieldedUuid   string `json:"shieldedUuid,omitempty"` // 原本該被淘汰但被護盾擋下的玩家
+}
+
+// RevealedGuess 同時猜測模式揭曉時每位玩家的結果（不含與答案的距離，避免洩漏答案）
+type RevealedGuess struct {
+	Uuid     string         `json:"uuid"`
+	Name     string         `json:"name"`
+	Guess    int            `json:"guess"`
+	Hint     string         `json:"hint"` // correct、none（本回合未出價），其餘依回饋模式為方向、距離區間或趨勢
+	Feedback *GuessFeedback `json:"feedback,omitempty"`
+	Points   int            `json:"points"`
+	Score    int            `json:"score"`
+}
+
+type RoundResult struct {
+	GuessRound int             `json:"guessRound"`
+	Guesses    []RevealedGuess `json:"guesses"`
+	Finished   bool            `json:"finished"`
+	WinnerUuid string          `json:"winnerUuid,omitempty"`
 }
 
 type Message struct {
@@ -29,6 +201,52 @@ type Message struct {
 	From    string      `json:"from,omitempty"`
 }
 
+// 房主為目前玩家列表的第一位（建立房間者，離開後順延）
+func (g *Game) IsHost(uuid string) bool {
+	return len(g.Players) > 0 && g.Players[0].Uuid == uuid
+}
+
+// 玩家目前是否被禁言，回傳解除時間
+func (g *Game) MutedUntil(uuid string) (time.Time, bool) {
+	until, ok := g.Muted[uuid]
+	if !ok || !time.Now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// 依 UUID 找出玩家
+func (g *Game) FindPlayer(uuid string) *Player {
+	for i := range g.Players {
+		if g.Players[i].Uuid == uuid {
+			return &g.Players[i]
+		}
+	}
+	return nil
+}
+
+// 取得指定隊伍的成員 UUID
+func (g *Game) TeamMembers(team int) []string {
+	members := make([]string, 0)
+	for _, player := range g.Players {
+		if player.Team == team {
+			members = append(members, player.Uuid)
+		}
+	}
+	return members
+}
+
+// 淘汰模式中尚未被淘汰的玩家，其他模式為所有玩家
+func (g *Game) ActivePlayers() []Player {
+	active := make([]Player, 0, len(g.Players))
+	for _, player := range g.Players {
+		if !player.Eliminated {
+			active = append(active, player)
+		}
+	}
+	return active
+}
+
 // 添加方法到 Game 結構體
 func (g *Game) GetCurrentPlayer() *Player {
 	if len(g.Players) == 0 || g.CurrentTurn >= len(g.Players) {
@@ -5,11 +5,15 @@ import (
 )
 
 type Users struct {
-	ID           string    `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
-	Username     string    `gorm:"column:username;unique;size:100;not null" json:"username"`
-	PasswordHash string    `gorm:"column:password_hash;size:255;not null" json:"password_hash"`
-	Email        *string   `gorm:"column:email;unique;size:100" json:"email,omitempty"`
-	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	ID           string  `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	Username     string  `gorm:"column:username;unique;size:100;not null" json:"username"`
+	PasswordHash string  `gorm:"column:password_hash;size:255;not null" json:"password_hash"`
+	Email        *string `gorm:"column:email;unique;size:100" json:"email,omitempty"`
+	AvatarURL    *string `gorm:"column:avatar_url;size:255" json:"avatar_url,omitempty"`
+	// email 驗證完成的時間，NULL 表示尚未驗證
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at" json:"email_verified_at,omitempty"`
+	Rating          int        `gorm:"column:rating;not null;default:1000" json:"rating"` // Elo 積分
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 
 	// Relations (optional)
 	GameResults []GameResults `gorm:"foreignKey:WinnerID" json:"-"`
@@ -24,6 +28,10 @@ type GameResults struct {
 	Answer       int       `gorm:"column:answer;not null" json:"answer"`
 	TotalTurns   *int      `gorm:"column:total_turns" json:"total_turns,omitempty"`
 	TotalPlayers *int      `gorm:"column:total_players" json:"total_players,omitempty"`
+	Mode         string    `gorm:"column:mode;type:varchar(20);default:classic" json:"mode"`
+	WinningTeam  *int      `gorm:"column:winning_team" json:"winning_team,omitempty"`
+	Salt         string    `gorm:"column:salt;type:varchar(64)" json:"salt"`
+	Commitment   string    `gorm:"column:commitment;type:varchar(64)" json:"commitment"`
 	FinishedAt   time.Time `gorm:"column:finished_at;autoCreateTime" json:"finished_at"`
 
 	// Relations
@@ -39,6 +47,8 @@ type GamePlayers struct {
 	TurnOrder        int    `gorm:"column:turn_order;not null" json:"turn_order"`
 	Score            *int   `gorm:"column:score;default:0" json:"score,omitempty"`
 	GuessCount       *int   `gorm:"column:guess_count;default:0" json:"guess_count,omitempty"`
+	Team             int    `gorm:"column:team;default:0" json:"team"`
+	Won              bool   `gorm:"column:won;default:false" json:"won"` // 個人獲勝或所屬隊伍獲勝
 
 	// Relations
 	User       Users       `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
@@ -50,3 +60,54 @@ type Leaderboard struct {
 	Username string
 	WinCount int64
 }
+
+// PlayerStats 玩家的生涯統計
+type PlayerStats struct {
+	Username     string `json:"username"`
+	GamesPlayed  int64  `json:"games_played"`
+	Wins         int64  `json:"wins"`
+	TotalGuesses int64  `json:"total_guesses"`
+}
+
+// RatingChange 一輪結束後玩家的積分變化
+type RatingChange struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+	Delta  int `json:"delta"`
+}
+
+// ProfileStats 個人頁面的生涯統計
+type ProfileStats struct {
+	GamesPlayed      int64   `json:"games_played"`
+	Wins             int64   `json:"wins"`
+	WinRate          float64 `json:"win_rate"`            // 0 ~ 1
+	AvgGuessesPerWin float64 `json:"avg_guesses_per_win"` // 獲勝時的平均猜測次數
+	FavouriteMode    string  `json:"favourite_mode,omitempty"`
+	CurrentStreak    int     `json:"current_streak"`
+	Rating           int     `json:"rating"`
+}
+
+// RecentGame 個人頁面上最近的一輪遊戲
+type RecentGame struct {
+	GameID       string    `json:"game_id"`
+	Round        int       `json:"round"`
+	Mode         string    `json:"mode"`
+	Won          bool      `json:"won"`
+	GuessCount   int       `json:"guess_count"`
+	Score        int       `json:"score"`
+	TotalPlayers int       `json:"total_players"`
+	FinishedAt   time.Time `json:"finished_at"`
+}
+
+// UserProfile 個人頁面；Email 只在查詢自己時回傳
+type UserProfile struct {
+	ID            string            `json:"id"`
+	Username      string            `json:"username"`
+	Email         *string           `json:"email,omitempty"`
+	EmailVerified *bool             `json:"email_verified,omitempty"` // 只在查詢自己時回傳
+	AvatarURL     *string           `json:"avatar_url,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Stats         ProfileStats      `json:"stats"`
+	RecentGames   []RecentGame      `json:"recent_games"`
+	Achievements  []AchievementInfo `json:"achievements"`
+}
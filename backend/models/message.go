@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// DirectMessage 玩家之間的私訊；收件人離線時暫存在 Redis 的收件匣
+type DirectMessage struct {
+	ID       string    `json:"id"`
+	FromUuid string    `json:"fromUuid"`
+	FromName string    `json:"fromName"`
+	ToUuid   string    `json:"toUuid"`
+	Text     string    `json:"text"`
+	Filtered bool      `json:"filtered,omitempty"`
+	SentAt   time.Time `json:"sentAt"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 檢舉的處理狀態
+const (
+	ReportOpen      = "open"
+	ReportReviewed  = "reviewed"
+	ReportDismissed = "dismissed"
+)
+
+// 聊天頻道
+const (
+	ChatChannelRoom    = "room"    // 房間公開聊天
+	ChatChannelTeam    = "team"    // 隊伍頻道
+	ChatChannelWhisper = "whisper" // 房間內悄悄話
+)
+
+// ChatLine 一則聊天紀錄，作為檢舉時的上下文
+type ChatLine struct {
+	Uuid     string    `json:"uuid"`
+	Name     string    `json:"name"`
+	Text     string    `json:"text"`
+	Channel  string    `json:"channel,omitempty"`  // 只在房間記錄中使用，見 ChatChannelRoom
+	Filtered bool      `json:"filtered,omitempty"` // 已被禁用詞過濾
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// ChatContext 以 JSON 存入 MySQL 的聊天上下文
+type ChatContext []ChatLine
+
+func (c ChatContext) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *ChatContext) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return fmt.Errorf("無法將 %T 轉換為 ChatContext", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// ChatArchives 排名局結束時封存的聊天紀錄，供爭議時查閱
+type ChatArchives struct {
+	ID        string      `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	GameID    string      `gorm:"column:game_id;type:varchar(36);not null;index:uq_chat_game_round,unique" json:"game_id"`
+	Round     int         `gorm:"column:round;not null;index:uq_chat_game_round,unique" json:"round"`
+	Messages  ChatContext `gorm:"column:messages;type:json" json:"messages"`
+	CreatedAt time.Time   `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// ChatReports 玩家對聊天內容的檢舉，由管理員審查
+type ChatReports struct {
+	ID         string      `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	GameID     string      `gorm:"column:game_id;type:varchar(36);not null;index" json:"game_id"`
+	ReporterID string      `gorm:"column:reporter_id;type:varchar(36);not null;index" json:"reporter_id"`
+	TargetID   string      `gorm:"column:target_id;type:varchar(36);not null;index" json:"target_id"`
+	TargetName string      `gorm:"column:target_name;size:100" json:"target_name"`
+	Reason     string      `gorm:"column:reason;size:255" json:"reason"`
+	Message    string      `gorm:"column:message;type:text" json:"message"` // 被檢舉的訊息（原文）
+	Context    ChatContext `gorm:"column:context;type:json" json:"context"` // 檢舉當下房間最近的聊天紀錄
+	Status     string      `gorm:"column:status;type:varchar(20);not null;default:open;index" json:"status"`
+	CreatedAt  time.Time   `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	ReviewedAt *time.Time  `gorm:"column:reviewed_at" json:"reviewed_at,omitempty"`
+	ReviewedBy *string     `gorm:"column:reviewed_by;size:100" json:"reviewed_by,omitempty"`
+}
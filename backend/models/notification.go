@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 站內通知的類型
+const (
+	NotifyFriendRequest  = "friend_request"
+	NotifyFriendAccepted = "friend_accepted"
+	NotifyGameInvite     = "game_invite"
+	NotifyMatchResult    = "match_result"
+	NotifyRatingChange   = "rating_change"
+	NotifyAchievement    = "achievement"
+)
+
+// NotificationData 通知附帶的資料（房號、邀請 token 等），以 JSON 存入 MySQL
+type NotificationData map[string]interface{}
+
+func (d NotificationData) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *NotificationData) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*d = nil
+		return nil
+	default:
+		return fmt.Errorf("無法將 %T 轉換為 NotificationData", value)
+	}
+	return json.Unmarshal(data, d)
+}
+
+// Notifications 站內通知；ReadAt 為空表示未讀
+type Notifications struct {
+	ID        string           `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	UserID    string           `gorm:"column:user_id;type:varchar(36);not null;index:idx_notification_user" json:"user_id"`
+	Type      string           `gorm:"column:type;type:varchar(30);not null" json:"type"`
+	Title     string           `gorm:"column:title;size:100;not null" json:"title"`
+	Body      string           `gorm:"column:body;size:255" json:"body"`
+	Data      NotificationData `gorm:"column:data;type:json" json:"data,omitempty"`
+	ReadAt    *time.Time       `gorm:"column:read_at" json:"read_at,omitempty"`
+	CreatedAt time.Time        `gorm:"column:created_at;autoCreateTime;index:idx_notification_user" json:"created_at"`
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
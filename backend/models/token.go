@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// RefreshTokens 只保存 refresh token 的雜湊值；每次換發都會撤銷舊 token 並在同一個 FamilyID 下建立新 token，
+// 已撤銷的 token 再次被使用時視為外洩，整個 family 一併撤銷
+type RefreshTokens struct {
+	ID         string     `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	UserID     string     `gorm:"column:user_id;type:varchar(36);not null;index" json:"user_id"`
+	FamilyID   string     `gorm:"column:family_id;type:varchar(36);not null;index" json:"family_id"` // 同一次登入換發出的所有 token
+	TokenHash  string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	ReplacedBy *string    `gorm:"column:replaced_by;type:varchar(36)" json:"replaced_by,omitempty"` // 換發後的新 token
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+// TokenPair 登入或換發時回傳的 token
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // access token 的有效秒數
+}
+
+// Sessions 每次登入建立一個 session，ID 即為該次登入的 refresh token FamilyID，access token 以 sid 記錄所屬 session；
+// 撤銷 session 會撤銷其 refresh token 並中斷它的 WebSocket 連線
+type Sessions struct {
+	ID         string     `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	UserID     string     `gorm:"column:user_id;type:varchar(36);not null;index" json:"user_id"`
+	UserAgent  string     `gorm:"column:user_agent;type:varchar(255)" json:"user_agent"`
+	IP         string     `gorm:"column:ip;type:varchar(45)" json:"ip"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;not null" json:"last_seen_at"` // 最後一次登入或換發 token 的時間
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+// SessionInfo 登入裝置列表的一筆資料
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // 是否為目前請求使用的 session
+}
+
+// ClientInfo 登入或換發 token 時的裝置資訊
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// UserTokens 的用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+// UserTokens 寄到信箱的一次性 token（email 驗證、重設密碼），只保存雜湊值
+type UserTokens struct {
+	ID        string     `gorm:"column:id;primaryKey;type:varchar(36)" json:"id"`
+	UserID    string     `gorm:"column:user_id;type:varchar(36);not null;index:idx_user_purpose" json:"user_id"`
+	Purpose   string     `gorm:"column:purpose;type:varchar(20);not null;index:idx_user_purpose" json:"purpose"`
+	TokenHash string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty"` // 已使用或被新的 token 取代
+
+	// Relations
+	User Users `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
@@ -14,6 +14,7 @@ var Upgrader = websocket.Upgrader{
 	},
 }
 
+// GameMessage 伺服器送出的訊息；舊版客戶端收到整個結構，v2 客戶端只收到 Payload 中的型別化內容
 type GameMessage struct {
 	Type        string                   `json:"type"`
 	GameId      string                   `json:"gameId"`
@@ -24,6 +25,10 @@ type GameMessage struct {
 	Timestamp   string                   `json:"timestamp"`
 	Players     []map[string]interface{} `json:"players,omitempty"`
 	GameInfo    map[string]interface{}   `json:"gameInfo,omitempty"`
+	Code        string                   `json:"code,omitempty"`      // 錯誤碼，只有 error 事件會帶
+	RequestId   string                   `json:"requestId,omitempty"` // 回覆客戶端請求時帶回請求的 requestId
+	Seq         int64                    `json:"seq,omitempty"`       // 房間廣播的序號，只傳給單一玩家的訊息沒有序號
+	Payload     interface{}              `json:"-"`                   // 型別化的事件內容，見 protocol 套件
 }
 
 // 遊戲事件類型常數
@@ -41,4 +46,46 @@ const (
 	EventJoinGame     = "join_game"
 	EventLeftGame     = "left_game"
 	EventPlayerReady  = "player_ready"
+
+	// 同時猜測模式
+	EventGuessSubmitted = "guess_submitted"
+	EventRoundReveal    = "round_reveal"
+	EventRoundStarted   = "round_started"
+
+	// 隊伍模式
+	EventChooseTeam   = "choose_team"
+	EventBalanceTeams = "balance_teams"
+	EventTeamsUpdated = "teams_updated"
+	EventTeamChat     = "team_chat"
+
+	// 淘汰模式
+	EventPlayerEliminated = "player_eliminated"
+
+	// 道具
+	EventUseItem  = "use_item"
+	EventItemUsed = "item_used"
+
+	// 聊天審核
+	EventMute           = "mute"
+	EventUnmute         = "unmute"
+	EventReport         = "report"
+	EventPlayerMuted    = "player_muted"
+	EventPlayerUnmuted  = "player_unmuted"
+	EventReportReceived = "report_received"
+	EventPlayerKicked   = "player_kicked"
+
+	// 私訊
+	EventWhisper       = "whisper"        // 房間內以 /w 名稱 訊息 送出的悄悄話
+	EventDirectMessage = "direct_message" // 跨房間私訊
+
+	// 好友
+	EventInvite     = "invite"      // 邀請好友加入目前的房間
+	EventGameInvite = "game_invite" // 受邀者在大廳收到的邀請
+	EventInviteSent = "invite_sent"
+
+	// 站內通知
+	EventNotification = "notification"
+
+	// 成就
+	EventAchievementUnlocked = "achievement_unlocked"
 )
@@ -0,0 +1,114 @@
+// Package moderation 聊天內容的審核：禁用詞過濾與長度限制
+package moderation
+
+import (
+	"bufio"
+	"embed"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed wordlists/*.txt
+var wordlists embed.FS
+
+// Filter 禁用詞過濾器；英文詞以單字邊界比對（避免 class 被 ass 誤判），其他語言以子字串比對
+type Filter struct {
+	english *regexp.Regexp
+	words   []string
+}
+
+// NewFilter 以內建的中英文詞庫加上額外的禁用詞建立過濾器
+func NewFilter(extraWords []string) *Filter {
+	words := append(builtinWords(), extraWords...)
+
+	seen := make(map[string]bool)
+	var english, others []string
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		if isLatin(word) {
+			english = append(english, regexp.QuoteMeta(word))
+		} else {
+			others = append(others, word)
+		}
+	}
+
+	// 長的詞優先，避免只遮住較長詞的一部分
+	sort.Slice(english, func(i, j int) bool { return len(english[i]) > len(english[j]) })
+	sort.Slice(others, func(i, j int) bool { return len(others[i]) > len(others[j]) })
+
+	f := &Filter{words: others}
+	if len(english) > 0 {
+		f.english = regexp.MustCompile(`(?i)\b(?:` + strings.Join(english, "|") + `)\b`)
+	}
+	return f
+}
+
+// Clean 將禁用詞替換成等長的 *，回傳處理後的文字與是否有被過濾
+func (f *Filter) Clean(text string) (string, bool) {
+	filtered := false
+	if f.english != nil {
+		text = f.english.ReplaceAllStringFunc(text, func(match string) string {
+			filtered = true
+			return mask(match)
+		})
+	}
+	for _, word := range f.words {
+		lower := strings.ToLower(text)
+		for {
+			index := strings.Index(lower, word)
+			if index < 0 {
+				break
+			}
+			// 只對不受大小寫影響的文字比對，位置與原文一致
+			if len(lower) != len(text) {
+				break
+			}
+			filtered = true
+			text = text[:index] + mask(text[index:index+len(word)]) + text[index+len(word):]
+			lower = strings.ToLower(text)
+		}
+	}
+	return text, filtered
+}
+
+func mask(word string) string {
+	return strings.Repeat("*", len([]rune(word)))
+}
+
+// 只包含拉丁字母、數字與常見符號的詞用單字邊界比對
+func isLatin(word string) bool {
+	for _, r := range word {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// 讀取內建詞庫，一行一個，# 開頭為註解
+func builtinWords() []string {
+	var words []string
+	entries, _ := wordlists.ReadDir("wordlists")
+	for _, entry := range entries {
+		file, err := wordlists.Open("wordlists/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			words = append(words, line)
+		}
+		file.Close()
+	}
+	return words
+}
@@ -0,0 +1,55 @@
+package moderation
+
+import (
+	"testing"
+
+	"game/config"
+	"game/protocol"
+)
+
+func TestFilterClean(t *testing.T) {
+	// 額外的詞會去除空白、轉小寫並去除重複，空字串不應比對到任何文字
+	filter := NewFilter([]string{"  Darn ", "DARN", "", "ass", "壞蛋"})
+
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		filtered bool
+	}{
+		{"一般文字", "hello world", "hello world", false},
+		{"英文詞不分大小寫", "Darn it", "**** it", true},
+		{"全大寫與標點", "DARN!", "****!", true},
+		{"單字邊界：較長的單字不受影響", "darnation", "darnation", false},
+		{"單字邊界：class 不會被 ass 誤判", "first class pass", "first class pass", false},
+		{"獨立的英文詞", "you ass", "you ***", true},
+		{"內建英文詞庫", "what the fuck", "what the ****", true},
+		{"中文以子字串比對，依字數遮蔽", "你這個壞蛋啊", "你這個**啊", true},
+		{"同一個詞出現多次", "壞蛋壞蛋", "****", true},
+		{"內建中文詞庫", "王八蛋", "***", true},
+	}
+	for _, tt := range tests {
+		got, filtered := filter.Clean(tt.text)
+		if got != tt.want || filtered != tt.filtered {
+			t.Errorf("%s: Clean(%q) = (%q, %v)，預期 (%q, %v)", tt.name, tt.text, got, filtered, tt.want, tt.filtered)
+		}
+	}
+}
+
+func TestModeratorModerate(t *testing.T) {
+	moderator := NewModerator(config.Moderation{MaxChatLength: 5, BannedWords: " darn , ,heck"})
+
+	cleaned, filtered, err := moderator.Moderate("  heck  ")
+	if err != nil || cleaned != "****" || !filtered {
+		t.Errorf("Moderate = (%q, %v, %v)，預期前後空白被去除且 heck 被遮蔽", cleaned, filtered, err)
+	}
+
+	// 長度以字元數計算，去除空白後才檢查
+	if _, _, err := moderator.Moderate(" 一二三四五 "); err != nil {
+		t.Errorf("5 個中文字不應超過上限: %v", err)
+	}
+	_, _, err = moderator.Moderate("一二三四五六")
+	if protocol.CodeOf(err) != protocol.ErrMessageTooLong {
+		t.Errorf("超過長度的錯誤碼 = %q，預期 %q", protocol.CodeOf(err), protocol.ErrMessageTooLong)
+	}
+}
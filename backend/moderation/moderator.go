@@ -0,0 +1,48 @@
+package moderation
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"game/config"
+	"game/protocol"
+)
+
+// 未設定時的聊天訊息長度上限（字元數）
+const DefaultMaxLength = 200
+
+// Moderator 聊天訊息送出前的審核流程
+type Moderator struct {
+	filter    *Filter
+	maxLength int
+}
+
+// NewModerator 依設定建立審核流程
+func NewModerator(cfg config.Moderation) *Moderator {
+	maxLength := cfg.MaxChatLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxLength
+	}
+	var extraWords []string
+	for _, word := range strings.Split(cfg.BannedWords, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			extraWords = append(extraWords, word)
+		}
+	}
+	return &Moderator{filter: NewFilter(extraWords), maxLength: maxLength}
+}
+
+// MaxLength 聊天訊息的長度上限
+func (m *Moderator) MaxLength() int {
+	return m.maxLength
+}
+
+// Moderate 檢查長度並遮蔽禁用詞，回傳可以廣播的文字與是否有被過濾
+func (m *Moderator) Moderate(text string) (string, bool, error) {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > m.maxLength {
+		return "", false, protocol.Errorf(protocol.ErrMessageTooLong, "訊息長度不可超過 %d 個字", m.maxLength)
+	}
+	cleaned, filtered := m.filter.Clean(text)
+	return cleaned, filtered, nil
+}
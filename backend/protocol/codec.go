@@ -0,0 +1,113 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// 訊息編碼，連線時以 WebSocket subprotocol（Sec-WebSocket-Protocol）協商
+const (
+	EncodingJSON     = "json"     // 文字訊框，預設
+	EncodingMsgpack  = "msgpack"  // 二進位訊框，MessagePack
+	EncodingProtobuf = "protobuf" // 二進位訊框，google.protobuf.Struct
+)
+
+// SupportedEncodings 伺服器支援的編碼
+var SupportedEncodings = []string{EncodingJSON, EncodingMsgpack, EncodingProtobuf}
+
+// NegotiateEncoding 依客戶端要求的順序選出第一個支援的編碼，都不支援時回傳空字串
+func NegotiateEncoding(requested []string) string {
+	for _, encoding := range requested {
+		for _, supported := range SupportedEncodings {
+			if encoding == supported {
+				return encoding
+			}
+		}
+	}
+	return ""
+}
+
+// Codec 負責訊息與訊框之間的轉換；收到的訊框一律先轉成 JSON 再交給 DecodeInbound
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	ToJSON(data []byte) ([]byte, error)
+	Binary() bool
+}
+
+// CodecFor 取得編碼對應的 Codec，未知的編碼使用 JSON
+func CodecFor(encoding string) Codec {
+	switch encoding {
+	case EncodingMsgpack:
+		return msgpackCodec{}
+	case EncodingProtobuf:
+		return protobufCodec{}
+	default:
+		return jsonCodec{}
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+func (jsonCodec) ToJSON(data []byte) ([]byte, error)    { return data, nil }
+func (jsonCodec) Binary() bool                          { return false }
+
+// MessagePack 沿用 json 標籤的欄位名稱與 omitempty，與 JSON 格式的欄位一致
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.RawToString = true
+	return h
+}()
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var out []byte
+	err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(v)
+	return out, err
+}
+
+func (msgpackCodec) ToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&v); err != nil {
+		return nil, Errorf(ErrInvalidMessage, "無法解析的 MessagePack 訊息: %v", err)
+	}
+	return json.Marshal(v)
+}
+
+func (msgpackCodec) Binary() bool { return true }
+
+// Protobuf 以 google.protobuf.Struct 承載與 JSON 相同的結構，客戶端不需要額外的 .proto 定義
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	message, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
+
+func (protobufCodec) ToJSON(data []byte) ([]byte, error) {
+	var message structpb.Struct
+	if err := proto.Unmarshal(data, &message); err != nil {
+		return nil, Errorf(ErrInvalidMessage, "無法解析的 Protobuf 訊息: %v", err)
+	}
+	return message.MarshalJSON()
+}
+
+func (protobufCodec) Binary() bool { return true }
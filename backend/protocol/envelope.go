@@ -0,0 +1,35 @@
+package protocol
+
+import "game/models"
+
+// Envelope v2 伺服器事件的外層，事件內容依 type 對應到 payload 的型別
+type Envelope struct {
+	Version   int         `json:"v"`
+	Type      string      `json:"type"`
+	GameId    string      `json:"gameId"`
+	From      string      `json:"from,omitempty"`
+	Message   string      `json:"message,omitempty"` // 顯示用的中文訊息
+	Timestamp string      `json:"timestamp"`
+	RequestId string      `json:"requestId,omitempty"`
+	Seq       int64       `json:"seq,omitempty"`
+	Payload   interface{} `json:"payload,omitempty"`
+}
+
+// Encode 依客戶端的協定版本與編碼序列化遊戲訊息
+func Encode(msg *models.GameMessage, version int, encoding string) ([]byte, error) {
+	c := CodecFor(encoding)
+	if version < Version2 {
+		return c.Marshal(msg)
+	}
+	return c.Marshal(Envelope{
+		Version:   Version2,
+		Type:      msg.Type,
+		GameId:    msg.GameId,
+		From:      msg.From,
+		Message:   msg.Message,
+		Timestamp: msg.Timestamp,
+		RequestId: msg.RequestId,
+		Seq:       msg.Seq,
+		Payload:   msg.Payload,
+	})
+}
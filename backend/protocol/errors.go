@@ -0,0 +1,93 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 穩定的錯誤碼，客戶端應以錯誤碼判斷錯誤種類，message 只供顯示
+const (
+	ErrInvalidMessage     = "invalid_message"     // 無法解析的訊息
+	ErrInvalidPayload     = "invalid_payload"     // 事件內容格式錯誤
+	ErrUnknownType        = "unknown_type"        // 未知的事件類型
+	ErrUnsupportedVersion = "unsupported_version" // 不支援的協定版本
+	ErrInvalidOptions     = "invalid_options"     // 房間設定不正確
+	ErrGameNotFound       = "game_not_found"      // 遊戲不存在或已過期
+	ErrGameClosed         = "game_closed"         // 最後一位玩家離開，遊戲已刪除
+	ErrInvalidState       = "invalid_state"       // 目前遊戲狀態不允許此操作
+	ErrWrongMode          = "wrong_mode"          // 此房間的模式不支援此操作
+	ErrRoomFull           = "room_full"           // 房間人數已滿
+	ErrAlreadyJoined      = "already_joined"      // 玩家已在房間內
+	ErrNotInGame          = "not_in_game"         // 玩家不在遊戲中
+	ErrNotHost            = "not_host"            // 只有房主可以執行
+	ErrNotReady           = "players_not_ready"   // 有玩家尚未準備
+	ErrNotEnoughPlayers   = "not_enough_players"  // 人數不足以開始
+	ErrNotYourTurn        = "not_your_turn"       // 尚未輪到此玩家
+	ErrAlreadyGuessed     = "already_guessed"     // 本輪已經猜過
+	ErrEliminated         = "eliminated"          // 已被淘汰，只能觀戰
+	ErrOutOfRange         = "out_of_range"        // 猜測超出範圍
+	ErrInvalidTeam        = "invalid_team"        // 隊伍編號不正確
+	ErrTeamFull           = "team_full"           // 隊伍人數已滿
+	ErrNoTeam             = "no_team"             // 尚未加入隊伍
+	ErrUnknownItem        = "unknown_item"        // 未知的道具
+	ErrItemUnavailable    = "item_unavailable"    // 道具未啟用、用完或無法使用
+	ErrRateLimited        = "rate_limited"        // 操作太頻繁，retryAfterMs 後再試
+	ErrMessageTooLong     = "message_too_long"    // 聊天訊息超過長度上限
+	ErrMuted              = "muted"               // 已被房主禁言
+	ErrUserNotFound       = "user_not_found"      // 找不到指定的使用者
+	ErrUnknownCommand     = "unknown_command"     // 未知的聊天指令
+	ErrNotFriends         = "not_friends"         // 只能邀請好友
+	ErrUserOffline        = "user_offline"        // 對方不在大廳，無法送出邀請
+	ErrInvalidInvite      = "invalid_invite"      // 邀請不存在、已過期或已使用
+	ErrInternal           = "internal_error"      // 伺服器內部錯誤
+)
+
+// Error 帶有錯誤碼的錯誤
+type Error struct {
+	Code         string `json:"code"`
+	Message      string `json:"message"`
+	RetryAfterMs int64  `json:"retryAfterMs,omitempty"` // 只有 rate_limited 會帶
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf 建立帶有錯誤碼的錯誤
+func Errorf(code string, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap 在錯誤訊息前加上說明，保留原本的錯誤碼
+func Wrap(err error, prefix string) *Error {
+	return &Error{Code: CodeOf(err), Message: fmt.Sprintf("%s: %s", prefix, err.Error())}
+}
+
+// RateLimited 被限流的錯誤，附上建議的等待時間
+func RateLimited(eventType string, retryAfter time.Duration) *Error {
+	return &Error{
+		Code:         ErrRateLimited,
+		Message:      fmt.Sprintf("%s 操作太頻繁，請稍後再試", eventType),
+		RetryAfterMs: retryAfter.Milliseconds() + 1,
+	}
+}
+
+// CodeOf 取出錯誤碼，沒有錯誤碼的錯誤視為內部錯誤
+func CodeOf(err error) string {
+	var protocolErr *Error
+	if errors.As(err, &protocolErr) {
+		return protocolErr.Code
+	}
+	return ErrInternal
+}
+
+// ErrorCodes 所有錯誤碼，用於產生 JSON Schema
+var ErrorCodes = []string{
+	ErrInvalidMessage, ErrInvalidPayload, ErrUnknownType, ErrUnsupportedVersion, ErrInvalidOptions,
+	ErrGameNotFound, ErrGameClosed, ErrInvalidState, ErrWrongMode, ErrRoomFull, ErrAlreadyJoined,
+	ErrNotInGame, ErrNotHost, ErrNotReady, ErrNotEnoughPlayers, ErrNotYourTurn, ErrAlreadyGuessed,
+	ErrEliminated, ErrOutOfRange, ErrInvalidTeam, ErrTeamFull, ErrNoTeam, ErrUnknownItem,
+	ErrItemUnavailable, ErrRateLimited, ErrMessageTooLong, ErrMuted, ErrUserNotFound, ErrUnknownCommand,
+	ErrNotFriends, ErrUserOffline, ErrInvalidInvite, ErrInternal,
+}
@@ -0,0 +1,247 @@
+package protocol
+
+import "game/models"
+
+// 伺服器主動送出的事件類型（其餘沿用 models 中的事件常數）
+const (
+	EventWelcome     = "welcome"
+	EventAck         = "ack"
+	EventError       = "error"
+	EventRoomStatus  = "room_status_update"
+	EventPlayerTurn  = "player_turn"
+	EventChatHistory = "chat_history"
+	EventCommand     = "command_result"
+)
+
+// EmptyEvent 沒有額外內容的事件，例如 game_reset
+type EmptyEvent struct{}
+
+// WelcomeEvent 連線成功後只傳給該客戶端，告知協商結果
+type WelcomeEvent struct {
+	Version           int    `json:"version"`
+	SupportedVersions []int  `json:"supportedVersions"`
+	PlayerUuid        string `json:"playerUuid"`
+	PlayerName        string `json:"playerName"`
+}
+
+// AckEvent 帶有 requestId 的請求處理成功時，只回覆給送出請求的連線；
+// lastSeq 為當下房間的最後序號，此請求造成的廣播序號都不會大於它
+type AckEvent struct {
+	RequestType string `json:"requestType"`
+	LastSeq     int64  `json:"lastSeq"`
+}
+
+// PlayerInfo 房間內玩家的公開資訊
+type PlayerInfo struct {
+	Uuid       string `json:"uuid"`
+	Name       string `json:"name"`
+	Avatar     string `json:"avatar,omitempty"`
+	IsReady    bool   `json:"isReady"`
+	Team       int    `json:"team"`
+	Eliminated bool   `json:"eliminated"`
+}
+
+func NewPlayerInfo(player models.Player) PlayerInfo {
+	return PlayerInfo{
+		Uuid:       player.Uuid,
+		Name:       player.Name,
+		Avatar:     player.Avatar,
+		IsReady:    player.Ready,
+		Team:       player.Team,
+		Eliminated: player.Eliminated,
+	}
+}
+
+// AuthEvent 認證成功
+type AuthEvent struct {
+	PlayerName string `json:"playerName"`
+}
+
+// ChatEvent 聊天訊息
+type ChatEvent struct {
+	From     string `json:"from"`
+	Text     string `json:"text"`
+	Filtered bool   `json:"filtered,omitempty"` // 訊息中的禁用詞已被遮蔽
+}
+
+// TeamChatEvent 隊伍頻道訊息，只有同隊成員收得到
+type TeamChatEvent struct {
+	From     string `json:"from"`
+	Text     string `json:"text"`
+	Team     int    `json:"team"`
+	Filtered bool   `json:"filtered,omitempty"`
+}
+
+// ChatHistoryEvent 房間最近的聊天紀錄，由舊到新，只傳給剛加入的玩家
+type ChatHistoryEvent struct {
+	Messages []models.ChatLine `json:"messages"`
+}
+
+// PrivateMessageEvent 房間內的悄悄話或跨房間私訊，收件人與寄件人各收到一份
+type PrivateMessageEvent struct {
+	FromUuid string `json:"fromUuid"`
+	From     string `json:"from"`
+	ToUuid   string `json:"toUuid"`
+	To       string `json:"to,omitempty"`
+	Text     string `json:"text"`
+	Filtered bool   `json:"filtered,omitempty"`
+	Queued   bool   `json:"queued,omitempty"` // 收件人不在線上，已存入收件匣（只出現在寄件人的副本）
+}
+
+// CommandResultEvent 聊天指令的結果；public 為 true 時公告給整個房間，否則只傳給執行者
+type CommandResultEvent struct {
+	Command string `json:"command"`
+	From    string `json:"from"`
+	Text    string `json:"text"`
+	Public  bool   `json:"public"`
+}
+
+// InviteEvent 好友的遊戲邀請，送到受邀者的大廳連線；以 token 呼叫 /joinByInvite 加入
+type InviteEvent struct {
+	Token     string `json:"token"`
+	GameId    string `json:"gameId"`
+	FromUuid  string `json:"fromUuid"`
+	From      string `json:"from"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// InviteSentEvent 邀請已送達，只傳給邀請人
+type InviteSentEvent struct {
+	To        string `json:"to"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// NotificationEvent 站內通知，推送到玩家所有的房間與大廳連線；kind 為通知類型，data 依類型而不同
+type NotificationEvent struct {
+	Id        string                 `json:"id"`
+	Kind      string                 `json:"kind"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt string                 `json:"createdAt"`
+}
+
+// AchievementEvent 玩家達成成就，公告給整個房間
+type AchievementEvent struct {
+	Uuid        string `json:"uuid"`
+	PlayerName  string `json:"playerName"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// MuteEvent 房主禁言或解除禁言
+type MuteEvent struct {
+	Uuid       string `json:"uuid"`
+	PlayerName string `json:"playerName"`
+	Until      string `json:"until,omitempty"` // RFC3339，解除禁言時為空
+}
+
+// ReportReceivedEvent 檢舉已送出，只傳給檢舉人
+type ReportReceivedEvent struct {
+	ReportId   string `json:"reportId"`
+	TargetName string `json:"targetName"`
+}
+
+// PresenceEvent 玩家加入或離開
+type PresenceEvent struct {
+	PlayerName  string `json:"playerName"`
+	PlayerCount int    `json:"playerCount"`
+}
+
+// PlayerReadyEvent 玩家準備或取消準備
+type PlayerReadyEvent struct {
+	PlayerName string `json:"playerName"`
+	Ready      bool   `json:"ready"`
+}
+
+// RoomStatusEvent 房間狀態
+type RoomStatusEvent struct {
+	Players        []PlayerInfo `json:"players"`
+	MaxPlayers     int          `json:"maxPlayers"`
+	CurrentPlayers int          `json:"currentPlayers"`
+	ReadyCount     int          `json:"readyCount"`
+	GameStatus     string       `json:"gameStatus"`
+	MinRange       int          `json:"minRange"`
+	MaxRange       int          `json:"maxRange"`
+	Mode           string       `json:"mode"`
+}
+
+// GameStartedEvent 遊戲開始，附上答案的承諾值
+type GameStartedEvent struct {
+	Mode             string       `json:"mode"`
+	Players          []PlayerInfo `json:"players"`
+	Commitment       string       `json:"commitment"`
+	CommitmentScheme string       `json:"commitmentScheme"`
+	GuessRound       int          `json:"guessRound,omitempty"`
+	RoundDeadline    string       `json:"roundDeadline,omitempty"`
+}
+
+// GuessEvent 玩家猜測的結果
+type GuessEvent struct {
+	PlayerName string                `json:"playerName"`
+	Guess      int                   `json:"guess"`
+	Feedback   *models.GuessFeedback `json:"feedback"`
+}
+
+// GameOverEvent 遊戲結束，揭露答案與鹽值供客戶端比對承諾值
+type GameOverEvent struct {
+	WinnerName  string                 `json:"winnerName"`
+	Answer      int                    `json:"answer"`
+	Salt        string                 `json:"salt"`
+	Commitment  string                 `json:"commitment"`
+	Round       int                    `json:"round"`
+	WinningTeam int                    `json:"winningTeam,omitempty"`
+	Rotation    int                    `json:"rotation,omitempty"`
+	LastGuess   *GuessEvent            `json:"lastGuess,omitempty"`
+	Guesses     []models.RevealedGuess `json:"guesses,omitempty"`
+}
+
+// TurnEvent 輪到下一位玩家或隊伍
+type TurnEvent struct {
+	CurrentTurn int    `json:"currentTurn"`
+	PlayerName  string `json:"playerName,omitempty"`
+	Team        int    `json:"team,omitempty"`
+}
+
+// GuessSubmittedEvent 同時猜測模式的提交進度，不含數字
+type GuessSubmittedEvent struct {
+	PlayerName string `json:"playerName"`
+	GuessRound int    `json:"guessRound"`
+	Submitted  int    `json:"submitted"`
+	Total      int    `json:"total"`
+}
+
+// RoundRevealEvent 同時猜測模式揭曉回合
+type RoundRevealEvent struct {
+	GuessRound int                    `json:"guessRound"`
+	Guesses    []models.RevealedGuess `json:"guesses"`
+}
+
+// RoundStartedEvent 同時猜測模式開始新回合
+type RoundStartedEvent struct {
+	GuessRound    int    `json:"guessRound"`
+	RoundDeadline string `json:"roundDeadline"`
+}
+
+// TeamsUpdatedEvent 分隊結果
+type TeamsUpdatedEvent struct {
+	TeamCount int                 `json:"teamCount"`
+	Teams     map[string][]string `json:"teams"`
+	Players   []PlayerInfo        `json:"players"`
+}
+
+// EliminationEvent 淘汰模式每輪結束的結果
+type EliminationEvent struct {
+	Elimination *models.Elimination `json:"elimination"`
+	Remaining   int                 `json:"remaining"`
+}
+
+// ItemUsedEvent 使用道具；private 為 true 時只有使用者收得到，result 為偷看等結果
+type ItemUsedEvent struct {
+	PlayerName string `json:"playerName"`
+	Item       string `json:"item"`
+	Remaining  int    `json:"remaining"`
+	Private    bool   `json:"private,omitempty"`
+	Result     string `json:"result,omitempty"`
+}
@@ -0,0 +1,80 @@
+package protocol
+
+import "game/models"
+
+// EventSpec 一種事件類型與其 payload 的型別
+type EventSpec struct {
+	Type        string
+	Description string
+	Payload     interface{}
+}
+
+// ClientEvents 客戶端可以送出的請求
+var ClientEvents = []EventSpec{
+	{models.EventAuthenticate, "認證", EmptyRequest{}},
+	{models.EventJoinGame, "加入遊戲", EmptyRequest{}},
+	{models.EventLeftGame, "離開遊戲", EmptyRequest{}},
+	{models.EventPlayerReady, "準備或取消準備", EmptyRequest{}},
+	{models.EventStartGame, "開始遊戲", EmptyRequest{}},
+	{models.EventGameReset, "重置遊戲", EmptyRequest{}},
+	{models.EventChat, "聊天；以 / 開頭為聊天指令，/help 列出可用指令", ChatRequest{}},
+	{models.EventPlayerGuess, "猜數字", GuessRequest{}},
+	{models.EventChooseTeam, "選擇隊伍", ChooseTeamRequest{}},
+	{models.EventBalanceTeams, "房主自動分隊", EmptyRequest{}},
+	{models.EventTeamChat, "隊伍頻道", ChatRequest{}},
+	{models.EventUseItem, "使用道具", UseItemRequest{}},
+	{models.EventMute, "房主禁言玩家", MuteRequest{}},
+	{models.EventUnmute, "房主解除禁言", UnmuteRequest{}},
+	{models.EventReport, "檢舉玩家的聊天內容", ReportRequest{}},
+	{models.EventDirectMessage, "私訊其他使用者，不在線上時存入收件匣；房間內的悄悄話以 chat 送出 /w 名稱 訊息", DirectMessageRequest{}},
+	{models.EventInvite, "邀請在大廳的好友加入目前的房間", InviteRequest{}},
+	{EventResync, "要求重新同步房間狀態", EmptyRequest{}},
+}
+
+// ServerEvents 伺服器送出的事件
+var ServerEvents = []EventSpec{
+	{EventWelcome, "連線成功，告知協商的協定版本", WelcomeEvent{}},
+	{EventAck, "請求處理成功，帶回 requestId", AckEvent{}},
+	{EventSync, "完整房間狀態，只傳給剛連線或要求重新同步的玩家", SyncEvent{}},
+	{EventError, "錯誤，code 為穩定的錯誤碼，回覆請求時帶回 requestId", Error{}},
+	{models.EventAuthenticate, "認證成功", AuthEvent{}},
+	{models.EventChat, "聊天訊息", ChatEvent{}},
+	{EventChatHistory, "房間最近的聊天紀錄，只傳給剛加入的玩家", ChatHistoryEvent{}},
+	{models.EventTeamChat, "隊伍頻道訊息", TeamChatEvent{}},
+	{models.EventPlayerJoined, "玩家加入", PresenceEvent{}},
+	{models.EventPlayerLeft, "玩家離開", PresenceEvent{}},
+	{models.EventPlayerReady, "玩家準備狀態改變", PlayerReadyEvent{}},
+	{EventRoomStatus, "房間狀態", RoomStatusEvent{}},
+	{models.EventGameStarted, "遊戲開始", GameStartedEvent{}},
+	{models.EventPlayerGuess, "猜測結果", GuessEvent{}},
+	{models.EventGameOver, "遊戲結束", GameOverEvent{}},
+	{EventPlayerTurn, "輪到下一位玩家或隊伍", TurnEvent{}},
+	{models.EventGameReset, "遊戲已重置", EmptyEvent{}},
+	{models.EventGuessSubmitted, "同時猜測模式的提交進度", GuessSubmittedEvent{}},
+	{models.EventRoundReveal, "同時猜測模式揭曉回合", RoundRevealEvent{}},
+	{models.EventRoundStarted, "同時猜測模式開始新回合", RoundStartedEvent{}},
+	{models.EventTeamsUpdated, "分隊結果", TeamsUpdatedEvent{}},
+	{models.EventPlayerEliminated, "淘汰模式每輪結果", EliminationEvent{}},
+	{models.EventItemUsed, "使用道具", ItemUsedEvent{}},
+	{models.EventPlayerMuted, "玩家被房主禁言", MuteEvent{}},
+	{models.EventPlayerUnmuted, "玩家被解除禁言", MuteEvent{}},
+	{models.EventReportReceived, "檢舉已送出", ReportReceivedEvent{}},
+	{models.EventWhisper, "房間內的悄悄話", PrivateMessageEvent{}},
+	{EventCommand, "聊天指令的結果", CommandResultEvent{}},
+	{models.EventPlayerKicked, "玩家被房主踢出", PresenceEvent{}},
+	{models.EventGameInvite, "好友的遊戲邀請（大廳連線）", InviteEvent{}},
+	{models.EventInviteSent, "遊戲邀請已送出", InviteSentEvent{}},
+	{models.EventDirectMessage, "跨房間私訊", PrivateMessageEvent{}},
+	{models.EventNotification, "站內通知（房間與大廳連線）", NotificationEvent{}},
+	{models.EventAchievementUnlocked, "玩家達成成就", AchievementEvent{}},
+}
+
+// FindEvent 依類型找出事件定義
+func FindEvent(specs []EventSpec, eventType string) (EventSpec, bool) {
+	for _, spec := range specs {
+		if spec.Type == eventType {
+			return spec, true
+		}
+	}
+	return EventSpec{}, false
+}
@@ -0,0 +1,250 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Inbound 客戶端傳來的訊息；v2 把內容放在 payload，舊版放在 message。
+// requestId 為選填，伺服器會在直接回覆的 ack 或 error 中帶回
+type Inbound struct {
+	Version   int             `json:"v,omitempty"`
+	Type      string          `json:"type"`
+	RequestId string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Message   json.RawMessage `json:"message,omitempty"`
+}
+
+// DecodeInbound 解析客戶端訊息的外層
+func DecodeInbound(data []byte) (*Inbound, error) {
+	var msg Inbound
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, Errorf(ErrInvalidMessage, "無法解析的訊息: %v", err)
+	}
+	if msg.Type == "" {
+		return nil, Errorf(ErrInvalidMessage, "訊息缺少 type")
+	}
+	if len(msg.RequestId) > MaxRequestIdLength {
+		return nil, Errorf(ErrInvalidMessage, "requestId 長度不可超過 %d", MaxRequestIdLength)
+	}
+	return &msg, nil
+}
+
+// MaxRequestIdLength requestId 的長度上限
+const MaxRequestIdLength = 64
+
+// Body 事件內容，優先使用 payload
+func (m *Inbound) Body() json.RawMessage {
+	if len(m.Payload) > 0 {
+		return m.Payload
+	}
+	return m.Message
+}
+
+// EmptyRequest 不需要內容的請求，例如 join_game、start_game
+type EmptyRequest struct{}
+
+// ChatRequest 聊天與隊伍頻道
+type ChatRequest struct {
+	Text string `json:"text"`
+}
+
+// GuessRequest 猜數字
+type GuessRequest struct {
+	Guess int `json:"guess"`
+}
+
+// ChooseTeamRequest 選擇隊伍
+type ChooseTeamRequest struct {
+	Team int `json:"team"`
+}
+
+// UseItemRequest 使用道具：peek 得知答案奇偶、skip 跳過回合、double_guess 本回合多猜一次（回合結束未用完即失效）、
+// shield 只能在淘汰模式使用，效果是免於下一次淘汰而不是抵擋一次猜錯
+type UseItemRequest struct {
+	Item string `json:"item"`
+}
+
+// MuteRequest 房主禁言玩家，minutes 省略時使用預設值
+type MuteRequest struct {
+	Target  string `json:"target"` // 玩家 UUID
+	Minutes int    `json:"minutes,omitempty"`
+}
+
+// UnmuteRequest 房主解除禁言
+type UnmuteRequest struct {
+	Target string `json:"target"`
+}
+
+// ReportRequest 檢舉玩家的聊天內容
+type ReportRequest struct {
+	Target string `json:"target"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DirectMessageRequest 跨房間私訊線上或離線的使用者
+type DirectMessageRequest struct {
+	To   string `json:"to"` // 使用者 UUID
+	Text string `json:"text"`
+}
+
+// InviteRequest 邀請好友加入目前的房間
+type InviteRequest struct {
+	To string `json:"to"` // 好友的 UUID
+}
+
+// DecodeChat 接受 {"text": "..."}，以及舊版直接傳字串的格式
+func DecodeChat(raw json.RawMessage) (ChatRequest, error) {
+	var req ChatRequest
+	if isObject(raw) {
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return req, Errorf(ErrInvalidPayload, "聊天訊息格式錯誤")
+		}
+	} else if err := json.Unmarshal(raw, &req.Text); err != nil {
+		// 舊版客戶端可能送出數字等非字串內容，直接當成文字
+		req.Text = strings.TrimSpace(string(raw))
+	}
+	if req.Text == "" {
+		return req, Errorf(ErrInvalidPayload, "聊天訊息不可為空")
+	}
+	return req, nil
+}
+
+// DecodeGuess 接受 {"guess": 50}，以及舊版的數字或數字字串
+func DecodeGuess(raw json.RawMessage) (GuessRequest, error) {
+	guess, err := decodeInt(raw, "guess")
+	if err != nil {
+		return GuessRequest{}, Errorf(ErrInvalidPayload, "請輸入有效的數字")
+	}
+	return GuessRequest{Guess: guess}, nil
+}
+
+// DecodeChooseTeam 接受 {"team": 1}，以及舊版的數字或數字字串
+func DecodeChooseTeam(raw json.RawMessage) (ChooseTeamRequest, error) {
+	team, err := decodeInt(raw, "team")
+	if err != nil {
+		return ChooseTeamRequest{}, Errorf(ErrInvalidPayload, "請輸入有效的隊伍編號")
+	}
+	return ChooseTeamRequest{Team: team}, nil
+}
+
+// DecodeUseItem 接受 {"item": "peek"}，以及舊版直接傳字串的格式
+func DecodeUseItem(raw json.RawMessage) (UseItemRequest, error) {
+	var req UseItemRequest
+	var err error
+	if isObject(raw) {
+		err = json.Unmarshal(raw, &req)
+	} else {
+		err = json.Unmarshal(raw, &req.Item)
+	}
+	if err != nil || req.Item == "" {
+		return req, Errorf(ErrInvalidPayload, "道具格式錯誤")
+	}
+	return req, nil
+}
+
+func isObject(raw json.RawMessage) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
+
+// 解析整數欄位：物件中的指定欄位、數字或數字字串
+func decodeInt(raw json.RawMessage, field string) (int, error) {
+	if isObject(raw) {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return 0, err
+		}
+		raw = obj[field]
+	}
+	var number float64
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return int(number), nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(text))
+}
+
+// DecodeMute 接受 {"target": "uuid", "minutes": 5}，以及舊版直接傳 UUID 字串的格式
+func DecodeMute(raw json.RawMessage) (MuteRequest, error) {
+	var req MuteRequest
+	if err := decodeTarget(raw, &req, &req.Target); err != nil {
+		return req, Errorf(ErrInvalidPayload, "禁言格式錯誤")
+	}
+	if req.Target == "" {
+		return req, Errorf(ErrInvalidPayload, "請指定要禁言的玩家")
+	}
+	if req.Minutes < 0 {
+		return req, Errorf(ErrInvalidPayload, "禁言時間不可為負數")
+	}
+	return req, nil
+}
+
+// DecodeUnmute 接受 {"target": "uuid"}，以及舊版直接傳 UUID 字串的格式
+func DecodeUnmute(raw json.RawMessage) (UnmuteRequest, error) {
+	var req UnmuteRequest
+	if err := decodeTarget(raw, &req, &req.Target); err != nil || req.Target == "" {
+		return req, Errorf(ErrInvalidPayload, "請指定要解除禁言的玩家")
+	}
+	return req, nil
+}
+
+// DecodeReport 接受 {"target": "uuid", "reason": "..."}，以及舊版直接傳 UUID 字串的格式
+func DecodeReport(raw json.RawMessage) (ReportRequest, error) {
+	var req ReportRequest
+	if err := decodeTarget(raw, &req, &req.Target); err != nil {
+		return req, Errorf(ErrInvalidPayload, "檢舉格式錯誤")
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Target == "" {
+		return req, Errorf(ErrInvalidPayload, "請指定要檢舉的玩家")
+	}
+	if len([]rune(req.Reason)) > MaxReportReasonLength {
+		return req, Errorf(ErrInvalidPayload, "檢舉原因不可超過 %d 個字", MaxReportReasonLength)
+	}
+	return req, nil
+}
+
+// DecodeDirectMessage 只接受 {"to": "uuid", "text": "..."}
+func DecodeDirectMessage(raw json.RawMessage) (DirectMessageRequest, error) {
+	var req DirectMessageRequest
+	if !isObject(raw) || json.Unmarshal(raw, &req) != nil {
+		return req, Errorf(ErrInvalidPayload, "私訊格式錯誤")
+	}
+	req.To = strings.TrimSpace(req.To)
+	if req.To == "" {
+		return req, Errorf(ErrInvalidPayload, "請指定私訊的對象")
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return req, Errorf(ErrInvalidPayload, "私訊內容不可為空")
+	}
+	return req, nil
+}
+
+// DecodeInvite 接受 {"to": "uuid"}，以及直接傳 UUID 字串的格式
+func DecodeInvite(raw json.RawMessage) (InviteRequest, error) {
+	var req InviteRequest
+	if err := decodeTarget(raw, &req, &req.To); err != nil || req.To == "" {
+		return req, Errorf(ErrInvalidPayload, "請指定要邀請的好友")
+	}
+	return req, nil
+}
+
+// 檢舉原因的長度上限
+const MaxReportReasonLength = 200
+
+// 物件格式解析到 req，否則把整個內容當成目標玩家的 UUID
+func decodeTarget(raw json.RawMessage, req interface{}, target *string) error {
+	var err error
+	if isObject(raw) {
+		err = json.Unmarshal(raw, req)
+	} else {
+		err = json.Unmarshal(raw, target)
+	}
+	*target = strings.TrimSpace(*target)
+	return err
+}
@@ -0,0 +1,198 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// 產生的 JSON Schema 依方向放在 schema/client 與 schema/server
+const (
+	DirectionClient = "client"
+	DirectionServer = "server"
+)
+
+// Schemas 產生所有 JSON Schema，key 為相對路徑，例如 server/player_guess.json
+func Schemas() map[string]interface{} {
+	schemas := map[string]interface{}{"index.json": Index()}
+	for _, spec := range ClientEvents {
+		schemas[DirectionClient+"/"+spec.Type+".json"] = ClientSchema(spec)
+	}
+	for _, spec := range ServerEvents {
+		schemas[DirectionServer+"/"+spec.Type+".json"] = ServerSchema(spec)
+	}
+	return schemas
+}
+
+// Index 協定總覽：版本、錯誤碼與所有事件
+func Index() map[string]interface{} {
+	return map[string]interface{}{
+		"currentVersion":    CurrentVersion,
+		"supportedVersions": SupportedVersions(),
+		"versionQueryParam": VersionQueryParam,
+		"encodings":         SupportedEncodings,
+		"errorCodes":        ErrorCodes,
+		DirectionClient:     eventIndex(DirectionClient, ClientEvents),
+		DirectionServer:     eventIndex(DirectionServer, ServerEvents),
+	}
+}
+
+func eventIndex(direction string, specs []EventSpec) []map[string]string {
+	events := make([]map[string]string, 0, len(specs))
+	for _, spec := range specs {
+		events = append(events, map[string]string{
+			"type":        spec.Type,
+			"description": spec.Description,
+			"schema":      direction + "/" + spec.Type + ".json",
+		})
+	}
+	return events
+}
+
+// ClientSchema 客戶端請求的 Schema
+func ClientSchema(spec EventSpec) map[string]interface{} {
+	properties := map[string]interface{}{
+		"v":         map[string]interface{}{"type": "integer", "enum": SupportedVersions()},
+		"type":      map[string]interface{}{"const": spec.Type},
+		"requestId": map[string]interface{}{"type": "string", "maxLength": MaxRequestIdLength},
+		"payload":   TypeSchema(reflect.TypeOf(spec.Payload)),
+	}
+	required := []string{"type"}
+	if hasFields(spec.Payload) {
+		required = append(required, "payload")
+	}
+	return documentSchema(DirectionClient, spec, properties, required)
+}
+
+// ServerSchema 伺服器事件（v2 Envelope）的 Schema
+func ServerSchema(spec EventSpec) map[string]interface{} {
+	payload := TypeSchema(reflect.TypeOf(spec.Payload))
+	if spec.Type == EventError {
+		payload["properties"].(map[string]interface{})["code"] = map[string]interface{}{"type": "string", "enum": ErrorCodes}
+	}
+	properties := map[string]interface{}{
+		"v":         map[string]interface{}{"const": Version2},
+		"type":      map[string]interface{}{"const": spec.Type},
+		"gameId":    map[string]interface{}{"type": "string"},
+		"from":      map[string]interface{}{"type": "string"},
+		"message":   map[string]interface{}{"type": "string"},
+		"timestamp": map[string]interface{}{"type": "string"},
+		"requestId": map[string]interface{}{"type": "string"},
+		"seq":       map[string]interface{}{"type": "integer", "minimum": 1},
+		"payload":   payload,
+	}
+	required := []string{"v", "type", "gameId", "timestamp"}
+	if hasFields(spec.Payload) {
+		required = append(required, "payload")
+	}
+	return documentSchema(DirectionServer, spec, properties, required)
+}
+
+func documentSchema(direction string, spec EventSpec, properties map[string]interface{}, required []string) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":     schemaDraft,
+		"$id":         direction + "/" + spec.Type + ".json",
+		"title":       spec.Type,
+		"description": spec.Description,
+		"type":        "object",
+		"properties":  properties,
+		"required":    required,
+	}
+}
+
+func hasFields(payload interface{}) bool {
+	t := reflect.TypeOf(payload)
+	return t.Kind() == reflect.Struct && t.NumField() > 0
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// TypeSchema 以反射將 Go 型別轉成 JSON Schema，依 encoding/json 的規則處理欄位名稱與 omitempty
+func TypeSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return TypeSchema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": TypeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": TypeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitEmpty := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		fieldSchema := TypeSchema(field.Type)
+		if field.Type.Kind() == reflect.Ptr && !omitEmpty {
+			// 沒有 omitempty 的指標欄位為 nil 時會輸出 null
+			fieldSchema = map[string]interface{}{"anyOf": []interface{}{fieldSchema, map[string]interface{}{"type": "null"}}}
+		}
+		properties[name] = fieldSchema
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{
+		"title":      t.Name(),
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	omitEmpty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
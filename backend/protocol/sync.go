@@ -0,0 +1,64 @@
+package protocol
+
+import "game/models"
+
+const (
+	EventSync   = "sync"   // 伺服器只傳給單一連線的完整房間狀態
+	EventResync = "resync" // 客戶端發現序號不連續時要求重新同步
+)
+
+// SyncEvent 房間的完整公開狀態；lastSeq 為快照當下的房間序號，之後的廣播序號都會大於它
+type SyncEvent struct {
+	LastSeq int64         `json:"lastSeq"`
+	Game    GameSnapshot  `json:"game"`
+	You     *SelfSnapshot `json:"you,omitempty"`
+}
+
+// GameSnapshot 遊戲的公開狀態，不含答案、鹽值與尚未揭曉的密封猜測；遊戲結束後才附上答案與鹽值
+type GameSnapshot struct {
+	Status           string               `json:"status"`
+	Mode             string               `json:"mode"`
+	FeedbackMode     string               `json:"feedbackMode"`
+	PowerUps         bool                 `json:"powerUps"`
+	NumOfPeople      int                  `json:"numOfPeople"`
+	MinRange         int                  `json:"minRange"`
+	MaxRange         int                  `json:"maxRange"`
+	Round            int                  `json:"round"`
+	Rotation         int                  `json:"rotation"`
+	CurrentTurn      int                  `json:"currentTurn"`
+	CurrentPlayer    string               `json:"currentPlayer,omitempty"`
+	TeamCount        int                  `json:"teamCount,omitempty"`
+	WinningTeam      int                  `json:"winningTeam,omitempty"`
+	GuessRound       int                  `json:"guessRound,omitempty"`
+	RoundDeadline    string               `json:"roundDeadline,omitempty"`
+	Submitted        []string             `json:"submitted"` // 本輪已猜測或已提交密封猜測的玩家 uuid
+	Commitment       string               `json:"commitment,omitempty"`
+	CommitmentScheme string               `json:"commitmentScheme,omitempty"`
+	Answer           *int                 `json:"answer,omitempty"`
+	Salt             string               `json:"salt,omitempty"`
+	Players          []PlayerSnapshot     `json:"players"`
+	Guesses          []models.GuessRecord `json:"guesses"`
+	Items            []models.ItemUsage   `json:"items"`
+}
+
+// PlayerSnapshot 玩家的公開狀態
+type PlayerSnapshot struct {
+	Uuid       string `json:"uuid"`
+	Name       string `json:"name"`
+	Avatar     string `json:"avatar,omitempty"`
+	IsReady    bool   `json:"isReady"`
+	Team       int    `json:"team"`
+	Eliminated bool   `json:"eliminated"`
+	Score      int    `json:"score"`
+	GuessCount int    `json:"guessCount"`
+	TurnOrder  int    `json:"turnOrder"`
+	MutedUntil string `json:"mutedUntil,omitempty"` // 被禁言時的解除時間（RFC3339）
+}
+
+// SelfSnapshot 只有自己看得到的狀態，例如道具庫存
+type SelfSnapshot struct {
+	Uuid         string         `json:"uuid"`
+	Items        map[string]int `json:"items"`
+	ExtraGuesses int            `json:"extraGuesses"`
+	Shielded     bool           `json:"shielded"`
+}
@@ -0,0 +1,41 @@
+// Package protocol 定義遊戲 WebSocket 的訊息格式：版本協商、各事件的型別化請求與事件內容、錯誤碼，
+// 以及產生 JSON Schema 供第三方客戶端驗證。
+package protocol
+
+//go:generate go run ../cmd/genschema -out schema
+
+import "strconv"
+
+const (
+	// VersionLegacy 舊版格式：伺服器送出 models.GameMessage，客戶端以 {type, message} 傳送
+	VersionLegacy = 1
+	// Version2 型別化格式：伺服器送出 Envelope，事件內容放在 payload
+	Version2 = 2
+
+	CurrentVersion = Version2
+	MinVersion     = VersionLegacy
+
+	// 連線時透過查詢參數協商版本，例如 /wsGame?protocol=2
+	VersionQueryParam = "protocol"
+)
+
+// SupportedVersions 伺服器支援的所有協定版本
+func SupportedVersions() []int {
+	versions := make([]int, 0, CurrentVersion-MinVersion+1)
+	for v := MinVersion; v <= CurrentVersion; v++ {
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// Negotiate 解析客戶端要求的版本，未指定時使用舊版格式以相容現有前端
+func Negotiate(requested string) (int, error) {
+	if requested == "" {
+		return VersionLegacy, nil
+	}
+	version, err := strconv.Atoi(requested)
+	if err != nil || version < MinVersion || version > CurrentVersion {
+		return 0, Errorf(ErrUnsupportedVersion, "不支援的協定版本: %s，支援 %d 到 %d", requested, MinVersion, CurrentVersion)
+	}
+	return version, nil
+}
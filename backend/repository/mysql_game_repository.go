@@ -3,6 +3,7 @@ package repository
 import (
 	"game/models"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -43,6 +44,12 @@ func (r *MySQLGameService) AddGameResult(gameResult models.GameResults) error {
 	return r.db.Save(&gameResult).Error
 }
 
+func (r *MySQLGameService) GetGameResult(gameID string, round int) (models.GameResults, error) {
+	var gameResult models.GameResults
+	err := r.db.First(&gameResult, "game_id = ? AND round = ?", gameID, round).Error
+	return gameResult, err
+}
+
 func (r *MySQLGameService) AddGamePlayer(gamePlayer models.GamePlayers) error {
 	return r.db.Create(&gamePlayer).Error
 }
@@ -53,6 +60,12 @@ func (r *MySQLGameService) GetUser(email string) (models.Users, error) {
 	return user, err
 }
 
+func (r *MySQLGameService) GetUserByID(id string) (models.Users, error) {
+	var user models.Users
+	err := r.db.Select("id", "username").First(&user, "id = ?", id).Error
+	return user, err
+}
+
 func (r *MySQLGameService) CreateUser(user models.Users) error {
 	return r.db.Create(&user).Error
 }
@@ -60,13 +73,15 @@ func (r *MySQLGameService) CreateUser(user models.Users) error {
 func (r *MySQLGameService) GetTopPlayers(limit int) ([]models.Leaderboard, error) {
 	var leaderboard []models.Leaderboard
 
-	err := r.db.Table("game_results AS gr").
-		Select("u.id AS user_id, u.username, COUNT(gr.id) AS win_count").
-		Joins("JOIN users u ON gr.winner_id = u.id").
-		Where("gr.winner_id IS NOT NULL").
+	// 個人獲勝（winner_id）或隊伍獲勝（won）都計入勝場
+	err := r.db.Table("game_players AS gp").
+		Select("u.id AS user_id, u.username, COUNT(gp.id) AS win_count").
+		Joins("JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Joins("JOIN users u ON gp.user_id = u.id").
+		Where("gp.won = ? OR gr.winner_id = gp.user_id", true).
 		Group("u.id, u.username").
 		Order("win_count DESC").
-		Limit(10).
+		Limit(limit).
 		Scan(&leaderboard).Error
 
 	if err != nil {
@@ -74,3 +89,467 @@ func (r *MySQLGameService) GetTopPlayers(limit int) ([]models.Leaderboard, error
 	}
 	return leaderboard, err
 }
+
+func (r *MySQLGameService) GetPlayerStats(username string) (models.PlayerStats, error) {
+	var stats models.PlayerStats
+
+	err := r.db.Table("users AS u").
+		Select("u.username, COUNT(gp.id) AS games_played, "+
+			"COALESCE(SUM(CASE WHEN gp.won OR gr.winner_id = gp.user_id THEN 1 ELSE 0 END), 0) AS wins, "+
+			"COALESCE(SUM(gp.guess_count), 0) AS total_guesses").
+		Joins("LEFT JOIN game_players gp ON gp.user_id = u.id").
+		Joins("LEFT JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Where("u.username = ?", username).
+		Group("u.username").
+		Scan(&stats).Error
+
+	if err != nil {
+		log.Println("查詢玩家統計失敗:", err)
+	}
+	return stats, err
+}
+
+func (r *MySQLGameService) GetPlayerStatsByID(userID string) (models.PlayerStats, error) {
+	var stats models.PlayerStats
+
+	err := r.db.Table("users AS u").
+		Select("u.username, COUNT(gp.id) AS games_played, "+
+			"COALESCE(SUM(CASE WHEN gp.won OR gr.winner_id = gp.user_id THEN 1 ELSE 0 END), 0) AS wins, "+
+			"COALESCE(SUM(gp.guess_count), 0) AS total_guesses").
+		Joins("LEFT JOIN game_players gp ON gp.user_id = u.id").
+		Joins("LEFT JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Where("u.id = ?", userID).
+		Group("u.username").
+		Scan(&stats).Error
+
+	if err != nil {
+		log.Println("查詢玩家統計失敗:", err)
+	}
+	return stats, err
+}
+
+// 玩家最近幾輪的勝負，由新到舊
+func (r *MySQLGameService) GetRecentOutcomes(userID string, limit int) ([]bool, error) {
+	var outcomes []bool
+
+	err := r.db.Table("game_players AS gp").
+		Joins("JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Where("gp.user_id = ?", userID).
+		Order("gr.finished_at DESC").
+		Limit(limit).
+		Pluck("(gp.won OR COALESCE(gr.winner_id = gp.user_id, FALSE))", &outcomes).Error
+
+	if err != nil {
+		log.Println("查詢玩家近期戰績失敗:", err)
+	}
+	return outcomes, err
+}
+
+// 個人頁面的勝場、場數與獲勝時的平均猜測次數
+func (r *MySQLGameService) GetProfileStats(userID string) (models.ProfileStats, error) {
+	var stats models.ProfileStats
+
+	err := r.db.Table("game_players AS gp").
+		Select("COUNT(gp.id) AS games_played, "+
+			"COALESCE(SUM(CASE WHEN gp.won OR gr.winner_id = gp.user_id THEN 1 ELSE 0 END), 0) AS wins, "+
+			"COALESCE(AVG(CASE WHEN gp.won OR gr.winner_id = gp.user_id THEN gp.guess_count END), 0) AS avg_guesses_per_win").
+		Joins("LEFT JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Where("gp.user_id = ?", userID).
+		Scan(&stats).Error
+
+	if err != nil {
+		log.Println("查詢個人統計失敗:", err)
+	}
+	return stats, err
+}
+
+// 玩家最常玩的模式，沒有紀錄時回傳空字串
+func (r *MySQLGameService) GetFavouriteMode(userID string) (string, error) {
+	var modes []string
+
+	err := r.db.Table("game_players AS gp").
+		Joins("JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Where("gp.user_id = ?", userID).
+		Group("gr.mode").
+		Order("COUNT(*) DESC").
+		Limit(1).
+		Pluck("gr.mode", &modes).Error
+
+	if err != nil || len(modes) == 0 {
+		return "", err
+	}
+	return modes[0], nil
+}
+
+func (r *MySQLGameService) GetRecentGames(userID string, limit int) ([]models.RecentGame, error) {
+	var games []models.RecentGame
+
+	err := r.db.Table("game_players AS gp").
+		Select("gp.game_id, gp.game_results_round AS round, gr.mode, "+
+			"(gp.won OR COALESCE(gr.winner_id = gp.user_id, FALSE)) AS won, "+
+			"COALESCE(gp.guess_count, 0) AS guess_count, COALESCE(gp.score, 0) AS score, "+
+			"COALESCE(gr.total_players, 0) AS total_players, gr.finished_at").
+		Joins("JOIN game_results gr ON gr.game_id = gp.game_id AND gr.round = gp.game_results_round").
+		Where("gp.user_id = ?", userID).
+		Order("gr.finished_at DESC").
+		Limit(limit).
+		Scan(&games).Error
+
+	if err != nil {
+		log.Println("查詢最近遊戲失敗:", err)
+	}
+	return games, err
+}
+
+func (r *MySQLGameService) AddDailyResult(result models.DailyResults) error {
+	return r.db.Create(&result).Error
+}
+
+func (r *MySQLGameService) GetDailyResult(userID string, date string) (models.DailyResults, error) {
+	var result models.DailyResults
+	err := r.db.First(&result, "user_id = ? AND date = ?", userID, date).Error
+	return result, err
+}
+
+func (r *MySQLGameService) GetDailyStreak(userID string) (models.DailyStreaks, error) {
+	var streak models.DailyStreaks
+	err := r.db.First(&streak, "user_id = ?", userID).Error
+	return streak, err
+}
+
+func (r *MySQLGameService) SaveDailyStreak(streak models.DailyStreaks) error {
+	return r.db.Save(&streak).Error
+}
+
+func (r *MySQLGameService) GetDailyLeaderboard(date string, limit int) ([]models.DailyLeaderboard, error) {
+	var leaderboard []models.DailyLeaderboard
+
+	err := r.db.Table("daily_results AS dr").
+		Select("u.username, dr.guess_count, dr.duration_ms").
+		Joins("JOIN users u ON dr.user_id = u.id").
+		Where("dr.date = ?", date).
+		Order("dr.guess_count ASC, dr.duration_ms ASC").
+		Limit(limit).
+		Scan(&leaderboard).Error
+
+	if err != nil {
+		log.Println("查詢每日挑戰排行榜失敗:", err)
+	}
+	return leaderboard, err
+}
+
+func (r *MySQLGameService) AddChatReport(report models.ChatReports) error {
+	return r.db.Create(&report).Error
+}
+
+func (r *MySQLGameService) GetChatReports(status string, limit int) ([]models.ChatReports, error) {
+	var reports []models.ChatReports
+	query := r.db.Order("created_at DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&reports).Error
+	return reports, err
+}
+
+func (r *MySQLGameService) GetChatReport(id string) (models.ChatReports, error) {
+	var report models.ChatReports
+	err := r.db.First(&report, "id = ?", id).Error
+	return report, err
+}
+
+func (r *MySQLGameService) SaveChatReport(report models.ChatReports) error {
+	return r.db.Save(&report).Error
+}
+
+func (r *MySQLGameService) AddChatArchive(archive models.ChatArchives) error {
+	return r.db.Create(&archive).Error
+}
+
+func (r *MySQLGameService) GetChatArchive(gameID string, round int) (models.ChatArchives, error) {
+	var archive models.ChatArchives
+	err := r.db.First(&archive, "game_id = ? AND round = ?", gameID, round).Error
+	return archive, err
+}
+
+func (r *MySQLGameService) GetUserByName(username string) (models.Users, error) {
+	var user models.Users
+	err := r.db.Select("id", "username").First(&user, "username = ?", username).Error
+	return user, err
+}
+
+func (r *MySQLGameService) AddFriendship(friendship models.Friendships) error {
+	return r.db.Create(&friendship).Error
+}
+
+func (r *MySQLGameService) GetFriendship(id string) (models.Friendships, error) {
+	var friendship models.Friendships
+	err := r.db.First(&friendship, "id = ?", id).Error
+	return friendship, err
+}
+
+// 兩人之間的好友關係，不分方向
+func (r *MySQLGameService) GetFriendshipBetween(userA string, userB string) (models.Friendships, error) {
+	var friendship models.Friendships
+	err := r.db.Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+		userA, userB, userB, userA).First(&friendship).Error
+	return friendship, err
+}
+
+func (r *MySQLGameService) SaveFriendship(friendship models.Friendships) error {
+	return r.db.Save(&friendship).Error
+}
+
+func (r *MySQLGameService) DeleteFriendship(id string) error {
+	return r.db.Delete(&models.Friendships{}, "id = ?", id).Error
+}
+
+func (r *MySQLGameService) GetFriends(userID string) ([]models.FriendInfo, error) {
+	var friends []models.FriendInfo
+
+	err := r.db.Table("friendships AS f").
+		Select("u.id AS user_id, u.username, DATE_FORMAT(COALESCE(f.responded_at, f.created_at), '%Y-%m-%d') AS since").
+		Joins("JOIN users u ON u.id = CASE WHEN f.requester_id = ? THEN f.addressee_id ELSE f.requester_id END", userID).
+		Where("(f.requester_id = ? OR f.addressee_id = ?) AND f.status = ?", userID, userID, models.FriendAccepted).
+		Order("u.username").
+		Scan(&friends).Error
+
+	if err != nil {
+		log.Println("查詢好友列表失敗:", err)
+	}
+	return friends, err
+}
+
+func (r *MySQLGameService) GetPendingFriendRequests(userID string) ([]models.FriendRequest, error) {
+	var requests []models.FriendRequest
+
+	err := r.db.Table("friendships AS f").
+		Select("f.id, u.id AS user_id, u.username, f.created_at").
+		Joins("JOIN users u ON u.id = f.requester_id").
+		Where("f.addressee_id = ? AND f.status = ?", userID, models.FriendPending).
+		Order("f.created_at DESC").
+		Scan(&requests).Error
+
+	if err != nil {
+		log.Println("查詢好友邀請失敗:", err)
+	}
+	return requests, err
+}
+
+func (r *MySQLGameService) AddNotification(notification models.Notifications) error {
+	return r.db.Create(&notification).Error
+}
+
+// 依時間由新到舊列出通知；before 不為零值時只取該時間之前的通知，用於分頁
+func (r *MySQLGameService) GetNotifications(userID string, unreadOnly bool, before time.Time, limit int) ([]models.Notifications, error) {
+	var notifications []models.Notifications
+	query := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if !before.IsZero() {
+		query = query.Where("created_at < ?", before)
+	}
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *MySQLGameService) CountUnreadNotifications(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notifications{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// 將通知標為已讀，ids 為空時標記該使用者所有的未讀通知；回傳實際更新的筆數
+func (r *MySQLGameService) MarkNotificationsRead(userID string, ids []string) (int64, error) {
+	query := r.db.Model(&models.Notifications{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *MySQLGameService) AddUserAchievement(achievement models.UserAchievements) error {
+	return r.db.Create(&achievement).Error
+}
+
+func (r *MySQLGameService) GetUserAchievements(userID string) ([]models.UserAchievements, error) {
+	var achievements []models.UserAchievements
+	err := r.db.Where("user_id = ?", userID).Order("unlocked_at").Find(&achievements).Error
+	return achievements, err
+}
+
+// 個人頁面的使用者資料，不含密碼
+func (r *MySQLGameService) GetUserProfile(id string) (models.Users, error) {
+	var user models.Users
+	err := r.db.Select("id", "username", "email", "email_verified_at", "avatar_url", "rating", "created_at").First(&user, "id = ?", id).Error
+	return user, err
+}
+
+// 更新使用者資料的指定欄位
+func (r *MySQLGameService) UpdateUser(id string, fields map[string]interface{}) error {
+	return r.db.Model(&models.Users{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *MySQLGameService) GetRatings(userIDs []string) (map[string]int, error) {
+	var users []models.Users
+	if err := r.db.Select("id", "rating").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	ratings := make(map[string]int, len(users))
+	for _, user := range users {
+		ratings[user.ID] = user.Rating
+	}
+	return ratings, nil
+}
+
+// 在同一個交易中套用積分變化
+func (r *MySQLGameService) ApplyRatingDeltas(deltas map[string]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for userID, delta := range deltas {
+			if delta == 0 {
+				continue
+			}
+			err := tx.Model(&models.Users{}).Where("id = ?", userID).
+				Update("rating", gorm.Expr("rating + ?", delta)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *MySQLGameService) AddRefreshToken(token models.RefreshTokens) error {
+	return r.db.Create(&token).Error
+}
+
+func (r *MySQLGameService) GetRefreshTokenByHash(tokenHash string) (models.RefreshTokens, error) {
+	var token models.RefreshTokens
+	err := r.db.First(&token, "token_hash = ?", tokenHash).Error
+	return token, err
+}
+
+// 在同一個交易中撤銷舊 token 並寫入換發的新 token，回傳是否由這次呼叫換發
+// （同時換發時只有一個請求會成功；寫入失敗時舊 token 維持有效）
+func (r *MySQLGameService) RotateRefreshToken(id string, next models.RefreshTokens) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshTokens{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by": next.ID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// 撤銷同一個 family 中所有尚未撤銷的 token
+func (r *MySQLGameService) RevokeRefreshTokenFamily(familyID string) error {
+	return r.db.Model(&models.RefreshTokens{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// 撤銷使用者所有尚未撤銷的 refresh token
+func (r *MySQLGameService) RevokeUserRefreshTokens(userID string) error {
+	return r.db.Model(&models.RefreshTokens{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *MySQLGameService) AddSession(session models.Sessions) error {
+	return r.db.Create(&session).Error
+}
+
+func (r *MySQLGameService) GetSession(id string) (models.Sessions, error) {
+	var session models.Sessions
+	err := r.db.First(&session, "id = ?", id).Error
+	return session, err
+}
+
+// 更新 session 的最後使用時間與裝置資訊
+func (r *MySQLGameService) TouchSession(id string, userAgent string, ip string) error {
+	return r.db.Model(&models.Sessions{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": time.Now(), "user_agent": userAgent, "ip": ip}).Error
+}
+
+// 使用者尚未撤銷且在 since 之後使用過的 session，最近使用的在前
+func (r *MySQLGameService) GetActiveSessions(userID string, since time.Time) ([]models.Sessions, error) {
+	var sessions []models.Sessions
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, since).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// 建立新的一次性 token，同一用途尚未使用的舊 token 一併作廢
+func (r *MySQLGameService) ReplaceUserToken(token models.UserTokens) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.UserTokens{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+}
+
+func (r *MySQLGameService) GetUserTokenByHash(tokenHash string) (models.UserTokens, error) {
+	var token models.UserTokens
+	err := r.db.First(&token, "token_hash = ?", tokenHash).Error
+	return token, err
+}
+
+// since 之後建立的同用途 token 數，用來限制寄信頻率
+func (r *MySQLGameService) CountUserTokensSince(userID string, purpose string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserTokens{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, since).
+		Count(&count).Error
+	return count, err
+}
+
+// 使用 token 並更新使用者欄位，token 已被使用時回傳 false 且不更新
+func (r *MySQLGameService) ConsumeUserToken(id string, userID string, fields map[string]interface{}) (bool, error) {
+	consumed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserTokens{}).
+			Where("id = ? AND used_at IS NULL", id).
+			Update("used_at", time.Now())
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Model(&models.Users{}).Where("id = ?", userID).Updates(fields).Error; err != nil {
+			return err
+		}
+		consumed = true
+		return nil
+	})
+	return consumed, err
+}
+
+// 撤銷 session 並撤銷它所有的 refresh token
+func (r *MySQLGameService) RevokeSession(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&models.Sessions{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshTokens{}).
+			Where("family_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+	})
+}
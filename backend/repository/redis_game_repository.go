@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -43,6 +44,47 @@ func (r *RedisGameService) GetGame(ctx context.Context, gameID string) (*models.
 	return &game, nil
 }
 
+// 樂觀鎖衝突時的重試次數
+const gameUpdateRetries = 10
+
+// UpdateGame 以 WATCH/MULTI 讀取、修改並寫回遊戲，期間有其他寫入時重新讀取並重試；
+// fn 回傳 false 或錯誤時不寫入，回傳最後一次讀到（並修改）的遊戲
+func (r *RedisGameService) UpdateGame(ctx context.Context, gameID string, ttl time.Duration, fn func(game *models.Game) (bool, error)) (*models.Game, error) {
+	key := fmt.Sprintf("game:%s", gameID)
+	var game *models.Game
+	txf := func(tx *redis.Tx) error {
+		val, err := tx.Get(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		game = &models.Game{}
+		if err := json.Unmarshal([]byte(val), game); err != nil {
+			return err
+		}
+		save, err := fn(game)
+		if err != nil || !save {
+			return err
+		}
+		data, err := json.Marshal(game)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < gameUpdateRetries; i++ {
+		err := r.redisClient.Watch(ctx, txf, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return game, err
+		}
+	}
+	return nil, fmt.Errorf("更新遊戲 %s 失敗：同時寫入次數過多", gameID)
+}
+
 func (r *RedisGameService) DeleteGame(ctx context.Context, gameID string) error {
 	key := fmt.Sprintf("game:%s", gameID)
 	return r.redisClient.Del(ctx, key).Err()
@@ -67,3 +109,263 @@ func (r *RedisGameService) GetAllGames(ctx context.Context) ([]*models.Game, err
 	}
 	return games, nil
 }
+
+func (r *RedisGameService) SaveDailyAttempt(ctx context.Context, attempt *models.DailyAttempt, ttl time.Duration) error {
+	data, err := json.Marshal(attempt)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("daily:%s:%s", attempt.Date, attempt.UserID)
+	return r.redisClient.Set(ctx, key, data, ttl).Err()
+}
+
+func (r *RedisGameService) GetDailyAttempt(ctx context.Context, date string, userID string) (*models.DailyAttempt, error) {
+	key := fmt.Sprintf("daily:%s:%s", date, userID)
+	val, err := r.redisClient.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	var attempt models.DailyAttempt
+	if err := json.Unmarshal([]byte(val), &attempt); err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// UpdateDailyAttempt 以 WATCH/MULTI 讀取、修改並寫回每日挑戰，同時送出的猜測不會互相覆蓋；
+// fn 回傳 false 或錯誤時不寫入，挑戰不存在時回傳 redis.Nil
+func (r *RedisGameService) UpdateDailyAttempt(ctx context.Context, date string, userID string, ttl time.Duration, fn func(attempt *models.DailyAttempt) (bool, error)) (*models.DailyAttempt, error) {
+	key := fmt.Sprintf("daily:%s:%s", date, userID)
+	var attempt *models.DailyAttempt
+	txf := func(tx *redis.Tx) error {
+		val, err := tx.Get(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		attempt = &models.DailyAttempt{}
+		if err := json.Unmarshal([]byte(val), attempt); err != nil {
+			return err
+		}
+		save, err := fn(attempt)
+		if err != nil || !save {
+			return err
+		}
+		data, err := json.Marshal(attempt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < gameUpdateRetries; i++ {
+		err := r.redisClient.Watch(ctx, txf, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return attempt, err
+		}
+	}
+	return nil, fmt.Errorf("更新每日挑戰失敗：同時寫入次數過多")
+}
+
+// 聊天紀錄存在 chat:{房號} 的 list，只保留最新 limit 則，每次寫入都更新過期時間
+func (r *RedisGameService) AppendChat(ctx context.Context, gameID string, line models.ChatLine, limit int, ttl time.Duration) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("chat:%s", gameID)
+	pipe := r.redisClient.TxPipeline()
+	pipe.RPush(ctx, key, data)
+	pipe.LTrim(ctx, key, int64(-limit), -1)
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisGameService) GetChatHistory(ctx context.Context, gameID string) ([]models.ChatLine, error) {
+	key := fmt.Sprintf("chat:%s", gameID)
+	values, err := r.redisClient.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]models.ChatLine, 0, len(values))
+	for _, value := range values {
+		var line models.ChatLine
+		if err := json.Unmarshal([]byte(value), &line); err != nil {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// 離線私訊存在 inbox:{uuid} 的 list，只保留最新 limit 則
+func (r *RedisGameService) PushInbox(ctx context.Context, uuid string, message models.DirectMessage, limit int, ttl time.Duration) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("inbox:%s", uuid)
+	pipe := r.redisClient.TxPipeline()
+	pipe.RPush(ctx, key, data)
+	pipe.LTrim(ctx, key, int64(-limit), -1)
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// 讀取收件匣中所有私訊，不會刪除
+func (r *RedisGameService) GetInbox(ctx context.Context, uuid string) ([]models.DirectMessage, error) {
+	key := fmt.Sprintf("inbox:%s", uuid)
+	values, err := r.redisClient.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeInbox(values), nil
+}
+
+// 刪除收件匣中 untilID（含）以前的私訊，回傳刪除的則數；找不到該私訊時不刪除
+func (r *RedisGameService) AckInbox(ctx context.Context, uuid string, untilID string) (int, error) {
+	key := fmt.Sprintf("inbox:%s", uuid)
+	var removed int
+	txf := func(tx *redis.Tx) error {
+		removed = 0
+		values, err := tx.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+		for i, message := range decodeInbox(values) {
+			if message.ID == untilID {
+				removed = i + 1
+				break
+			}
+		}
+		if removed == 0 {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.LTrim(ctx, key, int64(removed), -1)
+			return nil
+		})
+		return err
+	}
+
+	// 寫入新私訊時會裁掉最舊的幾則，位置可能改變，因此以 WATCH 確保刪除的範圍正確
+	for i := 0; i < gameUpdateRetries; i++ {
+		err := r.redisClient.Watch(ctx, txf, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return removed, err
+		}
+	}
+	return 0, fmt.Errorf("更新收件匣失敗：同時寫入次數過多")
+}
+
+func decodeInbox(values []string) []models.DirectMessage {
+	messages := make([]models.DirectMessage, 0, len(values))
+	for _, value := range values {
+		var message models.DirectMessage
+		if err := json.Unmarshal([]byte(value), &message); err != nil {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func (r *RedisGameService) SetPresence(ctx context.Context, uuid string, presence models.Presence, ttl time.Duration) error {
+	data, err := json.Marshal(presence)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("presence:%s", uuid)
+	return r.redisClient.Set(ctx, key, data, ttl).Err()
+}
+
+func (r *RedisGameService) DeletePresence(ctx context.Context, uuid string) error {
+	key := fmt.Sprintf("presence:%s", uuid)
+	return r.redisClient.Del(ctx, key).Err()
+}
+
+// 一次查詢多位玩家的在線狀態，沒有紀錄的玩家視為離線
+func (r *RedisGameService) GetPresences(ctx context.Context, uuids []string) (map[string]models.Presence, error) {
+	presences := make(map[string]models.Presence, len(uuids))
+	if len(uuids) == 0 {
+		return presences, nil
+	}
+	keys := make([]string, len(uuids))
+	for i, uuid := range uuids {
+		keys[i] = fmt.Sprintf("presence:%s", uuid)
+	}
+	values, err := r.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		presence := models.Presence{Status: models.PresenceOffline}
+		if text, ok := value.(string); ok {
+			json.Unmarshal([]byte(text), &presence)
+		}
+		presences[uuids[i]] = presence
+	}
+	return presences, nil
+}
+
+func (r *RedisGameService) SaveInvite(ctx context.Context, invite *models.GameInvite, ttl time.Duration) error {
+	data, err := json.Marshal(invite)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("invite:%s", invite.Token)
+	return r.redisClient.Set(ctx, key, data, ttl).Err()
+}
+
+func (r *RedisGameService) GetInvite(ctx context.Context, token string) (*models.GameInvite, error) {
+	key := fmt.Sprintf("invite:%s", token)
+	val, err := r.redisClient.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	var invite models.GameInvite
+	if err := json.Unmarshal([]byte(val), &invite); err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// 刪除邀請，回傳是否真的刪除（同一個邀請只能使用一次）
+func (r *RedisGameService) DeleteInvite(ctx context.Context, token string) (bool, error) {
+	key := fmt.Sprintf("invite:%s", token)
+	deleted, err := r.redisClient.Del(ctx, key).Result()
+	return deleted > 0, err
+}
+
+// 撤銷 access token 直到它原本的過期時間
+func (r *RedisGameService) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	key := fmt.Sprintf("denylist:%s", jti)
+	return r.redisClient.Set(ctx, key, 1, ttl).Err()
+}
+
+// 撤銷 session 已簽發的所有 access token，ttl 為 access token 的最長有效時間
+func (r *RedisGameService) RevokeSessionTokens(ctx context.Context, sessionID string, ttl time.Duration) error {
+	key := fmt.Sprintf("denylist:session:%s", sessionID)
+	return r.redisClient.Set(ctx, key, 1, ttl).Err()
+}
+
+// access token 本身（jti）或所屬的 session（sid）被撤銷時回傳 true，空值不檢查
+func (r *RedisGameService) IsAccessTokenRevoked(ctx context.Context, jti string, sessionID string) (bool, error) {
+	var keys []string
+	if jti != "" {
+		keys = append(keys, fmt.Sprintf("denylist:%s", jti))
+	}
+	if sessionID != "" {
+		keys = append(keys, fmt.Sprintf("denylist:session:%s", sessionID))
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	count, err := r.redisClient.Exists(ctx, keys...).Result()
+	return count > 0, err
+}
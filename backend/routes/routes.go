@@ -1,27 +1,54 @@
 package routes
 
 import (
+	"game/config"
 	"game/controllers"
+	"game/game"
+	"game/mailer"
 	"game/middleware"
+	"game/moderation"
 	"game/repository"
 	"game/services"
+	"game/storage"
+	"game/utils"
+	"game/ws"
+	"log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(db *gorm.DB, rds *redis.Client) *gin.Engine {
+func SetupRoutes(db *gorm.DB, rds *redis.Client, cfg *config.Config) *gin.Engine {
 	route := gin.Default()
 
+	// Game JWT 金鑰：未設定或設定錯誤時不啟動，本機開發需明確設定 JWT_ALLOW_DEV_SECRET
+	keySet, err := middleware.NewKeySet(cfg.JWT)
+	if err != nil {
+		log.Fatalf("JWT 金鑰設定錯誤: %v", err)
+	}
+	middleware.SetKeySet(keySet)
+
 	// 初始化 RedisGameService
 	redisGameService := repository.NewRedisGameService(rds)
 	// 初始化 MySQLGameService
 	mysqlGameService := repository.NewMySQLGameRepository(db)
-	// 使用 RedisGameService 初始化 RedisGameManager
-	redisGameManager := services.NewRedisGameManager(redisGameService)
+	// 一般房間使用隨機出題
+	answerGenerator := game.NewRandomAnswerGenerator()
+	// 使用 RedisGameService 初始化 RedisGameManager，加入房間時查詢頭像
+	redisGameManager := services.NewRedisGameManager(redisGameService, answerGenerator, services.NewGameManagerMysql(mysqlGameService))
+	// WebSocket 事件限流，規則格式錯誤時使用預設規則
+	rateLimiter, err := ws.NewRateLimiter(cfg.RateLimit)
+	if err != nil {
+		log.Printf("WebSocket 限流設定錯誤，使用預設規則: %v", err)
+		rateLimiter = ws.DefaultRateLimiter()
+	}
+	// 聊天審核：禁用詞與長度限制
+	moderator := moderation.NewModerator(cfg.Moderation)
+	// 房間聊天紀錄，CHAT_ARCHIVE 啟用時排名局結束會封存到 MySQL
+	chatHistory := services.NewChatHistoryManager(redisGameService, mysqlGameService, cfg.Chat.HistorySize, cfg.Chat.Archive)
 	// 初始化新的 WebSocket 服務
-	websocketService := services.NewWebSocketService(redisGameService, mysqlGameService)
+	websocketService := services.NewWebSocketService(redisGameService, mysqlGameService, answerGenerator, rateLimiter, moderator, chatHistory)
 	// 啟動
 	websocketService.StartChatHub()
 
@@ -31,16 +58,68 @@ func SetupRoutes(db *gorm.DB, rds *redis.Client) *gin.Engine {
 
 	// debugController := controllers.NewDebugController(wsService)
 
-	// 初始化 authController
-	authController := controllers.NewAuthController(services.NewGameManagerMysql(mysqlGameService))
+	// 初始化 authController，refresh token 存 MySQL、登出的 access token 記在 Redis
+	tokenManager := services.NewTokenManager(mysqlGameService, redisGameService)
+	// 撤銷登入裝置時中斷它的 WebSocket 連線
+	tokenManager.SetSessionCloser(websocketService.GetChatHub())
+	sessionController := controllers.NewSessionController(tokenManager)
+	// 驗證信與重設密碼信：預設只寫入日誌，MAIL_DRIVER=smtp 時寄出（開發環境使用 MailHog）
+	mail, err := mailer.NewMailer(cfg.Mail)
+	if err != nil {
+		log.Printf("寄信設定錯誤，改為只寫入日誌: %v", err)
+		mail = mailer.NewLogMailer()
+	}
+	accountManager := services.NewAccountManager(mysqlGameService, tokenManager, mail, cfg.Mail.AppURL)
+	authController := controllers.NewAuthController(services.NewGameManagerMysql(mysqlGameService), tokenManager, accountManager)
+
+	// 每日挑戰：由 DAILY_SECRET 推導每天的答案
+	dailySecret := cfg.Daily.Secret
+	if dailySecret == "" {
+		log.Println("未設定 DAILY_SECRET，使用臨時密鑰，重啟後每日答案會改變")
+		dailySecret = utils.GenerateUUID()
+	}
+	dailyManager := services.NewDailyChallengeManager(redisGameService, mysqlGameService, game.NewDailyAnswerGenerator(dailySecret))
+	dailyController := controllers.NewDailyController(dailyManager)
+	protocolController := controllers.NewProtocolController()
+	jwksController := controllers.NewJWKSController()
+	friendController := controllers.NewFriendController(websocketService.GetFriendManager(), redisGameManager)
+	messageController := controllers.NewMessageController(services.NewDirectMessageManager(redisGameService, mysqlGameService))
+	notificationController := controllers.NewNotificationController(websocketService.GetNotificationManager())
+	achievementManager := websocketService.GetAchievementManager()
+	// 頭像儲存：預設存在本機目錄，STORAGE_DRIVER=s3 時存到 S3 相容的物件儲存（開發環境使用 MinIO）
+	blobStore, err := storage.NewBlobStore(cfg.Storage)
+	if err != nil {
+		log.Printf("檔案儲存設定錯誤，改用本機目錄: %v", err)
+		blobStore = storage.NewLocalStore(cfg.Storage.LocalDir, "")
+	}
+	avatarManager := services.NewAvatarManager(mysqlGameService, blobStore)
+	userController := controllers.NewUserController(services.NewUserManager(mysqlGameService, achievementManager), achievementManager, avatarManager)
+	adminController := controllers.NewAdminController(services.NewGameManagerMysql(mysqlGameService), chatHistory)
+	if cfg.Moderation.AdminEmails == "" {
+		log.Println("未設定 ADMIN_EMAILS，無法使用檢舉審查功能")
+	}
 
 	// CORS 中間件
 	route.Use(middleware.CORS())
 
+	// 本機儲存的上傳檔案
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		route.Static(storage.DefaultLocalPublicURL, localStore.Dir())
+	}
+
+	// 驗證 Game JWT 用的公鑰（JWKS），依慣例放在網站根目錄；/api/v1 下保留相同的路徑
+	route.GET("/.well-known/jwks.json", jwksController.JWKSController)
+
 	api := route.Group("/api")
 	{
 		v1 := api.Group("/v1")
 		{
+			// WebSocket 協定文件，第三方客戶端可用來驗證訊息
+			v1.GET("/protocol", protocolController.IndexController)
+			v1.GET("/protocol/schema/:direction/:type", protocolController.SchemaController)
+
+			// 驗證 Game JWT 用的公鑰（JWKS），其他服務依 token header 的 kid 選擇公鑰
+			v1.GET("/.well-known/jwks.json", jwksController.JWKSController)
 
 			// 認證相關路由
 			auth := v1.Group("/auth")
@@ -48,14 +127,55 @@ func SetupRoutes(db *gorm.DB, rds *redis.Client) *gin.Engine {
 				auth.POST("/login", authController.LoginController)
 				auth.POST("/register", authController.RegisterController)
 				auth.POST("/captcha", authController.GetCaptchaController)
+				auth.POST("/refresh", authController.RefreshController)
+				auth.POST("/password/forgot", authController.ForgotPasswordController)
+				auth.POST("/password/reset", authController.ResetPasswordController)
+				auth.POST("/email/verify", authController.VerifyEmailController)
 			}
-			auth.Use(middleware.JWTAuthGame()) // 使用 JWT 認證中間件
+			auth.Use(middleware.JWTAuthGame(tokenManager)) // 使用 JWT 認證中間件，拒絕已登出的 token
 			{
+				auth.POST("/logout", authController.LogoutController)
+				auth.POST("/email/verify/resend", authController.ResendVerificationController)
 				auth.POST("/allGames", gameHandler.AllGamesController)
 				auth.GET("/leaderboard", gameHandler.LeaderboardController)
+				auth.GET("/verifyRound", gameHandler.VerifyRoundController)
 				auth.POST("/createGame", gameHandler.CreateGameController)
 				auth.POST("/joinGame", gameHandler.JoinGameController)
 				auth.GET("/wsGame", wsController.HandleWebSocket2)
+				auth.GET("/wsLobby", wsController.HandleLobbyWebSocket)
+				auth.POST("/dailyStart", dailyController.StartController)
+				auth.POST("/dailyGuess", dailyController.GuessController)
+				auth.GET("/dailyLeaderboard", dailyController.LeaderboardController)
+				auth.GET("/dailyStreak", dailyController.StreakController)
+				auth.GET("/inbox", messageController.InboxController)
+				auth.DELETE("/inbox", messageController.AckInboxController)
+				auth.GET("/friends", friendController.FriendsController)
+				auth.GET("/friends/requests", friendController.RequestsController)
+				auth.POST("/friends/requests", friendController.SendRequestController)
+				auth.POST("/friends/requests/:id/accept", friendController.AcceptController)
+				auth.POST("/friends/requests/:id/decline", friendController.DeclineController)
+				auth.DELETE("/friends/:userId", friendController.RemoveController)
+				auth.POST("/joinByInvite", friendController.JoinByInviteController)
+				auth.GET("/notifications", notificationController.ListController)
+				auth.GET("/notifications/unread", notificationController.UnreadCountController)
+				auth.POST("/notifications/read", notificationController.MarkReadController)
+				auth.GET("/users/:id", userController.ProfileController)
+				auth.GET("/users/:id/achievements", userController.AchievementsController)
+				auth.GET("/me", userController.MeController)
+				auth.PATCH("/me", userController.UpdateMeController)
+				auth.POST("/me/avatar", userController.UploadAvatarController)
+				auth.GET("/me/sessions", sessionController.ListController)
+				auth.DELETE("/me/sessions", sessionController.RevokeOthersController)
+				auth.DELETE("/me/sessions/:id", sessionController.RevokeController)
+			}
+
+			// 管理員：審查聊天檢舉
+			admin := auth.Group("/admin")
+			admin.Use(middleware.AdminOnly(cfg.Moderation.AdminEmails, mysqlGameService))
+			{
+				admin.GET("/reports", adminController.ReportsController)
+				admin.PATCH("/reports/:id", adminController.ReviewReportController)
+				admin.GET("/chatArchive", adminController.ChatArchiveController)
 			}
 
 		}
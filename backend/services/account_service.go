@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"game/mailer"
+	"game/models"
+	"game/repository"
+	"game/utils"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = time.Hour
+	mailCooldown     = time.Minute // 同一用途的信件最短間隔
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("連結無效或已過期，請重新申請")
+	ErrEmailAlreadyVerified = errors.New("Email 已完成驗證")
+	ErrMailCooldown         = errors.New("信件已寄出，請稍後再試")
+)
+
+// AccountManager email 驗證與忘記密碼：寄出含一次性 token 的連結，token 只存雜湊值，
+// 重新申請時舊 token 作廢；重設密碼後登出所有裝置
+type AccountManager struct {
+	mysqlRepo *repository.MySQLGameService
+	tokens    *TokenManager
+	mailer    mailer.Mailer
+	appURL    string
+}
+
+func NewAccountManager(mysqlRepo *repository.MySQLGameService, tokens *TokenManager, mail mailer.Mailer, appURL string) *AccountManager {
+	return &AccountManager{
+		mysqlRepo: mysqlRepo,
+		tokens:    tokens,
+		mailer:    mail,
+		appURL:    strings.TrimRight(appURL, "/"),
+	}
+}
+
+// SendVerification 寄出 email 驗證信，註冊後與使用者要求重寄時呼叫
+func (a *AccountManager) SendVerification(userID string) error {
+	user, err := a.mysqlRepo.GetUserProfile(userID)
+	if err != nil {
+		return fmt.Errorf("找不到該使用者")
+	}
+	if user.Email == nil || *user.Email == "" {
+		return fmt.Errorf("尚未設定 Email")
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := a.createToken(user.ID, models.TokenPurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	a.send(*user.Email, "請驗證您的 Email", fmt.Sprintf(
+		"%s 您好：\n\n請在 24 小時內開啟以下連結完成 Email 驗證：\n%s/verify-email?token=%s\n\n如果您沒有註冊帳號，請忽略這封信。\n",
+		user.Username, a.appURL, token))
+	return nil
+}
+
+// SendVerificationByEmail 註冊成功後依 email 寄出驗證信
+func (a *AccountManager) SendVerificationByEmail(email string) error {
+	user, err := a.mysqlRepo.GetUser(email)
+	if err != nil {
+		return err
+	}
+	return a.SendVerification(user.ID)
+}
+
+// VerifyEmail 以信中的 token 完成 email 驗證
+func (a *AccountManager) VerifyEmail(token string) error {
+	stored, err := a.findToken(token, models.TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	consumed, err := a.mysqlRepo.ConsumeUserToken(stored.ID, stored.UserID, map[string]interface{}{"email_verified_at": time.Now()})
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidAccountToken
+	}
+	return nil
+}
+
+// RequestPasswordReset 寄出重設密碼信；email 未註冊時同樣回傳成功，避免被用來查詢帳號是否存在
+func (a *AccountManager) RequestPasswordReset(email string) error {
+	user, err := a.mysqlRepo.GetUser(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	token, err := a.createToken(user.ID, models.TokenPurposeResetPassword, resetPasswordTTL)
+	if errors.Is(err, ErrMailCooldown) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	a.send(email, "重設您的密碼", fmt.Sprintf(
+		"%s 您好：\n\n請在 1 小時內開啟以下連結重設密碼：\n%s/reset-password?token=%s\n\n如果您沒有申請重設密碼，請忽略這封信，您的密碼不會改變。\n",
+		user.Username, a.appURL, token))
+	return nil
+}
+
+// ResetPassword 以信中的 token 設定新密碼，並登出所有裝置
+func (a *AccountManager) ResetPassword(token string, password string) error {
+	stored, err := a.findToken(token, models.TokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+	// 能收到重設信代表擁有此信箱，一併視為已驗證
+	consumed, err := a.mysqlRepo.ConsumeUserToken(stored.ID, stored.UserID, map[string]interface{}{
+		"password_hash":     passwordHash,
+		"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidAccountToken
+	}
+
+	if err := a.tokens.RevokeAllSessions(stored.UserID); err != nil {
+		log.Printf("重設密碼後登出使用者 %s 的裝置失敗: %v", stored.UserID, err)
+	}
+	return nil
+}
+
+// 建立新的一次性 token 並回傳明文，距離上一封同用途的信太近時回傳 ErrMailCooldown
+func (a *AccountManager) createToken(userID string, purpose string, ttl time.Duration) (string, error) {
+	recent, err := a.mysqlRepo.CountUserTokensSince(userID, purpose, time.Now().Add(-mailCooldown))
+	if err != nil {
+		return "", err
+	}
+	if recent > 0 {
+		return "", ErrMailCooldown
+	}
+
+	token := utils.GenerateToken(32)
+	err = a.mysqlRepo.ReplaceUserToken(models.UserTokens{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// 查詢尚未使用且未過期的 token
+func (a *AccountManager) findToken(token string, purpose string) (*models.UserTokens, error) {
+	stored, err := a.mysqlRepo.GetUserTokenByHash(utils.HashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAccountToken
+	} else if err != nil {
+		return nil, err
+	}
+	if stored.Purpose != purpose || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidAccountToken
+	}
+	return &stored, nil
+}
+
+// 在背景寄信，回應時間不受 SMTP 影響，也不會因 email 是否存在而不同
+func (a *AccountManager) send(to string, subject string, body string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := a.mailer.Send(ctx, mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+			log.Printf("寄信給 %s 失敗: %v", to, err)
+		}
+	}()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"game/game"
+	"game/models"
+	"game/repository"
+	"game/utils"
+	"log"
+)
+
+// 計算目前連勝時最多往回查的輪數
+const streakWindow = 100
+
+// AchievementManager 每輪結果寫入 MySQL 後評估成就，新達成的成就存入 user_achievements 並發送通知
+type AchievementManager struct {
+	mysqlRepo     *repository.MySQLGameService
+	notifications *NotificationManager
+}
+
+func NewAchievementManager(mysqlRepo *repository.MySQLGameService, notifications *NotificationManager) *AchievementManager {
+	return &AchievementManager{mysqlRepo: mysqlRepo, notifications: notifications}
+}
+
+// 依剛寫入的參與紀錄評估成就，回傳新達成的成就
+func (a *AchievementManager) UnlockAchievements(player *models.GamePlayers, totalPlayers int) ([]models.AchievementInfo, error) {
+	stats, err := a.mysqlRepo.GetPlayerStatsByID(player.UserID)
+	if err != nil {
+		return nil, err
+	}
+	streak, err := a.currentStreak(player.UserID)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := a.mysqlRepo.GetUserAchievements(player.UserID)
+	if err != nil {
+		return nil, err
+	}
+	unlocked := make(map[string]bool, len(owned))
+	for _, achievement := range owned {
+		unlocked[achievement.Code] = true
+	}
+
+	guessCount := 0
+	if player.GuessCount != nil {
+		guessCount = *player.GuessCount
+	}
+	earned := game.EvaluateAchievements(game.AchievementStats{
+		GamesPlayed:  stats.GamesPlayed,
+		Wins:         stats.Wins,
+		WinStreak:    streak,
+		Won:          player.Won,
+		GuessCount:   guessCount,
+		TotalPlayers: totalPlayers,
+	}, unlocked)
+
+	var infos []models.AchievementInfo
+	for _, achievement := range earned {
+		record := models.UserAchievements{
+			ID:     utils.GenerateUUID(),
+			UserID: player.UserID,
+			Code:   achievement.Code,
+			GameID: player.GameID,
+			Round:  player.GameResultsRound,
+		}
+		// 同一位玩家同時在多個房間結束時可能重複寫入，由唯一索引擋下
+		if err := a.mysqlRepo.AddUserAchievement(record); err != nil {
+			log.Printf("儲存玩家 %s 的成就 %s 失敗: %v", player.UserID, achievement.Code, err)
+			continue
+		}
+		if a.notifications != nil {
+			a.notifications.Notify(player.UserID, models.NotifyAchievement, "達成成就："+achievement.Name, achievement.Description,
+				map[string]interface{}{"code": achievement.Code, "gameId": player.GameID, "round": player.GameResultsRound})
+		}
+		infos = append(infos, models.AchievementInfo{
+			Code:        achievement.Code,
+			Name:        achievement.Name,
+			Description: achievement.Description,
+			Unlocked:    true,
+		})
+	}
+	return infos, nil
+}
+
+// 目前連勝場數
+func (a *AchievementManager) currentStreak(userID string) (int, error) {
+	outcomes, err := a.mysqlRepo.GetRecentOutcomes(userID, streakWindow)
+	if err != nil {
+		return 0, err
+	}
+	return game.CurrentStreak(outcomes), nil
+}
+
+// 玩家的所有成就，包含尚未達成的
+func (a *AchievementManager) Achievements(userID string) ([]models.AchievementInfo, error) {
+	if _, err := a.mysqlRepo.GetUserByID(userID); err != nil {
+		return nil, errors.New("找不到該使用者")
+	}
+	owned, err := a.mysqlRepo.GetUserAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+	unlockedAt := make(map[string]models.UserAchievements, len(owned))
+	for _, achievement := range owned {
+		unlockedAt[achievement.Code] = achievement
+	}
+
+	infos := make([]models.AchievementInfo, 0, len(game.Achievements))
+	for _, achievement := range game.Achievements {
+		info := models.AchievementInfo{
+			Code:        achievement.Code,
+			Name:        achievement.Name,
+			Description: achievement.Description,
+		}
+		if record, ok := unlockedAt[achievement.Code]; ok {
+			info.Unlocked = true
+			info.UnlockedAt = &record.UnlockedAt
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
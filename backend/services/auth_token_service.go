@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"game/middleware"
+	"game/models"
+	"game/repository"
+	"game/utils"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// refresh token 的有效時間
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// refresh token 無效、過期或已被使用時回傳，呼叫端應要求重新登入
+var ErrInvalidRefreshToken = errors.New("refresh token 無效或已過期，請重新登入")
+
+// 找不到或不屬於此使用者的 session
+var ErrSessionNotFound = errors.New("找不到此登入裝置")
+
+// SessionCloser 中斷 session 的 WebSocket 連線，由 ws.ChatHub 實作
+type SessionCloser interface {
+	CloseSession(uuid string, sessionID string) int
+}
+
+// TokenManager 用到的 MySQL 資料存取，由 repository.MySQLGameService 實作
+type tokenStore interface {
+	AddSession(session models.Sessions) error
+	GetSession(id string) (models.Sessions, error)
+	TouchSession(id string, userAgent string, ip string) error
+	GetActiveSessions(userID string, since time.Time) ([]models.Sessions, error)
+	RevokeSession(id string) error
+	GetUserProfile(id string) (models.Users, error)
+	AddRefreshToken(token models.RefreshTokens) error
+	GetRefreshTokenByHash(tokenHash string) (models.RefreshTokens, error)
+	RotateRefreshToken(id string, next models.RefreshTokens) (bool, error)
+	RevokeUserRefreshTokens(userID string) error
+}
+
+// TokenManager 用到的 Redis denylist，由 repository.RedisGameService 實作
+type tokenDenylist interface {
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	RevokeSessionTokens(ctx context.Context, sessionID string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, jti string, sessionID string) (bool, error)
+}
+
+// TokenManager 簽發 access token 與 refresh token；refresh token 只存雜湊值，每次換發即輪替，
+// 舊 token 被重複使用時撤銷整個 family，登出的 access token 以 jti 記在 Redis 直到過期。
+// 每次登入是一個 session（即 refresh token 的 family），撤銷 session 時其 access token 以 sid 記在 Redis
+type TokenManager struct {
+	mysqlRepo tokenStore
+	redisRepo tokenDenylist
+	closer    SessionCloser
+}
+
+func NewTokenManager(mysqlRepo *repository.MySQLGameService, redisRepo *repository.RedisGameService) *TokenManager {
+	return &TokenManager{mysqlRepo: mysqlRepo, redisRepo: redisRepo}
+}
+
+// SetSessionCloser 設定撤銷 session 時中斷連線的對象，ChatHub 建立後呼叫
+func (t *TokenManager) SetSessionCloser(closer SessionCloser) {
+	t.closer = closer
+}
+
+// 登入成功後建立新的 session 並簽發 token
+func (t *TokenManager) Issue(userID string, email string, username string, client models.ClientInfo) (*models.TokenPair, error) {
+	session := models.Sessions{
+		ID:         utils.GenerateUUID(),
+		UserID:     userID,
+		UserAgent:  truncate(client.UserAgent, 255),
+		IP:         client.IP,
+		LastSeenAt: time.Now(),
+	}
+	if err := t.mysqlRepo.AddSession(session); err != nil {
+		return nil, err
+	}
+	tokens, record, err := newTokenPair(userID, email, username, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.mysqlRepo.AddRefreshToken(record); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// 簽發 access token 並產生新的 refresh token，回傳要存入資料庫的紀錄；familyID 即 session ID
+func newTokenPair(userID string, email string, username string, familyID string) (*models.TokenPair, models.RefreshTokens, error) {
+	accessToken, err := middleware.GenerateJWTGame(email, username, userID, familyID)
+	if err != nil {
+		return nil, models.RefreshTokens{}, err
+	}
+	refreshToken := utils.GenerateToken(32)
+	record := models.RefreshTokens{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(middleware.AccessTokenTTL.Seconds()),
+	}, record, nil
+}
+
+// 以 refresh token 換發新的 token，舊的 refresh token 隨即失效，並更新 session 的最後使用時間
+func (t *TokenManager) Refresh(refreshToken string, client models.ClientInfo) (*models.TokenPair, error) {
+	stored, err := t.mysqlRepo.GetRefreshTokenByHash(utils.HashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		// 已換發或已登出的 token 又被使用，可能已外洩：撤銷同一次登入的所有 token
+		log.Printf("偵測到 refresh token 重複使用，撤銷使用者 %s 的 family %s", stored.UserID, stored.FamilyID)
+		if err := t.revokeSession(stored.UserID, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := t.mysqlRepo.GetUserProfile(stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := t.mysqlRepo.GetSession(stored.FamilyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 加入 session 前簽發的 refresh token，補建 session
+		session = models.Sessions{ID: stored.FamilyID, UserID: stored.UserID, LastSeenAt: time.Now()}
+		if err := t.mysqlRepo.AddSession(session); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+	tokens, record, err := newTokenPair(user.ID, email, user.Username, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	// 撤銷舊 token 與寫入新 token 在同一個交易中，寫入失敗時舊 token 仍可再次換發
+	rotated, err := t.mysqlRepo.RotateRefreshToken(stored.ID, record)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// 同一個 token 同時被換發兩次，視同重複使用
+		if err := t.revokeSession(stored.UserID, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err := t.mysqlRepo.TouchSession(session.ID, truncate(client.UserAgent, 255), client.IP); err != nil {
+		log.Printf("更新 session %s 失敗: %v", session.ID, err)
+	}
+	return tokens, nil
+}
+
+// 登出：撤銷目前的 access token 與 session（sessionID 可為空），
+// 並撤銷 refresh token 所屬的整個 family（refreshToken 可為空）
+func (t *TokenManager) Logout(userID string, jti string, sessionID string, accessExpiresAt time.Time, refreshToken string) error {
+	if sessionID != "" {
+		if err := t.revokeSession(userID, sessionID); err != nil {
+			return err
+		}
+	}
+	if refreshToken != "" {
+		stored, err := t.mysqlRepo.GetRefreshTokenByHash(utils.HashToken(refreshToken))
+		if err == nil && stored.UserID == userID && stored.FamilyID != sessionID {
+			if err := t.revokeSession(userID, stored.FamilyID); err != nil {
+				return err
+			}
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	// 舊版 token 沒有 jti，只能等它自然過期
+	if jti == "" {
+		return nil
+	}
+	return t.RevokeAccessToken(jti, accessExpiresAt)
+}
+
+// 將 access token 加入 denylist，直到它原本的過期時間
+func (t *TokenManager) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return fmt.Errorf("token 缺少 jti，無法撤銷")
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return t.redisRepo.RevokeAccessToken(ctx, jti, ttl)
+}
+
+// IsRevoked 供 JWTAuthGame 檢查 access token 或其 session 是否已登出
+func (t *TokenManager) IsRevoked(jti string, sessionID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return t.redisRepo.IsAccessTokenRevoked(ctx, jti, sessionID)
+}
+
+// Sessions 使用者目前有效的登入裝置，currentID 為目前請求的 session
+func (t *TokenManager) Sessions(userID string, currentID string) ([]models.SessionInfo, error) {
+	sessions, err := t.mysqlRepo.GetActiveSessions(userID, time.Now().Add(-refreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, models.SessionInfo{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		})
+	}
+	return infos, nil
+}
+
+// RevokeSession 登出指定的裝置
+func (t *TokenManager) RevokeSession(userID string, sessionID string) error {
+	session, err := t.mysqlRepo.GetSession(sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && session.UserID != userID) {
+		return ErrSessionNotFound
+	} else if err != nil {
+		return err
+	}
+	return t.revokeSession(userID, sessionID)
+}
+
+// RevokeOtherSessions 登出目前 session 以外的所有裝置，回傳登出的數量
+func (t *TokenManager) RevokeOtherSessions(userID string, currentID string) (int, error) {
+	sessions, err := t.mysqlRepo.GetActiveSessions(userID, time.Now().Add(-refreshTokenTTL))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, session := range sessions {
+		if session.ID == currentID {
+			continue
+		}
+		if err := t.revokeSession(userID, session.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// RevokeAllSessions 登出所有裝置，包含加入 session 前簽發的 refresh token
+func (t *TokenManager) RevokeAllSessions(userID string) error {
+	if _, err := t.RevokeOtherSessions(userID, ""); err != nil {
+		return err
+	}
+	return t.mysqlRepo.RevokeUserRefreshTokens(userID)
+}
+
+// 撤銷 session 的 refresh token 與已簽發的 access token，並中斷它的 WebSocket 連線
+func (t *TokenManager) revokeSession(userID string, sessionID string) error {
+	if err := t.mysqlRepo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.redisRepo.RevokeSessionTokens(ctx, sessionID, middleware.AccessTokenTTL); err != nil {
+		return err
+	}
+	if t.closer != nil {
+		t.closer.CloseSession(userID, sessionID)
+	}
+	return nil
+}
+
+// 依字元截斷，避免超過欄位長度
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
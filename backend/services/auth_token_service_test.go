@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"game/config"
+	"game/middleware"
+	"game/models"
+
+	"gorm.io/gorm"
+)
+
+// 以記憶體模擬 MySQL，RotateRefreshToken 與 RevokeSession 和實際的交易一樣是原子操作
+type fakeTokenStore struct {
+	mu       sync.Mutex
+	users    map[string]models.Users
+	sessions map[string]models.Sessions
+	tokens   map[string]models.RefreshTokens
+
+	readBarrier *sync.WaitGroup // 設定時，讀取 refresh token 的請求會互相等待，模擬同時換發
+	rotateErr   error           // 設定時，換發的交易失敗
+}
+
+func newFakeTokenStore() *fakeTokenStore {
+	return &fakeTokenStore{
+		users:    map[string]models.Users{"u1": {ID: "u1", Username: "alice"}},
+		sessions: make(map[string]models.Sessions),
+		tokens:   make(map[string]models.RefreshTokens),
+	}
+}
+
+func (s *fakeTokenStore) AddSession(session models.Sessions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *fakeTokenStore) GetSession(id string) (models.Sessions, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return session, gorm.ErrRecordNotFound
+	}
+	return session, nil
+}
+
+func (s *fakeTokenStore) TouchSession(id string, userAgent string, ip string) error {
+	return nil
+}
+
+func (s *fakeTokenStore) GetActiveSessions(userID string, since time.Time) ([]models.Sessions, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sessions []models.Sessions
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *fakeTokenStore) RevokeSession(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if session, ok := s.sessions[id]; ok && session.RevokedAt == nil {
+		session.RevokedAt = &now
+		s.sessions[id] = session
+	}
+	for tokenID, token := range s.tokens {
+		if token.FamilyID == id && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			s.tokens[tokenID] = token
+		}
+	}
+	return nil
+}
+
+func (s *fakeTokenStore) GetUserProfile(id string) (models.Users, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return user, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (s *fakeTokenStore) AddRefreshToken(token models.RefreshTokens) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.ID] = token
+	return nil
+}
+
+func (s *fakeTokenStore) GetRefreshTokenByHash(tokenHash string) (models.RefreshTokens, error) {
+	s.mu.Lock()
+	var found *models.RefreshTokens
+	for _, token := range s.tokens {
+		if token.TokenHash == tokenHash {
+			found = &token
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if s.readBarrier != nil {
+		s.readBarrier.Done()
+		s.readBarrier.Wait()
+	}
+	if found == nil {
+		return models.RefreshTokens{}, gorm.ErrRecordNotFound
+	}
+	return *found, nil
+}
+
+func (s *fakeTokenStore) RotateRefreshToken(id string, next models.RefreshTokens) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rotateErr != nil {
+		return false, s.rotateErr
+	}
+	token, ok := s.tokens[id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	token.ReplacedBy = &next.ID
+	s.tokens[id] = token
+	s.tokens[next.ID] = next
+	return true, nil
+}
+
+func (s *fakeTokenStore) RevokeUserRefreshTokens(userID string) error {
+	return nil
+}
+
+func (s *fakeTokenStore) session(id string) models.Sessions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[id]
+}
+
+type fakeDenylist struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+}
+
+func (d *fakeDenylist) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return nil
+}
+
+func (d *fakeDenylist) RevokeSessionTokens(ctx context.Context, sessionID string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sessions[sessionID] = true
+	return nil
+}
+
+func (d *fakeDenylist) IsAccessTokenRevoked(ctx context.Context, jti string, sessionID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sessions[sessionID], nil
+}
+
+func newTestTokenManager(t *testing.T) (*TokenManager, *fakeTokenStore, *fakeDenylist) {
+	t.Helper()
+	keys, err := middleware.NewKeySet(config.JWT{HMACSecret: "test-secret-test-secret-test-secret"})
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	middleware.SetKeySet(keys)
+
+	store := newFakeTokenStore()
+	denylist := &fakeDenylist{sessions: make(map[string]bool)}
+	return &TokenManager{mysqlRepo: store, redisRepo: denylist}, store, denylist
+}
+
+// 由 refresh token 找出它所屬的 session
+func familyOf(t *testing.T, store *fakeTokenStore) string {
+	t.Helper()
+	for _, token := range store.tokens {
+		return token.FamilyID
+	}
+	t.Fatal("沒有任何 refresh token")
+	return ""
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	tokens, store, _ := newTestTokenManager(t)
+	first, err := tokens.Issue("u1", "", "alice", models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	second, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == "" {
+		t.Fatal("換發後應取得新的 token")
+	}
+	if _, err := tokens.Refresh(second.RefreshToken, models.ClientInfo{}); err != nil {
+		t.Errorf("新的 refresh token 應可再次換發: %v", err)
+	}
+	if len(store.tokens) != 3 {
+		t.Errorf("資料庫中有 %d 個 refresh token，預期 3 個", len(store.tokens))
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	tokens, store, denylist := newTestTokenManager(t)
+	first, _ := tokens.Issue("u1", "", "alice", models.ClientInfo{})
+	sessionID := familyOf(t, store)
+	second, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// 舊 token 再次被使用：視為外洩，整個 session 都要撤銷
+	if _, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("重複使用舊 token 的錯誤 = %v，預期 ErrInvalidRefreshToken", err)
+	}
+	if store.session(sessionID).RevokedAt == nil {
+		t.Error("重複使用後 session 應被撤銷")
+	}
+	if !denylist.sessions[sessionID] {
+		t.Error("重複使用後 session 的 access token 應加入 denylist")
+	}
+	if _, err := tokens.Refresh(second.RefreshToken, models.ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("同一個 session 換發出的新 token 也應失效，錯誤 = %v", err)
+	}
+}
+
+func TestRefreshConcurrentRotation(t *testing.T) {
+	tokens, store, _ := newTestTokenManager(t)
+	first, _ := tokens.Issue("u1", "", "alice", models.ClientInfo{})
+	sessionID := familyOf(t, store)
+
+	// 兩個請求都讀到尚未撤銷的 token 後才開始換發，只有一個能成功
+	store.readBarrier = &sync.WaitGroup{}
+	store.readBarrier.Add(2)
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{})
+			results <- err
+		}()
+	}
+	var succeeded, rejected int
+	for i := 0; i < 2; i++ {
+		switch err := <-results; {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrInvalidRefreshToken):
+			rejected++
+		default:
+			t.Fatalf("非預期的錯誤: %v", err)
+		}
+	}
+	store.readBarrier = nil
+
+	if succeeded != 1 || rejected != 1 {
+		t.Fatalf("同時換發：成功 %d 次、被拒 %d 次，預期各 1 次", succeeded, rejected)
+	}
+	// 同一個 token 同時換發兩次視同重複使用
+	if store.session(sessionID).RevokedAt == nil {
+		t.Error("同時換發後 session 應被撤銷")
+	}
+}
+
+func TestRefreshRotationFailureKeepsOldToken(t *testing.T) {
+	tokens, store, _ := newTestTokenManager(t)
+	first, _ := tokens.Issue("u1", "", "alice", models.ClientInfo{})
+
+	store.rotateErr = errors.New("寫入失敗")
+	if _, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{}); err == nil {
+		t.Fatal("交易失敗時 Refresh 應回傳錯誤")
+	}
+	store.rotateErr = nil
+
+	// 撤銷與寫入在同一個交易中，失敗時舊 token 仍然可以使用，不會被誤判為重複使用
+	if _, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{}); err != nil {
+		t.Errorf("交易失敗後舊 token 應仍可換發: %v", err)
+	}
+}
+
+func TestRefreshRejectsUnknownAndExpiredTokens(t *testing.T) {
+	tokens, store, _ := newTestTokenManager(t)
+	if _, err := tokens.Refresh("unknown", models.ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("不存在的 token 錯誤 = %v", err)
+	}
+
+	first, _ := tokens.Issue("u1", "", "alice", models.ClientInfo{})
+	for id, token := range store.tokens {
+		token.ExpiresAt = time.Now().Add(-time.Minute)
+		store.tokens[id] = token
+	}
+	if _, err := tokens.Refresh(first.RefreshToken, models.ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("過期的 token 錯誤 = %v", err)
+	}
+}
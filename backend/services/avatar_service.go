@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"game/repository"
+	"game/storage"
+	"game/utils"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	_ "golang.org/x/image/webp"
+)
+
+// 頭像的限制：檔案大小、原始圖片的邊長範圍，以及輸出的尺寸（第一個為個人頁面使用的主要尺寸）
+const (
+	MaxAvatarBytes = 2 << 20
+	minAvatarSide  = 64
+	maxAvatarSide  = 4096
+)
+
+var AvatarSizes = []int{256, 64}
+
+// 接受的圖片格式（以內容判斷，不信任副檔名）
+var avatarTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// AvatarManager 頭像上傳：驗證後裁成正方形並縮放為固定尺寸，以 PNG 存入 BlobStore
+type AvatarManager struct {
+	mysqlRepo *repository.MySQLGameService
+	store     storage.BlobStore
+}
+
+func NewAvatarManager(mysqlRepo *repository.MySQLGameService, store storage.BlobStore) *AvatarManager {
+	return &AvatarManager{mysqlRepo: mysqlRepo, store: store}
+}
+
+// 上傳頭像並更新使用者的 avatar_url，回傳新的網址
+func (a *AvatarManager) Upload(userID string, data []byte) (string, error) {
+	if len(data) > MaxAvatarBytes {
+		return "", fmt.Errorf("頭像不可超過 %d MB", MaxAvatarBytes>>20)
+	}
+	if contentType := http.DetectContentType(data); !avatarTypes[contentType] {
+		return "", fmt.Errorf("不支援的圖片格式，請上傳 PNG、JPEG、GIF 或 WebP")
+	}
+	// 先只讀取尺寸，避免解碼過大的圖片
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("無法讀取圖片")
+	}
+	if config.Width < minAvatarSide || config.Height < minAvatarSide {
+		return "", fmt.Errorf("圖片至少需要 %dx%d", minAvatarSide, minAvatarSide)
+	}
+	if config.Width > maxAvatarSide || config.Height > maxAvatarSide {
+		return "", fmt.Errorf("圖片不可超過 %dx%d", maxAvatarSide, maxAvatarSide)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("無法讀取圖片")
+	}
+
+	user, err := a.mysqlRepo.GetUserProfile(userID)
+	if err != nil {
+		return "", fmt.Errorf("找不到該使用者")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// 每次上傳使用新的版本路徑，避免瀏覽器與 CDN 快取到舊頭像
+	prefix := fmt.Sprintf("avatars/%s/%s", userID, utils.GenerateToken(8))
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, utils.ResizeSquare(src, size)); err != nil {
+			return "", err
+		}
+		if err := a.store.Put(ctx, avatarKey(prefix, size), buf.Bytes(), "image/png"); err != nil {
+			log.Printf("儲存玩家 %s 的頭像失敗: %v", userID, err)
+			return "", fmt.Errorf("儲存頭像失敗，請稍後再試")
+		}
+	}
+
+	avatarURL := a.store.URL(avatarKey(prefix, AvatarSizes[0]))
+	if err := a.mysqlRepo.UpdateUser(userID, map[string]interface{}{"avatar_url": avatarURL}); err != nil {
+		return "", err
+	}
+	if user.AvatarURL != nil {
+		a.deleteAvatar(ctx, *user.AvatarURL)
+	}
+	return avatarURL, nil
+}
+
+// 刪除舊頭像的所有尺寸；外部網址（由 PATCH /me 設定）不處理
+func (a *AvatarManager) deleteAvatar(ctx context.Context, avatarURL string) {
+	key, ok := storage.KeyOf(a.store, avatarURL)
+	suffix := fmt.Sprintf("/%d.png", AvatarSizes[0])
+	if !ok || !strings.HasSuffix(key, suffix) {
+		return
+	}
+	prefix := strings.TrimSuffix(key, suffix)
+	for _, size := range AvatarSizes {
+		if err := a.store.Delete(ctx, avatarKey(prefix, size)); err != nil {
+			log.Printf("刪除舊頭像 %s 失敗: %v", avatarKey(prefix, size), err)
+		}
+	}
+}
+
+func avatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.png", prefix, size)
+}
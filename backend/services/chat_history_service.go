@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"game/models"
+	"game/repository"
+	"game/utils"
+	"time"
+)
+
+// 未設定時每個房間保留的聊天則數
+const DefaultChatHistorySize = 50
+
+// 聊天紀錄的過期時間，與房間相同
+const chatHistoryTTL = 1 * time.Hour
+
+// ChatHistoryManager 房間聊天紀錄：最近的訊息存在 Redis，排名局結束時可選擇封存到 MySQL
+type ChatHistoryManager struct {
+	redisRepo *repository.RedisGameService
+	mysqlRepo *repository.MySQLGameService
+	size      int
+	archive   bool
+}
+
+func NewChatHistoryManager(redisRepo *repository.RedisGameService, mysqlRepo *repository.MySQLGameService, size int, archive bool) *ChatHistoryManager {
+	if size <= 0 {
+		size = DefaultChatHistorySize
+	}
+	return &ChatHistoryManager{redisRepo: redisRepo, mysqlRepo: mysqlRepo, size: size, archive: archive}
+}
+
+// 記錄一則已廣播的聊天訊息
+func (m *ChatHistoryManager) AppendChat(gameID string, line models.ChatLine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return m.redisRepo.AppendChat(ctx, gameID, line, m.size, chatHistoryTTL)
+}
+
+// 房間最近的聊天紀錄，由舊到新
+func (m *ChatHistoryManager) ChatHistory(gameID string) ([]models.ChatLine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return m.redisRepo.GetChatHistory(ctx, gameID)
+}
+
+// 封存本局開始後的聊天紀錄，未啟用 CHAT_ARCHIVE 時不做事
+func (m *ChatHistoryManager) ArchiveChat(gameID string, round int, since time.Time) error {
+	if !m.archive {
+		return nil
+	}
+	lines, err := m.ChatHistory(gameID)
+	if err != nil {
+		return err
+	}
+	messages := make(models.ChatContext, 0, len(lines))
+	for _, line := range lines {
+		if !line.SentAt.Before(since) {
+			messages = append(messages, line)
+		}
+	}
+	return m.mysqlRepo.AddChatArchive(models.ChatArchives{
+		ID:       utils.GenerateUUID(),
+		GameID:   gameID,
+		Round:    round,
+		Messages: messages,
+	})
+}
+
+// 查詢某一輪封存的聊天紀錄
+func (m *ChatHistoryManager) GetChatArchive(gameID string, round int) (*models.ChatArchives, error) {
+	archive, err := m.mysqlRepo.GetChatArchive(gameID, round)
+	if err != nil {
+		return nil, fmt.Errorf("找不到該輪的聊天封存")
+	}
+	return &archive, nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"game/game"
+	"game/models"
+	"game/repository"
+	"game/utils"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	dailyMinRange   = 1
+	dailyMaxRange   = 100
+	dailyAttemptTTL = 48 * time.Hour // 跨過午夜的挑戰隔天仍可完成
+)
+
+// DailyChallengeManager 用到的 Redis 挑戰狀態，由 repository.RedisGameService 實作
+type dailyAttemptStore interface {
+	GetDailyAttempt(ctx context.Context, date string, userID string) (*models.DailyAttempt, error)
+	SaveDailyAttempt(ctx context.Context, attempt *models.DailyAttempt, ttl time.Duration) error
+	UpdateDailyAttempt(ctx context.Context, date string, userID string, ttl time.Duration, fn func(attempt *models.DailyAttempt) (bool, error)) (*models.DailyAttempt, error)
+}
+
+// DailyChallengeManager 用到的 MySQL 結果與連續天數，由 repository.MySQLGameService 實作
+type dailyResultStore interface {
+	AddDailyResult(result models.DailyResults) error
+	GetDailyResult(userID string, date string) (models.DailyResults, error)
+	GetDailyStreak(userID string) (models.DailyStreaks, error)
+	SaveDailyStreak(streak models.DailyStreaks) error
+	GetDailyLeaderboard(date string, limit int) ([]models.DailyLeaderboard, error)
+}
+
+// DailyChallengeManager 每日挑戰：所有玩家同一題、單人遊玩、每天一次
+type DailyChallengeManager struct {
+	redisRepo       dailyAttemptStore
+	mysqlRepo       dailyResultStore
+	answerGenerator *game.DailyAnswerGenerator
+}
+
+func NewDailyChallengeManager(redisRepo *repository.RedisGameService, mysqlRepo *repository.MySQLGameService, answerGenerator *game.DailyAnswerGenerator) *DailyChallengeManager {
+	return &DailyChallengeManager{
+		redisRepo:       redisRepo,
+		mysqlRepo:       mysqlRepo,
+		answerGenerator: answerGenerator,
+	}
+}
+
+// 開始（或繼續）今天的挑戰
+func (d *DailyChallengeManager) Start(userID string) (*models.DailyAttempt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	date := game.DailyDate(time.Now())
+
+	if _, err := d.mysqlRepo.GetDailyResult(userID, date); err == nil {
+		return nil, fmt.Errorf("今天已完成每日挑戰，明天再來吧")
+	}
+
+	attempt, err := d.currentAttempt(ctx, userID)
+	if err == nil {
+		// 每天只有一次機會，已開始的挑戰直接繼續，不重新計時；跨過午夜的挑戰仍以開始那天的題目完成
+		return attempt, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	attempt = &models.DailyAttempt{
+		UserID:    userID,
+		Date:      date,
+		MinRange:  dailyMinRange,
+		MaxRange:  dailyMaxRange,
+		Guesses:   []int{},
+		StartedAt: time.Now(),
+	}
+	return attempt, d.redisRepo.SaveDailyAttempt(ctx, attempt, dailyAttemptTTL)
+}
+
+// 每日挑戰猜數字
+func (d *DailyChallengeManager) Guess(userID string, guess int) (*models.DailyGuessResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	current, err := d.currentAttempt(ctx, userID)
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("請先開始今天的每日挑戰")
+	} else if err != nil {
+		return nil, err
+	}
+	// 以挑戰開始那天的日期出題與記錄
+	date := current.Date
+	answer := d.answerGenerator.AnswerFor(date, current.MinRange, current.MaxRange)
+
+	// 檢查與記錄猜測在同一個交易中完成，同時送出的猜測會依序計數，不會互相覆蓋
+	var result *models.DailyGuessResult
+	attempt, err := d.redisRepo.UpdateDailyAttempt(ctx, date, userID, dailyAttemptTTL, func(attempt *models.DailyAttempt) (bool, error) {
+		if attempt.Solved {
+			return false, fmt.Errorf("今天已完成每日挑戰，明天再來吧")
+		}
+		if guess < attempt.MinRange || guess > attempt.MaxRange {
+			return false, fmt.Errorf("猜測數字必須在 %d 到 %d 之間", attempt.MinRange, attempt.MaxRange)
+		}
+		attempt.Guesses = append(attempt.Guesses, guess)
+		result = &models.DailyGuessResult{
+			Date:       date,
+			Guess:      guess,
+			GuessCount: len(attempt.Guesses),
+		}
+		if guess == answer {
+			// 猜中時立即標記完成，之後才送達的猜測都會被拒絕；寫入 MySQL 失敗時再還原
+			now := time.Now()
+			attempt.Solved = true
+			attempt.FinishedAt = &now
+		}
+		return true, nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("請先開始今天的每日挑戰")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if guess > answer {
+		result.Result = "too_big"
+		result.Message = fmt.Sprintf("猜的數字 %d 太大了", guess)
+		return result, nil
+	} else if guess < answer {
+		result.Result = "too_small"
+		result.Message = fmt.Sprintf("猜的數字 %d 太小了", guess)
+		return result, nil
+	}
+
+	result.Result = "correct"
+	result.Message = "恭喜你猜對了！"
+	result.Solved = true
+	result.DurationMs = attempt.FinishedAt.Sub(attempt.StartedAt).Milliseconds()
+
+	// 唯一鍵避免同一天重複記錄；失敗時挑戰還原為未完成，可以再猜一次
+	err = d.mysqlRepo.AddDailyResult(models.DailyResults{
+		ID:         utils.GenerateUUID(),
+		UserID:     userID,
+		Date:       date,
+		GuessCount: result.GuessCount,
+		DurationMs: result.DurationMs,
+	})
+	if err != nil {
+		_, restoreErr := d.redisRepo.UpdateDailyAttempt(ctx, date, userID, dailyAttemptTTL, func(attempt *models.DailyAttempt) (bool, error) {
+			attempt.Solved = false
+			attempt.FinishedAt = nil
+			return true, nil
+		})
+		if restoreErr != nil {
+			log.Printf("還原玩家 %s 的每日挑戰狀態失敗: %v", userID, restoreErr)
+		}
+		return nil, fmt.Errorf("儲存每日挑戰結果失敗: %v", err)
+	}
+
+	streak, err := d.updateStreak(userID, date)
+	if err != nil {
+		return nil, err
+	}
+	result.Streak = streak
+
+	return result, nil
+}
+
+// 進行中的挑戰：今天的挑戰，或昨天開始、跨過午夜還沒完成的挑戰；都沒有時回傳 redis.Nil
+func (d *DailyChallengeManager) currentAttempt(ctx context.Context, userID string) (*models.DailyAttempt, error) {
+	now := time.Now()
+	attempt, err := d.redisRepo.GetDailyAttempt(ctx, game.DailyDate(now.AddDate(0, 0, -1)), userID)
+	if err == nil && !d.finished(attempt) {
+		return attempt, nil
+	} else if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	attempt, err = d.redisRepo.GetDailyAttempt(ctx, game.DailyDate(now), userID)
+	if err != nil {
+		return nil, err
+	}
+	if d.finished(attempt) {
+		return nil, fmt.Errorf("今天已完成每日挑戰，明天再來吧")
+	}
+	return attempt, nil
+}
+
+// 挑戰是否已完成；Redis 狀態沒有更新成功時以 MySQL 的結果為準
+func (d *DailyChallengeManager) finished(attempt *models.DailyAttempt) bool {
+	if attempt.Solved {
+		return true
+	}
+	_, err := d.mysqlRepo.GetDailyResult(attempt.UserID, attempt.Date)
+	return err == nil
+}
+
+// 完成挑戰後更新連續天數
+func (d *DailyChallengeManager) updateStreak(userID string, date string) (*models.DailyStreaks, error) {
+	streak, err := d.mysqlRepo.GetDailyStreak(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	streak.UserID = userID
+
+	today, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+	yesterday := game.DailyDate(today.AddDate(0, 0, -1))
+
+	switch streak.LastDate {
+	case date:
+		return &streak, nil
+	case yesterday:
+		streak.CurrentStreak++
+	default:
+		streak.CurrentStreak = 1
+	}
+	if streak.CurrentStreak > streak.BestStreak {
+		streak.BestStreak = streak.CurrentStreak
+	}
+	streak.LastDate = date
+
+	return &streak, d.mysqlRepo.SaveDailyStreak(streak)
+}
+
+// 取得玩家目前的連續天數，中斷超過一天則歸零
+func (d *DailyChallengeManager) GetStreak(userID string) (*models.DailyStreaks, error) {
+	streak, err := d.mysqlRepo.GetDailyStreak(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.DailyStreaks{UserID: userID}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if streak.LastDate != game.DailyDate(now) && streak.LastDate != game.DailyDate(now.AddDate(0, 0, -1)) {
+		streak.CurrentStreak = 0
+	}
+	return &streak, nil
+}
+
+func (d *DailyChallengeManager) GetLeaderboard(date string, limit int) ([]models.DailyLeaderboard, error) {
+	if date == "" {
+		date = game.DailyDate(time.Now())
+	}
+	return d.mysqlRepo.GetDailyLeaderboard(date, limit)
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"game/game"
+	"game/models"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 以記憶體模擬 Redis，UpdateDailyAttempt 和 WATCH/MULTI 一樣讓同時的更新依序套用
+type fakeDailyAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string][]byte
+}
+
+func (s *fakeDailyAttemptStore) key(date string, userID string) string {
+	return date + ":" + userID
+}
+
+func (s *fakeDailyAttemptStore) GetDailyAttempt(ctx context.Context, date string, userID string) (*models.DailyAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.attempts[s.key(date, userID)]
+	if !ok {
+		return nil, redis.Nil
+	}
+	var attempt models.DailyAttempt
+	return &attempt, json.Unmarshal(data, &attempt)
+}
+
+func (s *fakeDailyAttemptStore) SaveDailyAttempt(ctx context.Context, attempt *models.DailyAttempt, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(attempt)
+	s.attempts[s.key(attempt.Date, attempt.UserID)] = data
+	return err
+}
+
+func (s *fakeDailyAttemptStore) UpdateDailyAttempt(ctx context.Context, date string, userID string, ttl time.Duration, fn func(attempt *models.DailyAttempt) (bool, error)) (*models.DailyAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.attempts[s.key(date, userID)]
+	if !ok {
+		return nil, redis.Nil
+	}
+	var attempt models.DailyAttempt
+	if err := json.Unmarshal(data, &attempt); err != nil {
+		return nil, err
+	}
+	save, err := fn(&attempt)
+	if err != nil || !save {
+		return &attempt, err
+	}
+	data, err = json.Marshal(&attempt)
+	s.attempts[s.key(date, userID)] = data
+	return &attempt, err
+}
+
+// 以記憶體模擬 MySQL，daily_results 的 (user_id, date) 為唯一鍵
+type fakeDailyResultStore struct {
+	mu      sync.Mutex
+	results map[string]models.DailyResults
+	streaks map[string]models.DailyStreaks
+	addErr  error // 設定時，寫入結果失敗
+}
+
+func (s *fakeDailyResultStore) AddDailyResult(result models.DailyResults) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.addErr != nil {
+		return s.addErr
+	}
+	key := result.Date + ":" + result.UserID
+	if _, exists := s.results[key]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	s.results[key] = result
+	return nil
+}
+
+func (s *fakeDailyResultStore) GetDailyResult(userID string, date string) (models.DailyResults, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[date+":"+userID]
+	if !ok {
+		return result, gorm.ErrRecordNotFound
+	}
+	return result, nil
+}
+
+func (s *fakeDailyResultStore) GetDailyStreak(userID string) (models.DailyStreaks, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	streak, ok := s.streaks[userID]
+	if !ok {
+		return streak, gorm.ErrRecordNotFound
+	}
+	return streak, nil
+}
+
+func (s *fakeDailyResultStore) SaveDailyStreak(streak models.DailyStreaks) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streaks[streak.UserID] = streak
+	return nil
+}
+
+func (s *fakeDailyResultStore) GetDailyLeaderboard(date string, limit int) ([]models.DailyLeaderboard, error) {
+	return nil, nil
+}
+
+func newTestDailyManager() (*DailyChallengeManager, *fakeDailyAttemptStore, *fakeDailyResultStore) {
+	attempts := &fakeDailyAttemptStore{attempts: make(map[string][]byte)}
+	results := &fakeDailyResultStore{results: make(map[string]models.DailyResults), streaks: make(map[string]models.DailyStreaks)}
+	return &DailyChallengeManager{
+		redisRepo:       attempts,
+		mysqlRepo:       results,
+		answerGenerator: game.NewDailyAnswerGenerator("test-secret"),
+	}, attempts, results
+}
+
+func TestDailyGuessConcurrent(t *testing.T) {
+	manager, attempts, results := newTestDailyManager()
+	started, err := manager.Start("u1")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	answer := manager.answerGenerator.AnswerFor(started.Date, dailyMinRange, dailyMaxRange)
+
+	// 同時送出範圍內所有的數字
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var accepted []*models.DailyGuessResult
+	for guess := dailyMinRange; guess <= dailyMaxRange; guess++ {
+		wg.Add(1)
+		go func(guess int) {
+			defer wg.Done()
+			result, err := manager.Guess("u1", guess)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, result)
+			mu.Unlock()
+		}(guess)
+	}
+	wg.Wait()
+
+	attempt, err := attempts.GetDailyAttempt(context.Background(), started.Date, "u1")
+	if err != nil {
+		t.Fatalf("GetDailyAttempt: %v", err)
+	}
+	if len(attempt.Guesses) != len(accepted) {
+		t.Fatalf("紀錄了 %d 次猜測，但有 %d 次回傳成功，有猜測被覆蓋", len(attempt.Guesses), len(accepted))
+	}
+
+	// 每次成功的猜測都有不同的次數，猜中的次數等於它在紀錄中的位置
+	counts := make(map[int]bool)
+	var correct *models.DailyGuessResult
+	for _, result := range accepted {
+		if counts[result.GuessCount] {
+			t.Errorf("猜測次數 %d 重複出現", result.GuessCount)
+		}
+		counts[result.GuessCount] = true
+		if result.Solved {
+			if correct != nil {
+				t.Fatal("只能有一次猜中")
+			}
+			correct = result
+		}
+	}
+	if correct == nil {
+		t.Fatal("沒有任何一次猜中")
+	}
+	if correct.GuessCount != len(attempt.Guesses) || attempt.Guesses[correct.GuessCount-1] != answer {
+		t.Errorf("猜中的次數 %d 與紀錄 %v 不符", correct.GuessCount, attempt.Guesses)
+	}
+	if !attempt.Solved {
+		t.Error("猜中後挑戰應標記為完成")
+	}
+	stored, err := results.GetDailyResult("u1", started.Date)
+	if err != nil || stored.GuessCount != correct.GuessCount {
+		t.Errorf("排行榜記錄的次數 = %d (%v)，預期 %d", stored.GuessCount, err, correct.GuessCount)
+	}
+}
+
+func TestDailyGuessRestoresWhenResultFails(t *testing.T) {
+	manager, attempts, results := newTestDailyManager()
+	started, _ := manager.Start("u1")
+	answer := manager.answerGenerator.AnswerFor(started.Date, dailyMinRange, dailyMaxRange)
+
+	results.addErr = errors.New("寫入失敗")
+	if _, err := manager.Guess("u1", answer); err == nil {
+		t.Fatal("結果寫入失敗時應回傳錯誤")
+	}
+	attempt, _ := attempts.GetDailyAttempt(context.Background(), started.Date, "u1")
+	if attempt.Solved || attempt.FinishedAt != nil {
+		t.Fatal("結果寫入失敗時挑戰應維持未完成")
+	}
+
+	results.addErr = nil
+	result, err := manager.Guess("u1", answer)
+	if err != nil || !result.Solved || result.GuessCount != 2 {
+		t.Fatalf("再猜一次 = (%+v, %v)，預期第 2 次猜中", result, err)
+	}
+	if _, err := manager.Guess("u1", answer); err == nil {
+		t.Error("完成後不應再接受猜測")
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"game/models"
+	"game/protocol"
+	"game/repository"
+	"game/utils"
+	"time"
+)
+
+// 收件匣最多保留的私訊數與保存時間
+const (
+	inboxSize = 100
+	inboxTTL  = 7 * 24 * time.Hour
+)
+
+// DirectMessageManager 離線私訊的收件匣
+type DirectMessageManager struct {
+	redisRepo *repository.RedisGameService
+	mysqlRepo *repository.MySQLGameService
+}
+
+func NewDirectMessageManager(redisRepo *repository.RedisGameService, mysqlRepo *repository.MySQLGameService) *DirectMessageManager {
+	return &DirectMessageManager{redisRepo: redisRepo, mysqlRepo: mysqlRepo}
+}
+
+// 收件人不在線上時暫存私訊，收件人必須是已註冊的使用者
+func (m *DirectMessageManager) QueueDirectMessage(message models.DirectMessage) error {
+	if _, err := m.mysqlRepo.GetUserByID(message.ToUuid); err != nil {
+		return protocol.Errorf(protocol.ErrUserNotFound, "找不到該使用者")
+	}
+	message.ID = utils.GenerateUUID()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return m.redisRepo.PushInbox(ctx, message.ToUuid, message, inboxSize, inboxTTL)
+}
+
+// 離線期間收到的私訊，由舊到新；讀取不會移除，客戶端處理完後以 AckInbox 確認
+func (m *DirectMessageManager) Inbox(uuid string) ([]models.DirectMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return m.redisRepo.GetInbox(ctx, uuid)
+}
+
+// 確認已收到 untilID（含）以前的私訊，從收件匣移除並回傳移除的則數
+func (m *DirectMessageManager) AckInbox(uuid string, untilID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return m.redisRepo.AckInbox(ctx, uuid, untilID)
+}
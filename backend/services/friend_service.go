@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"game/models"
+	"game/protocol"
+	"game/repository"
+	"game/utils"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 遊戲邀請的有效時間與在線狀態的過期時間（連線期間由 ChatHub 定期更新）
+const (
+	inviteTTL   = 10 * time.Minute
+	presenceTTL = 3 * time.Minute
+)
+
+// FriendManager 好友、在線狀態與遊戲邀請
+type FriendManager struct {
+	mysqlRepo        *repository.MySQLGameService
+	redisRepo        *repository.RedisGameService
+	redisGameManager *RedisGameManager
+	notifications    *NotificationManager
+}
+
+func NewFriendManager(mysqlRepo *repository.MySQLGameService, redisRepo *repository.RedisGameService, redisGameManager *RedisGameManager, notifications *NotificationManager) *FriendManager {
+	return &FriendManager{mysqlRepo: mysqlRepo, redisRepo: redisRepo, redisGameManager: redisGameManager, notifications: notifications}
+}
+
+// 以使用者名稱送出好友邀請；對方已邀請過自己時直接成為好友
+func (f *FriendManager) SendRequest(uuid string, username string) (*models.Friendships, error) {
+	target, err := f.mysqlRepo.GetUserByName(username)
+	if err != nil {
+		return nil, fmt.Errorf("找不到使用者 %s", username)
+	}
+	if target.ID == uuid {
+		return nil, fmt.Errorf("不能加自己為好友")
+	}
+
+	existing, err := f.mysqlRepo.GetFriendshipBetween(uuid, target.ID)
+	if err == nil {
+		switch {
+		case existing.Status == models.FriendAccepted:
+			return nil, fmt.Errorf("你們已經是好友")
+		case existing.Status == models.FriendPending && existing.RequesterID == uuid:
+			return nil, fmt.Errorf("已經送出過邀請，等待對方回覆")
+		case existing.Status == models.FriendPending:
+			return f.respond(existing, true)
+		}
+		// 被拒絕過的邀請可以重新送出
+		now := time.Now()
+		existing.RequesterID, existing.AddresseeID = uuid, target.ID
+		existing.Status = models.FriendPending
+		existing.CreatedAt = now
+		existing.RespondedAt = nil
+		if err := f.mysqlRepo.SaveFriendship(existing); err != nil {
+			return nil, err
+		}
+		f.notifyRequest(existing)
+		return &existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	friendship := models.Friendships{
+		ID:          utils.GenerateUUID(),
+		RequesterID: uuid,
+		AddresseeID: target.ID,
+		Status:      models.FriendPending,
+	}
+	if err := f.mysqlRepo.AddFriendship(friendship); err != nil {
+		return nil, err
+	}
+	f.notifyRequest(friendship)
+	return &friendship, nil
+}
+
+// 受邀者接受或拒絕好友邀請
+func (f *FriendManager) Respond(id string, uuid string, accept bool) (*models.Friendships, error) {
+	friendship, err := f.mysqlRepo.GetFriendship(id)
+	if err != nil || friendship.AddresseeID != uuid {
+		return nil, fmt.Errorf("找不到該好友邀請")
+	}
+	if friendship.Status != models.FriendPending {
+		return nil, fmt.Errorf("該好友邀請已回覆過")
+	}
+	return f.respond(friendship, accept)
+}
+
+func (f *FriendManager) respond(friendship models.Friendships, accept bool) (*models.Friendships, error) {
+	now := time.Now()
+	friendship.RespondedAt = &now
+	friendship.Status = models.FriendDeclined
+	if accept {
+		friendship.Status = models.FriendAccepted
+	}
+	if err := f.mysqlRepo.SaveFriendship(friendship); err != nil {
+		return nil, err
+	}
+	if accept {
+		f.notifyAccepted(friendship)
+	}
+	return &friendship, nil
+}
+
+// 通知受邀者收到好友邀請
+func (f *FriendManager) notifyRequest(friendship models.Friendships) {
+	if f.notifications == nil {
+		return
+	}
+	requester, err := f.mysqlRepo.GetUserByID(friendship.RequesterID)
+	if err != nil {
+		return
+	}
+	f.notifications.Notify(friendship.AddresseeID, models.NotifyFriendRequest, "好友邀請",
+		fmt.Sprintf("%s 想加你為好友", requester.Username),
+		map[string]interface{}{"requestId": friendship.ID, "fromUuid": requester.ID, "from": requester.Username})
+}
+
+// 通知邀請人對方已接受
+func (f *FriendManager) notifyAccepted(friendship models.Friendships) {
+	if f.notifications == nil {
+		return
+	}
+	addressee, err := f.mysqlRepo.GetUserByID(friendship.AddresseeID)
+	if err != nil {
+		return
+	}
+	f.notifications.Notify(friendship.RequesterID, models.NotifyFriendAccepted, "好友邀請已接受",
+		fmt.Sprintf("%s 接受了你的好友邀請", addressee.Username),
+		map[string]interface{}{"friendUuid": addressee.ID, "friend": addressee.Username})
+}
+
+// 解除好友
+func (f *FriendManager) Remove(uuid string, friendUuid string) error {
+	friendship, err := f.mysqlRepo.GetFriendshipBetween(uuid, friendUuid)
+	if err != nil || friendship.Status != models.FriendAccepted {
+		return fmt.Errorf("對方不是你的好友")
+	}
+	return f.mysqlRepo.DeleteFriendship(friendship.ID)
+}
+
+// 好友列表，附上每位好友的在線狀態
+func (f *FriendManager) Friends(uuid string) ([]models.FriendInfo, error) {
+	friends, err := f.mysqlRepo.GetFriends(uuid)
+	if err != nil {
+		return nil, err
+	}
+	uuids := make([]string, len(friends))
+	for i, friend := range friends {
+		uuids[i] = friend.UserID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	presences, err := f.redisRepo.GetPresences(ctx, uuids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range friends {
+		friends[i].Presence = presences[friends[i].UserID]
+	}
+	return friends, nil
+}
+
+// 尚未回覆的好友邀請
+func (f *FriendManager) PendingRequests(uuid string) ([]models.FriendRequest, error) {
+	return f.mysqlRepo.GetPendingFriendRequests(uuid)
+}
+
+func (f *FriendManager) AreFriends(userA string, userB string) (bool, error) {
+	friendship, err := f.mysqlRepo.GetFriendshipBetween(userA, userB)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return friendship.Status == models.FriendAccepted, nil
+}
+
+// 更新在線狀態；status 為 offline 時刪除紀錄
+func (f *FriendManager) SetPresence(uuid string, status string, gameID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if status == models.PresenceOffline {
+		return f.redisRepo.DeletePresence(ctx, uuid)
+	}
+	return f.redisRepo.SetPresence(ctx, uuid, models.Presence{Status: status, GameID: gameID, UpdatedAt: time.Now()}, presenceTTL)
+}
+
+// 建立邀請好友加入房間的一次性 token
+func (f *FriendManager) CreateInvite(gameID string, fromUuid string, fromName string, toUuid string) (*models.GameInvite, error) {
+	invite := &models.GameInvite{
+		Token:     utils.GenerateToken(16),
+		GameID:    gameID,
+		FromUuid:  fromUuid,
+		FromName:  fromName,
+		ToUuid:    toUuid,
+		ExpiresAt: time.Now().Add(inviteTTL),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return invite, f.redisRepo.SaveInvite(ctx, invite, inviteTTL)
+}
+
+// 受邀者以邀請 token 加入房間，回傳房號
+func (f *FriendManager) JoinByInvite(token string, uuid string, username string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	invite, err := f.redisRepo.GetInvite(ctx, token)
+	if err != nil || invite.ToUuid != uuid {
+		return "", protocol.Errorf(protocol.ErrInvalidInvite, "邀請不存在或已過期")
+	}
+	// 先刪除邀請確保只能使用一次；加入失敗（例如房間已滿）時放回，讓對方可以再試
+	if deleted, err := f.redisRepo.DeleteInvite(ctx, token); err != nil || !deleted {
+		return "", protocol.Errorf(protocol.ErrInvalidInvite, "邀請已被使用")
+	}
+	if err := f.redisGameManager.AddPlayer(invite.GameID, uuid, username); err != nil {
+		if ttl := time.Until(invite.ExpiresAt); ttl > 0 {
+			if restoreErr := f.redisRepo.SaveInvite(ctx, invite, ttl); restoreErr != nil {
+				log.Printf("還原邀請 %s 失敗: %v", token, restoreErr)
+			}
+		}
+		return "", err
+	}
+	return invite.GameID, nil
+}
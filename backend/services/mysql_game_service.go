@@ -2,9 +2,12 @@ package services
 
 import (
 	"fmt"
+	"game/game"
 	"game/models"
+	"game/protocol"
 	"game/repository"
 	"game/utils"
+	"time"
 )
 
 type GameManagerMysql struct {
@@ -26,30 +29,28 @@ func (g *GameManagerMysql) GetUsers() ([]models.Users, error) {
 	return users, nil
 }
 
-func (g *GameManagerMysql) GameResult(gameID string, userID *string, resultRound int, answer int, totalPlayers *int) error {
-	resultUUID := utils.GenerateUUID()
-	gameResult := models.GameResults{
-		ID:           resultUUID,
-		GameID:       gameID,
-		WinnerID:     userID,
-		Round:        resultRound,
-		Answer:       answer,
-		TotalPlayers: totalPlayers,
-	}
-
-	return g.mysqlRepo.AddGameResult(gameResult)
+// 儲存一輪遊戲結果（含承諾值與鹽值，供事後驗證）
+func (g *GameManagerMysql) GameResult(gameResult *models.GameResults) error {
+	gameResult.ID = utils.GenerateUUID()
+	return g.mysqlRepo.AddGameResult(*gameResult)
 }
 
-func (g *GameManagerMysql) GamePlayer(gameID string, userID string, gameResultRound int, turnOrder int) error {
-	resultUUID := utils.GenerateUUID()
-	gamePlayer := models.GamePlayers{
-		ID:               resultUUID,
-		GameID:           gameID,
-		UserID:           userID,
-		GameResultsRound: gameResultRound,
-		TurnOrder:        turnOrder,
+// 驗證過去某一輪的答案是否與開局時公開的承諾值相符
+func (g *GameManagerMysql) VerifyRound(gameID string, round int) (*models.GameResults, bool, error) {
+	gameResult, err := g.mysqlRepo.GetGameResult(gameID, round)
+	if err != nil {
+		return nil, false, fmt.Errorf("找不到該輪遊戲紀錄")
 	}
-	return g.mysqlRepo.AddGamePlayer(gamePlayer)
+	if gameResult.Commitment == "" {
+		return &gameResult, false, nil
+	}
+	return &gameResult, game.VerifyCommitment(gameResult.Answer, gameResult.Salt, gameResult.Commitment), nil
+}
+
+// 儲存玩家在某一輪的參與紀錄
+func (g *GameManagerMysql) GamePlayer(gamePlayer *models.GamePlayers) error {
+	gamePlayer.ID = utils.GenerateUUID()
+	return g.mysqlRepo.AddGamePlayer(*gamePlayer)
 }
 
 func (g *GameManagerMysql) Login(email string, password string) (string, string, error) {
@@ -90,6 +91,83 @@ func (g *GameManagerMysql) Register(username string, email string, password stri
 	return g.mysqlRepo.CreateUser(user)
 }
 
+// 查詢玩家的生涯統計
+func (g *GameManagerMysql) PlayerStats(username string) (*models.PlayerStats, error) {
+	stats, err := g.mysqlRepo.GetPlayerStats(username)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Username == "" {
+		return nil, protocol.Errorf(protocol.ErrUserNotFound, "找不到玩家 %s", username)
+	}
+	return &stats, nil
+}
+
+// 依本輪勝負更新 Elo 積分，回傳每位玩家的積分變化
+func (g *GameManagerMysql) UpdateRatings(players []string, winners map[string]bool) (map[string]models.RatingChange, error) {
+	ratings, err := g.mysqlRepo.GetRatings(players)
+	if err != nil {
+		return nil, err
+	}
+	deltas := game.EloDeltas(ratings, winners)
+	if err := g.mysqlRepo.ApplyRatingDeltas(deltas); err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.RatingChange, len(deltas))
+	for userID, delta := range deltas {
+		changes[userID] = models.RatingChange{
+			Before: ratings[userID],
+			After:  ratings[userID] + delta,
+			Delta:  delta,
+		}
+	}
+	return changes, nil
+}
+
+// 玩家目前的頭像網址，查不到時回傳空字串
+func (g *GameManagerMysql) AvatarURL(uuid string) string {
+	user, err := g.mysqlRepo.GetUserProfile(uuid)
+	if err != nil || user.AvatarURL == nil {
+		return ""
+	}
+	return *user.AvatarURL
+}
+
 func (g *GameManagerMysql) GetTopPlayers(limit int) ([]models.Leaderboard, error) {
 	return g.mysqlRepo.GetTopPlayers(limit)
 }
+
+// 儲存玩家對聊天內容的檢舉
+func (g *GameManagerMysql) ChatReport(report *models.ChatReports) error {
+	report.ID = utils.GenerateUUID()
+	report.Status = models.ReportOpen
+	return g.mysqlRepo.AddChatReport(*report)
+}
+
+// 列出檢舉，status 為空時列出全部
+func (g *GameManagerMysql) ListChatReports(status string, limit int) ([]models.ChatReports, error) {
+	if status != "" && status != models.ReportOpen && status != models.ReportReviewed && status != models.ReportDismissed {
+		return nil, fmt.Errorf("無效的檢舉狀態: %s", status)
+	}
+	return g.mysqlRepo.GetChatReports(status, limit)
+}
+
+// 管理員審查檢舉，將狀態改為 reviewed 或 dismissed
+func (g *GameManagerMysql) ReviewChatReport(id string, status string, reviewer string) (*models.ChatReports, error) {
+	if status != models.ReportReviewed && status != models.ReportDismissed {
+		return nil, fmt.Errorf("審查結果必須是 %s 或 %s", models.ReportReviewed, models.ReportDismissed)
+	}
+	report, err := g.mysqlRepo.GetChatReport(id)
+	if err != nil {
+		return nil, fmt.Errorf("找不到該檢舉")
+	}
+	now := time.Now()
+	report.Status = status
+	report.ReviewedAt = &now
+	report.ReviewedBy = &reviewer
+	if err := g.mysqlRepo.SaveChatReport(report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"game/game"
+	"game/moderation"
 	"game/repository"
 	"game/ws"
 	"log"
@@ -8,21 +10,33 @@ import (
 
 // NewStruWebSocketService 使用新的 WebSocket 服務
 type NewStruWebSocketService struct {
-	chatHub          *ws.ChatHub
-	redisGameService *repository.RedisGameService // Redis 服務
-	redisGameManager *RedisGameManager            // Redis GameManager
+	chatHub             *ws.ChatHub
+	redisGameService    *repository.RedisGameService // Redis 服務
+	redisGameManager    *RedisGameManager            // Redis GameManager
+	friendManager       *FriendManager
+	notificationManager *NotificationManager
+	achievementManager  *AchievementManager
 }
 
-func NewWebSocketService(redisGameService *repository.RedisGameService, mySQLService *repository.MySQLGameService) *NewStruWebSocketService {
-	redisGameManager := NewRedisGameManager(redisGameService) // 使用 RedisGameService 初始化 RedisGameManager
-	mysqlGameManager := NewGameManagerMysql(mySQLService)     // 使用 MySQLGameService 初始化 GameManager
+func NewWebSocketService(redisGameService *repository.RedisGameService, mySQLService *repository.MySQLGameService, answerGenerator game.AnswerGenerator, rateLimiter *ws.RateLimiter, moderator *moderation.Moderator, chatHistory *ChatHistoryManager) *NewStruWebSocketService {
+	mysqlGameManager := NewGameManagerMysql(mySQLService)                                                    // 使用 MySQLGameService 初始化 GameManager
+	redisGameManager := NewRedisGameManager(redisGameService, answerGenerator, mysqlGameManager)             // 使用 RedisGameService 初始化 RedisGameManager，加入房間時查詢頭像
+	directMessageManager := NewDirectMessageManager(redisGameService, mySQLService)                          // 離線私訊的收件匣
+	notificationManager := NewNotificationManager(mySQLService)                                              // 站內通知
+	friendManager := NewFriendManager(mySQLService, redisGameService, redisGameManager, notificationManager) // 好友、在線狀態與遊戲邀請
+	achievementManager := NewAchievementManager(mySQLService, notificationManager)                           // 每輪結束後評估成就
 	// 將 RedisGameManager 和 MySQLGameManager 作為接口傳入
-	chatHub := ws.NewChatHub(redisGameManager, mysqlGameManager)
+	chatHub := ws.NewChatHub(redisGameManager, mysqlGameManager, rateLimiter, moderator, chatHistory, directMessageManager, friendManager, notificationManager, achievementManager)
+	// 通知透過 ChatHub 即時推送到玩家的連線
+	notificationManager.SetPusher(chatHub)
 
 	return &NewStruWebSocketService{
-		chatHub:          chatHub,
-		redisGameService: redisGameService,
-		redisGameManager: redisGameManager,
+		chatHub:             chatHub,
+		redisGameService:    redisGameService,
+		redisGameManager:    redisGameManager,
+		friendManager:       friendManager,
+		notificationManager: notificationManager,
+		achievementManager:  achievementManager,
 	}
 }
 
@@ -36,6 +50,20 @@ func (s *NewStruWebSocketService) GetChatHub() *ws.ChatHub {
 	return s.chatHub
 }
 
+// 與 ChatHub 共用的好友服務，REST API 送出的好友邀請也能即時通知
+func (s *NewStruWebSocketService) GetFriendManager() *FriendManager {
+	return s.friendManager
+}
+
+func (s *NewStruWebSocketService) GetNotificationManager() *NotificationManager {
+	return s.notificationManager
+}
+
+// 與 ChatHub 共用的成就服務，成就通知同樣即時推送
+func (s *NewStruWebSocketService) GetAchievementManager() *AchievementManager {
+	return s.achievementManager
+}
+
 // 獲取 Redis GameManager (services.GameManager)
 func (s *NewStruWebSocketService) GetRedisGameManager() *RedisGameManager {
 	return s.redisGameManager
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"game/models"
+	"game/repository"
+	"game/utils"
+	"log"
+	"time"
+)
+
+// 每次最多列出的通知數
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+)
+
+// NotificationPusher 即時推送通知到玩家目前的連線（由 ws.ChatHub 實作）
+type NotificationPusher interface {
+	PushNotification(uuid string, notification *models.Notifications) bool
+}
+
+// NotificationManager 站內通知：寫入 MySQL 後推送到玩家所有的 WebSocket 連線，不在線上的玩家下次查詢時看到
+type NotificationManager struct {
+	mysqlRepo *repository.MySQLGameService
+	pusher    NotificationPusher
+}
+
+func NewNotificationManager(mysqlRepo *repository.MySQLGameService) *NotificationManager {
+	return &NotificationManager{mysqlRepo: mysqlRepo}
+}
+
+// ChatHub 建立後再設定推送對象（ChatHub 本身也依賴 NotificationManager）
+func (n *NotificationManager) SetPusher(pusher NotificationPusher) {
+	n.pusher = pusher
+}
+
+// 建立通知並即時推送
+func (n *NotificationManager) Notify(userID string, kind string, title string, body string, data map[string]interface{}) error {
+	notification, err := n.create(userID, kind, title, body, data)
+	if err != nil {
+		return err
+	}
+	if n.pusher != nil {
+		n.pusher.PushNotification(userID, notification)
+	}
+	return nil
+}
+
+// 只建立通知不推送，用於已經以其他事件即時送達的內容（例如大廳收到的遊戲邀請）
+func (n *NotificationManager) Record(userID string, kind string, title string, body string, data map[string]interface{}) error {
+	_, err := n.create(userID, kind, title, body, data)
+	return err
+}
+
+func (n *NotificationManager) create(userID string, kind string, title string, body string, data map[string]interface{}) (*models.Notifications, error) {
+	notification := models.Notifications{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		Type:      kind,
+		Title:     title,
+		Body:      body,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if err := n.mysqlRepo.AddNotification(notification); err != nil {
+		log.Printf("建立通知給 %s 失敗: %v", userID, err)
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// 列出通知，before 用於往前翻頁
+func (n *NotificationManager) List(userID string, unreadOnly bool, before time.Time, limit int) ([]models.Notifications, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	return n.mysqlRepo.GetNotifications(userID, unreadOnly, before, limit)
+}
+
+func (n *NotificationManager) UnreadCount(userID string) (int64, error) {
+	return n.mysqlRepo.CountUnreadNotifications(userID)
+}
+
+// 將指定的通知標為已讀，ids 為空時全部標為已讀
+func (n *NotificationManager) MarkRead(userID string, ids []string) (int64, error) {
+	return n.mysqlRepo.MarkNotificationsRead(userID, ids)
+}
@@ -2,30 +2,96 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"time"
 
+	gamepkg "game/game"
 	"game/models"
+	"game/protocol"
 	"game/repository"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// AvatarLookup 玩家加入房間時查詢頭像網址，沒有頭像時回傳空字串
+type AvatarLookup interface {
+	AvatarURL(uuid string) string
+}
+
 // RedisGameManager 使用 Redis 儲存
 type RedisGameManager struct {
-	redisRepo *repository.RedisGameService
+	redisRepo       *repository.RedisGameService
+	answerGenerator gamepkg.AnswerGenerator // 出題方式，可注入以便測試或每日挑戰
+	avatars         AvatarLookup            // 可為 nil，房間內的玩家不顯示頭像
 }
 
-func NewRedisGameManager(redisRepo *repository.RedisGameService) *RedisGameManager {
+func NewRedisGameManager(redisRepo *repository.RedisGameService, answerGenerator gamepkg.AnswerGenerator, avatars AvatarLookup) *RedisGameManager {
+	if answerGenerator == nil {
+		answerGenerator = gamepkg.NewRandomAnswerGenerator()
+	}
 	return &RedisGameManager{
-		redisRepo: redisRepo,
+		redisRepo:       redisRepo,
+		answerGenerator: answerGenerator,
+		avatars:         avatars,
+	}
+}
+
+// 讀取遊戲，找不到時回傳 game_not_found 錯誤碼
+func (g *RedisGameManager) loadGame(ctx context.Context, gameID string) (*models.Game, error) {
+	game, err := g.redisRepo.GetGame(ctx, gameID)
+	if errors.Is(err, redis.Nil) {
+		return nil, protocol.Errorf(protocol.ErrGameNotFound, "找不到遊戲: %s", gameID)
 	}
+	return game, err
+}
+
+// 以樂觀鎖讀取、修改並寫回遊戲，fn 回傳 false 或錯誤時不寫入；回傳最後讀到的遊戲狀態
+func (g *RedisGameManager) updateGame(ctx context.Context, gameID string, fn func(game *models.Game) (bool, error)) (*models.Game, error) {
+	game, err := g.redisRepo.UpdateGame(ctx, gameID, 1*time.Hour, fn)
+	if errors.Is(err, redis.Nil) {
+		return nil, protocol.Errorf(protocol.ErrGameNotFound, "找不到遊戲: %s", gameID)
+	}
+	return game, err
 }
 
 // 創建遊戲
-func (g *RedisGameManager) CreateGame(gameID string, numPlayers int) error {
+func (g *RedisGameManager) CreateGame(gameID string, numPlayers int, options models.GameOptions) error {
+	mode := options.Mode
+	if mode == "" {
+		mode = models.ModeClassic
+	}
+	if mode != models.ModeClassic && mode != models.ModeSimultaneous && mode != models.ModeTeam && mode != models.ModeElimination {
+		return protocol.Errorf(protocol.ErrInvalidOptions, "不支援的遊戲模式: %s", mode)
+	}
+	roundSeconds := options.RoundSeconds
+	if roundSeconds <= 0 {
+		roundSeconds = 30
+	}
+	if roundSeconds < 10 || roundSeconds > 120 {
+		return protocol.Errorf(protocol.ErrInvalidOptions, "回合秒數必須在 10 到 120 之間")
+	}
+	feedbackMode := options.FeedbackMode
+	if feedbackMode == "" {
+		feedbackMode = models.FeedbackDirection
+	}
+	if feedbackMode != models.FeedbackDirection && feedbackMode != models.FeedbackProximityAnswer && feedbackMode != models.FeedbackProximityPrevious {
+		return protocol.Errorf(protocol.ErrInvalidOptions, "不支援的回饋模式: %s", feedbackMode)
+	}
+	teamCount := 0
+	if mode == models.ModeTeam {
+		teamCount = options.TeamCount
+		if teamCount == 0 {
+			teamCount = 2
+		}
+		if teamCount < 2 || teamCount > numPlayers {
+			return protocol.Errorf(protocol.ErrInvalidOptions, "隊伍數必須在 2 到 %d 之間", numPlayers)
+		}
+	}
+
 	game := &models.Game{
 		NumOfPeople:    numPlayers,
-		Answer:         rand.Intn(100) + 1,
+		Answer:         g.answerGenerator.Generate(1, 100),
 		Round:          0,
 		MinRange:       1,
 		MaxRange:       100,
@@ -33,6 +99,11 @@ func (g *RedisGameManager) CreateGame(gameID string, numPlayers int) error {
 		Players:        []models.Player{},
 		CurrentTurn:    0,
 		PlayersGuessed: make(map[string]bool),
+		Mode:           mode,
+		RoundSeconds:   roundSeconds,
+		TeamCount:      teamCount,
+		PowerUps:       options.PowerUps,
+		FeedbackMode:   feedbackMode,
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -44,25 +115,25 @@ func (g *RedisGameManager) CreateGame(gameID string, numPlayers int) error {
 func (g *RedisGameManager) AddPlayer(gameID string, uuid string, name string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return err
 	}
 	// 檢查遊戲狀態
 	if game.Status == "playing" {
-		return fmt.Errorf("遊戲狀態不正確: %s", game.Status)
+		return protocol.Errorf(protocol.ErrInvalidState, "遊戲狀態不正確: %s", game.Status)
 
 	}
 	// 檢查人數限制
 	if len(game.Players) >= game.NumOfPeople {
 		fmt.Println("遊戲人數已滿")
-		return fmt.Errorf("遊戲人數已滿: %d/%d", len(game.Players), game.NumOfPeople)
+		return protocol.Errorf(protocol.ErrRoomFull, "遊戲人數已滿: %d/%d", len(game.Players), game.NumOfPeople)
 	}
 	// 檢查玩家是否已存在
 	for _, p := range game.Players {
 		if uuid == p.Uuid {
 			fmt.Println("玩家已存在", uuid)
-			return fmt.Errorf("玩家已存在: %s", uuid)
+			return protocol.Errorf(protocol.ErrAlreadyJoined, "玩家已存在: %s", uuid)
 		}
 	}
 
@@ -77,78 +148,628 @@ func (g *RedisGameManager) AddPlayer(gameID string, uuid string, name string) er
 		Guessed:   false,
 		Ready:     false,
 	}
+	if g.avatars != nil {
+		player.Avatar = g.avatars.AvatarURL(uuid)
+	}
 	game.Players = append(game.Players, player)
 
 	return g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
 }
 
 // 玩家猜數字
-func (g *RedisGameManager) GuessNumber(gameID string, uuid string, guess int) (bool, string, error) {
+func (g *RedisGameManager) GuessNumber(gameID string, uuid string, guess int) (*models.GuessResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
-		return false, "", err
+		return nil, err
 	}
 
 	if game.Status != "playing" {
-		return false, "遊戲尚未開始", nil
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲尚未開始")
 	}
 
 	// var playerIndex int
 	var result string
+	var guesserTeam int
+	var extraGuess bool
 
 	for i, player := range game.Players {
 		if player.Uuid == uuid {
 			// playerIndex = i
-			if game.PlayersGuessed[uuid] {
-				return false, "您已經猜過數字了", nil
+			if game.Mode == models.ModeTeam {
+				// 隊伍共用回合：輪到的隊伍任一成員都可以代表隊伍猜測
+				if player.Team != game.CurrentTurn+1 {
+					return nil, protocol.Errorf(protocol.ErrNotYourTurn, "現在輪到第 %d 隊猜測", game.CurrentTurn+1)
+				}
+				guesserTeam = player.Team
+			} else if game.Mode == models.ModeElimination {
+				// 淘汰模式嚴格依照順序，被淘汰的玩家只能觀戰
+				if player.Eliminated {
+					return nil, protocol.Errorf(protocol.ErrEliminated, "您已被淘汰，只能觀戰")
+				}
+				if i != game.CurrentTurn {
+					if current := game.GetCurrentPlayer(); current != nil {
+						return nil, protocol.Errorf(protocol.ErrNotYourTurn, "現在輪到 %s 猜測", current.Name)
+					}
+					return nil, protocol.Errorf(protocol.ErrNotYourTurn, "現在不是您的回合")
+				}
+			} else if game.PlayersGuessed[uuid] {
+				return nil, protocol.Errorf(protocol.ErrAlreadyGuessed, "您已經猜過數字了")
+			}
+			if player.ExtraGuesses > 0 {
+				// double_guess：這次猜測不會結束回合
+				game.Players[i].ExtraGuesses--
+				extraGuess = true
+			} else {
+				game.PlayersGuessed[uuid] = true
+				game.Players[i].Guessed = true
 			}
-			game.PlayersGuessed[uuid] = true
-			game.Players[i].Guessed = true
 			game.Players[i].GuessNum = guess // 記錄玩家猜測的數字
+			game.Players[i].GuessCount++
 			break
 		} else if i == len(game.Players)-1 {
-			return false, "您不在遊戲中", nil
+			return nil, protocol.Errorf(protocol.ErrNotInGame, "您不在遊戲中")
 		}
 	}
 
 	if guess < game.MinRange || guess > game.MaxRange {
-		return false, fmt.Sprintf("猜測數字必須在 %d 到 %d 之間", game.MinRange, game.MaxRange), nil
+		return nil, protocol.Errorf(protocol.ErrOutOfRange, "猜測數字必須在 %d 到 %d 之間", game.MinRange, game.MaxRange)
 	}
 
-	if game.Answer == guess {
+	// 依房間設定產生回饋，並記錄到本局的猜測紀錄
+	feedback := gamepkg.BuildFeedback(game.FeedbackMode, guess, game.Answer, game.MinRange, game.MaxRange, game.LastGuess(""))
+	result = gamepkg.FeedbackMessage(feedback)
+	game.GuessHistory = append(game.GuessHistory, models.GuessRecord{
+		Uuid:     uuid,
+		Name:     guesserName(game, uuid),
+		Guess:    guess,
+		Rotation: game.Rotation,
+		Feedback: feedback,
+	})
+
+	if game.Mode == models.ModeElimination {
+		return g.eliminationGuess(ctx, gameID, game, feedback)
+	}
+
+	if feedback.Correct {
 		game.Status = "finished"
-		return true, "恭喜你猜對了！", g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
-	} else if game.Answer < guess {
-		result = fmt.Sprintf("猜的數字 %d 太大了", guess)
-		// return fmt.Sprintf("猜的數字 %d 太大了，請再試一次", guess), g.redisRepo.SaveGame(gameID, game, 24*time.Hour)
-	} else if game.Answer > guess {
-		result = fmt.Sprintf("猜的數字 %d 太小了", guess)
-		// return fmt.Sprintf("猜的數字 %d 太小了，請再試一次", guess), g.redisRepo.SaveGame(gameID, game, 24*time.Hour)
+		game.WinningTeam = guesserTeam
+		return &models.GuessResult{
+			Correct:    true,
+			Finished:   true,
+			WinnerUuid: uuid,
+			Message:    result,
+			Feedback:   feedback,
+		}, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+	}
+
+	if extraGuess {
+		return &models.GuessResult{Message: result, Feedback: feedback, ExtraGuess: true}, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
 	}
 
-	game.CurrentTurn = (game.CurrentTurn + 1) % len(game.Players)
+	if game.Mode == models.ModeTeam {
+		expireExtraGuesses(game, game.CurrentTurn+1)
+		game.CurrentTurn = (game.CurrentTurn + 1) % game.TeamCount
+	} else {
+		game.CurrentTurn = (game.CurrentTurn + 1) % len(game.Players)
+	}
 	if game.CurrentTurn == 0 {
 		game.PlayersGuessed = make(map[string]bool) // 重置玩家猜測狀態
+		expireExtraGuesses(game, 0)
 		for i := range game.Players {
 			game.Players[i].Guessed = false // 重置玩家猜測狀態
 			// game.Players[i].GuessNum = 0     // 重置玩家猜測數字
 		}
 	}
-	return false, result, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+	return &models.GuessResult{Message: result, Feedback: feedback}, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+}
+
+// 淘汰模式：猜中不會直接結束，每輪結束時淘汰離答案最遠的玩家
+func (g *RedisGameManager) eliminationGuess(ctx context.Context, gameID string, game *models.Game, feedback *models.GuessFeedback) (*models.GuessResult, error) {
+	result := &models.GuessResult{
+		Correct:  feedback.Correct,
+		Message:  gamepkg.FeedbackMessage(feedback),
+		Feedback: feedback,
+	}
+	if feedback.Correct {
+		result.Message = "猜中了！本輪安全"
+	}
+
+	// 所有存活玩家都猜過才算完成一輪
+	rotationDone := true
+	for _, player := range game.Players {
+		if !player.Eliminated && !game.PlayersGuessed[player.Uuid] {
+			rotationDone = false
+			break
+		}
+	}
+	if !rotationDone {
+		game.CurrentTurn = nextActivePlayer(game, game.CurrentTurn)
+		return result, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+	}
+
+	elimination, err := g.eliminateFurthest(game)
+	if err != nil {
+		return nil, err
+	}
+	result.Elimination = elimination
+
+	active := game.ActivePlayers()
+	if len(active) == 1 {
+		game.Status = "finished"
+		result.Finished = true
+		result.WinnerUuid = active[0].Uuid
+		return result, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+	}
+
+	game.PlayersGuessed = make(map[string]bool)
+	for i := range game.Players {
+		game.Players[i].Guessed = false
+	}
+	game.CurrentTurn = nextActivePlayer(game, -1)
+	return result, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+}
+
+// 淘汰離答案最遠的玩家（同距離時淘汰較晚出手者），並重新出題與公開新的承諾值
+func (g *RedisGameManager) eliminateFurthest(game *models.Game) (*models.Elimination, error) {
+	furthest := -1
+	furthestDistance := -1
+	for i, player := range game.Players {
+		if player.Eliminated {
+			continue
+		}
+		distance := player.GuessNum - game.Answer
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance >= furthestDistance {
+			furthest = i
+			furthestDistance = distance
+		}
+	}
+
+	game.Rotation++
+	elimination := &models.Elimination{
+		Rotation:   game.Rotation,
+		Answer:     game.Answer,
+		Salt:       game.Salt,
+		Commitment: game.Commitment,
+	}
+
+	// 全部都猜中時沒有人被淘汰；有護盾的玩家消耗護盾免於這次淘汰
+	if furthest >= 0 && furthestDistance > 0 && game.Players[furthest].Shielded {
+		game.Players[furthest].Shielded = false
+		elimination.ShieldedUuid = game.Players[furthest].Uuid
+		elimination.Name = game.Players[furthest].Name
+		elimination.Guess = game.Players[furthest].GuessNum
+	} else if furthest >= 0 && furthestDistance > 0 {
+		game.Players[furthest].Eliminated = true
+		elimination.Uuid = game.Players[furthest].Uuid
+		elimination.Name = game.Players[furthest].Name
+		elimination.Guess = game.Players[furthest].GuessNum
+	}
+
+	if len(game.ActivePlayers()) > 1 {
+		game.Answer = g.answerGenerator.Generate(game.MinRange, game.MaxRange)
+		salt, commitment, err := gamepkg.NewCommitment(game.Answer)
+		if err != nil {
+			return nil, err
+		}
+		game.Salt = salt
+		game.Commitment = commitment
+		elimination.NextCommitment = commitment
+	}
+	return elimination, nil
+}
+
+func guesserName(game *models.Game, uuid string) string {
+	for _, player := range game.Players {
+		if player.Uuid == uuid {
+			return player.Name
+		}
+	}
+	return ""
+}
+
+// 回合結束時清掉沒用完的連猜，避免帶到下一個回合；team 為 0 代表所有玩家
+func expireExtraGuesses(game *models.Game, team int) {
+	for i := range game.Players {
+		if team == 0 || game.Players[i].Team == team {
+			game.Players[i].ExtraGuesses = 0
+		}
+	}
+}
+
+// 從 from 之後找下一位未被淘汰的玩家
+func nextActivePlayer(game *models.Game, from int) int {
+	for step := 1; step <= len(game.Players); step++ {
+		i := (from + step + len(game.Players)) % len(game.Players)
+		if !game.Players[i].Eliminated {
+			return i
+		}
+	}
+	return 0
+}
+
+// 使用道具，驗證房間設定、模式、回合與庫存
+func (g *RedisGameManager) UseItem(gameID string, uuid string, item string) (*models.ItemResult, *models.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	game, err := g.loadGame(ctx, gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !game.PowerUps {
+		return nil, nil, protocol.Errorf(protocol.ErrItemUnavailable, "此房間未啟用道具")
+	}
+	if game.Status != "playing" {
+		return nil, nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲尚未開始")
+	}
+
+	index := -1
+	for i, player := range game.Players {
+		if player.Uuid == uuid {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, nil, protocol.Errorf(protocol.ErrNotInGame, "您不在遊戲中")
+	}
+	player := &game.Players[index]
+	if player.Eliminated {
+		return nil, nil, protocol.Errorf(protocol.ErrEliminated, "您已被淘汰，無法使用道具")
+	}
+	if player.Items[item] <= 0 {
+		return nil, nil, protocol.Errorf(protocol.ErrItemUnavailable, "您沒有道具 %s", item)
+	}
+
+	result := &models.ItemResult{Item: item}
+	switch item {
+	case models.ItemPeek:
+		parity := "偶數"
+		if game.Answer%2 != 0 {
+			parity = "奇數"
+		}
+		result.Message = fmt.Sprintf("玩家 %s 使用了偷看，得知答案的奇偶", player.Name)
+		result.Private = fmt.Sprintf("答案是%s", parity)
+
+	case models.ItemSkip:
+		if game.Mode == models.ModeTeam {
+			if player.Team != game.CurrentTurn+1 {
+				return nil, nil, protocol.Errorf(protocol.ErrNotYourTurn, "現在輪到第 %d 隊猜測", game.CurrentTurn+1)
+			}
+			expireExtraGuesses(game, game.CurrentTurn+1)
+			game.CurrentTurn = (game.CurrentTurn + 1) % game.TeamCount
+		} else if game.Mode == models.ModeClassic {
+			if game.PlayersGuessed[uuid] {
+				return nil, nil, protocol.Errorf(protocol.ErrAlreadyGuessed, "您已經猜過數字了")
+			}
+			game.PlayersGuessed[uuid] = true
+			player.Guessed = true
+			player.ExtraGuesses = 0
+			game.CurrentTurn = (game.CurrentTurn + 1) % len(game.Players)
+		} else {
+			return nil, nil, protocol.Errorf(protocol.ErrWrongMode, "此模式無法跳過回合")
+		}
+		if game.CurrentTurn == 0 {
+			game.PlayersGuessed = make(map[string]bool)
+			expireExtraGuesses(game, 0)
+			for i := range game.Players {
+				game.Players[i].Guessed = false
+			}
+		}
+		result.Message = fmt.Sprintf("玩家 %s 使用了跳過，放棄這一回合", player.Name)
+
+	case models.ItemDoubleGuess:
+		if game.Mode != models.ModeClassic && game.Mode != models.ModeTeam {
+			return nil, nil, protocol.Errorf(protocol.ErrWrongMode, "此模式無法使用連猜")
+		}
+		if game.Mode == models.ModeTeam && player.Team != game.CurrentTurn+1 {
+			return nil, nil, protocol.Errorf(protocol.ErrNotYourTurn, "現在輪到第 %d 隊猜測", game.CurrentTurn+1)
+		}
+		if game.Mode == models.ModeClassic && game.PlayersGuessed[uuid] {
+			return nil, nil, protocol.Errorf(protocol.ErrAlreadyGuessed, "您已經猜過數字了")
+		}
+		player.ExtraGuesses++
+		result.Message = fmt.Sprintf("玩家 %s 使用了連猜，這回合可以猜兩次", player.Name)
+
+	case models.ItemShield:
+		// 目前只有淘汰模式會「輸掉」回合，護盾在其他模式沒有作用
+		if game.Mode != models.ModeElimination {
+			return nil, nil, protocol.Errorf(protocol.ErrWrongMode, "護盾只能在淘汰模式使用")
+		}
+		if player.Shielded {
+			return nil, nil, protocol.Errorf(protocol.ErrItemUnavailable, "護盾已經生效中")
+		}
+		player.Shielded = true
+		result.Message = fmt.Sprintf("玩家 %s 啟用了護盾", player.Name)
+
+	default:
+		return nil, nil, protocol.Errorf(protocol.ErrUnknownItem, "未知的道具: %s", item)
+	}
+
+	player.Items[item]--
+	game.ItemLog = append(game.ItemLog, models.ItemUsage{
+		Uuid:   player.Uuid,
+		Name:   player.Name,
+		Item:   item,
+		UsedAt: time.Now(),
+	})
+
+	return result, game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+}
+
+// 同時猜測模式：玩家提交密封的猜測，回傳是否所有玩家都已提交；
+// 所有人同時提交時以樂觀鎖重試，不會遺失任何一筆猜測
+func (g *RedisGameManager) SubmitSealedGuess(gameID string, uuid string, guess int) (*models.Game, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	game, err := g.updateGame(ctx, gameID, func(game *models.Game) (bool, error) {
+		if game.Status != "playing" {
+			return false, protocol.Errorf(protocol.ErrInvalidState, "遊戲尚未開始")
+		}
+		if game.Mode != models.ModeSimultaneous {
+			return false, protocol.Errorf(protocol.ErrWrongMode, "此房間不是同時猜測模式")
+		}
+		if guess < game.MinRange || guess > game.MaxRange {
+			return false, protocol.Errorf(protocol.ErrOutOfRange, "猜測數字必須在 %d 到 %d 之間", game.MinRange, game.MaxRange)
+		}
+
+		for i, player := range game.Players {
+			if player.Uuid == uuid {
+				// PlayersGuessed 記錄本回合已提交的玩家
+				if game.PlayersGuessed[uuid] {
+					return false, protocol.Errorf(protocol.ErrAlreadyGuessed, "本回合您已經提交過猜測了")
+				}
+				game.PlayersGuessed[uuid] = true
+				game.Players[i].Guessed = true
+				game.Players[i].GuessNum = guess
+				game.Players[i].GuessCount++
+				return true, nil
+			}
+		}
+		return false, protocol.Errorf(protocol.ErrNotInGame, "您不在遊戲中")
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	allGuessed := len(game.PlayersGuessed) >= len(game.Players)
+	return game, allGuessed, nil
+}
+
+// 同時猜測模式：揭曉指定回合。回合已揭曉過或遊戲已結束時回傳 nil；
+// 與提交猜測同時發生時以樂觀鎖重試，不會覆蓋彼此的寫入
+func (g *RedisGameManager) RevealRound(gameID string, guessRound int) (*models.RoundResult, *models.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result *models.RoundResult
+	game, err := g.updateGame(ctx, gameID, func(game *models.Game) (bool, error) {
+		result = nil
+		if game.Status != "playing" || game.Mode != models.ModeSimultaneous || game.GuessRound != guessRound {
+			return false, nil
+		}
+		result = revealRound(game, guessRound)
+		return true, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, game, nil
+}
+
+// 計算回合結果並更新遊戲狀態：計分、寫入猜測紀錄，有人猜中即結束，否則進入下一回合
+func revealRound(game *models.Game, guessRound int) *models.RoundResult {
+	result := &models.RoundResult{GuessRound: guessRound}
+
+	// 找出最接近答案的距離
+	closest := -1
+	for _, player := range game.Players {
+		if !game.PlayersGuessed[player.Uuid] {
+			continue
+		}
+		distance := player.GuessNum - game.Answer
+		if distance < 0 {
+			distance = -distance
+		}
+		if closest == -1 || distance < closest {
+			closest = distance
+		}
+	}
+
+	records := make([]models.GuessRecord, 0)
+	for i, player := range game.Players {
+		revealed := models.RevealedGuess{Uuid: player.Uuid, Name: player.Name, Hint: "none"}
+		if game.PlayersGuessed[player.Uuid] {
+			revealed.Guess = player.GuessNum
+			// 同時猜測模式和自己上一回合的猜測比較
+			feedback := gamepkg.BuildFeedback(game.FeedbackMode, player.GuessNum, game.Answer, game.MinRange, game.MaxRange, game.LastGuess(player.Uuid))
+			revealed.Feedback = feedback
+			records = append(records, models.GuessRecord{
+				Uuid:     player.Uuid,
+				Name:     player.Name,
+				Guess:    player.GuessNum,
+				Rotation: game.Rotation,
+				Feedback: feedback,
+			})
+			distance := player.GuessNum - game.Answer
+			switch {
+			case distance == 0:
+				revealed.Hint = "correct"
+				revealed.Points = 3
+				result.Finished = true
+			case feedback.Direction != "":
+				revealed.Hint = feedback.Direction
+			case feedback.Band != "":
+				revealed.Hint = feedback.Band
+			default:
+				revealed.Hint = feedback.Trend
+			}
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance != 0 && distance == closest {
+				revealed.Points = 1
+			}
+		}
+		game.Players[i].Score += revealed.Points
+		revealed.Score = game.Players[i].Score
+		result.Guesses = append(result.Guesses, revealed)
+	}
+	game.GuessHistory = append(game.GuessHistory, records...)
+
+	if result.Finished {
+		// 有人猜中即結束，分數最高者獲勝，同分時以猜中者優先
+		winner := -1
+		for i, player := range game.Players {
+			if winner == -1 || player.Score > game.Players[winner].Score ||
+				(player.Score == game.Players[winner].Score && result.Guesses[i].Hint == "correct" && result.Guesses[winner].Hint != "correct") {
+				winner = i
+			}
+		}
+		result.WinnerUuid = game.Players[winner].Uuid
+		game.Status = "finished"
+		game.RoundDeadline = time.Time{}
+		return result
+	}
+
+	// 進入下一回合
+	game.GuessRound++
+	game.RoundDeadline = time.Now().Add(time.Duration(game.RoundSeconds) * time.Second)
+	game.PlayersGuessed = make(map[string]bool)
+	for i := range game.Players {
+		game.Players[i].Guessed = false
+	}
+	return result
+}
+
+// 隊伍模式：玩家在大廳選擇隊伍
+func (g *RedisGameManager) ChooseTeam(gameID string, uuid string, team int) (*models.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	game, err := g.loadGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game.Mode != models.ModeTeam {
+		return nil, protocol.Errorf(protocol.ErrWrongMode, "此房間不是隊伍模式")
+	}
+	if game.Status == "playing" {
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲正在進行中，無法更換隊伍")
+	}
+	if team < 1 || team > game.TeamCount {
+		return nil, protocol.Errorf(protocol.ErrInvalidTeam, "隊伍編號必須在 1 到 %d 之間", game.TeamCount)
+	}
+	// 每隊人數上限，避免全部擠在同一隊
+	maxTeamSize := (game.NumOfPeople + game.TeamCount - 1) / game.TeamCount
+	if len(game.TeamMembers(team)) >= maxTeamSize {
+		return nil, protocol.Errorf(protocol.ErrTeamFull, "第 %d 隊人數已滿", team)
+	}
+	for i, player := range game.Players {
+		if player.Uuid == uuid {
+			game.Players[i].Team = team
+			return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+		}
+	}
+	return nil, protocol.Errorf(protocol.ErrNotInGame, "您不在房間內")
+}
+
+// 隊伍模式：房主依加入順序平均分配所有玩家
+func (g *RedisGameManager) BalanceTeams(gameID string, uuid string) (*models.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	game, err := g.loadGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game.Mode != models.ModeTeam {
+		return nil, protocol.Errorf(protocol.ErrWrongMode, "此房間不是隊伍模式")
+	}
+	if game.Status == "playing" {
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲正在進行中，無法重新分隊")
+	}
+	if !game.IsHost(uuid) {
+		return nil, protocol.Errorf(protocol.ErrNotHost, "只有房主可以自動分隊")
+	}
+	for i := range game.Players {
+		game.Players[i].Team = i%game.TeamCount + 1
+	}
+	return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+}
+
+// 房主禁言玩家，duration 後自動解除；禁言狀態存在遊戲中，房間過期時一併清除
+func (g *RedisGameManager) MutePlayer(gameID string, hostUuid string, targetUuid string, duration time.Duration) (*models.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	game, err := g.loadGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if !game.IsHost(hostUuid) {
+		return nil, protocol.Errorf(protocol.ErrNotHost, "只有房主可以禁言")
+	}
+	if targetUuid == hostUuid {
+		return nil, protocol.Errorf(protocol.ErrInvalidPayload, "不能禁言自己")
+	}
+	if game.FindPlayer(targetUuid) == nil {
+		return nil, protocol.Errorf(protocol.ErrNotInGame, "該玩家不在房間內")
+	}
+	if game.Muted == nil {
+		game.Muted = make(map[string]time.Time)
+	}
+	// 順便清掉已到期的禁言
+	for uuid, until := range game.Muted {
+		if !time.Now().Before(until) {
+			delete(game.Muted, uuid)
+		}
+	}
+	game.Muted[targetUuid] = time.Now().Add(duration)
+	return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+}
+
+// 房主解除禁言
+func (g *RedisGameManager) UnmutePlayer(gameID string, hostUuid string, targetUuid string) (*models.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	game, err := g.loadGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if !game.IsHost(hostUuid) {
+		return nil, protocol.Errorf(protocol.ErrNotHost, "只有房主可以解除禁言")
+	}
+	if _, muted := game.MutedUntil(targetUuid); !muted {
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "該玩家沒有被禁言")
+	}
+	delete(game.Muted, targetUuid)
+	return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
+}
+
+// 找出目前人數最少的隊伍
+func smallestTeam(game *models.Game) int {
+	smallest := 1
+	for team := 2; team <= game.TeamCount; team++ {
+		if len(game.TeamMembers(team)) < len(game.TeamMembers(smallest)) {
+			smallest = team
+		}
+	}
+	return smallest
 }
 
 // 玩家準備或取消準備
 func (g *RedisGameManager) PlayerReady(gameID string, uuid string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
 	if game.Status == "playing" {
-		return nil, fmt.Errorf("遊戲正在進行中，無法準備或取消準備")
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲正在進行中，無法準備或取消準備")
 	}
 	for i, player := range game.Players {
 		if player.Uuid == uuid {
@@ -157,7 +778,7 @@ func (g *RedisGameManager) PlayerReady(gameID string, uuid string) (*models.Game
 		}
 
 	}
-	return nil, fmt.Errorf("您不在房間內")
+	return nil, protocol.Errorf(protocol.ErrNotInGame, "您不在房間內")
 
 }
 
@@ -165,22 +786,65 @@ func (g *RedisGameManager) PlayerReady(gameID string, uuid string) (*models.Game
 func (g *RedisGameManager) StartGame(gameID string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
 	if game.Status != "waiting" {
-		return nil, fmt.Errorf("遊戲狀態不正確: %s", game.Status)
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲狀態不正確: %s", game.Status)
 	}
 	for _, player := range game.Players {
 		if !player.Ready {
-			return nil, fmt.Errorf("有玩家尚未準備好")
+			return nil, protocol.Errorf(protocol.ErrNotReady, "有玩家尚未準備好")
 		}
 	}
 	game.Status = "playing"
 	game.CurrentTurn = 0
 	game.PlayersGuessed = make(map[string]bool)
 
+	if game.Mode == models.ModeTeam {
+		// 尚未選隊的玩家自動補進人數最少的隊伍
+		for i := range game.Players {
+			if game.Players[i].Team == 0 {
+				game.Players[i].Team = smallestTeam(game)
+			}
+		}
+		for team := 1; team <= game.TeamCount; team++ {
+			if len(game.TeamMembers(team)) == 0 {
+				return nil, protocol.Errorf(protocol.ErrNotEnoughPlayers, "第 %d 隊沒有玩家", team)
+			}
+		}
+	}
+
+	if game.Mode == models.ModeElimination && len(game.Players) < 2 {
+		return nil, protocol.Errorf(protocol.ErrNotEnoughPlayers, "淘汰模式至少需要 2 位玩家")
+	}
+
+	// 道具每局重新發放
+	for i := range game.Players {
+		game.Players[i].Items = nil
+		if game.PowerUps {
+			game.Players[i].Items = make(map[string]int)
+			for _, item := range models.AllItems {
+				game.Players[i].Items[item] = 1
+			}
+		}
+	}
+	game.ItemLog = nil
+	game.GuessHistory = nil
+	game.StartedAt = time.Now()
+
+	if game.Mode == models.ModeSimultaneous {
+		game.GuessRound = 1
+		game.RoundDeadline = time.Now().Add(time.Duration(game.RoundSeconds) * time.Second)
+	}
+
+	// 公開答案的承諾值，遊戲結束時再揭露答案與鹽值供玩家驗證
+	game.Salt, game.Commitment, err = gamepkg.NewCommitment(game.Answer)
+	if err != nil {
+		return nil, err
+	}
+
 	return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
 }
 
@@ -188,20 +852,20 @@ func (g *RedisGameManager) StartGame(gameID string) (*models.Game, error) {
 func (g *RedisGameManager) PlayerLeave(gameID string, uuid string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
 	if game.Status == "playing" {
-		return nil, fmt.Errorf("遊戲正在進行中，無法離開")
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲正在進行中，無法離開")
 	}
 	for i, player := range game.Players {
 		if player.Uuid == uuid {
 			if len(game.Players) <= 1 {
 				if err := g.redisRepo.DeleteGame(ctx, gameID); err != nil {
-					return nil, fmt.Errorf("無法刪除遊戲: %v", err)
+					return nil, protocol.Errorf(protocol.ErrInternal, "無法刪除遊戲: %v", err)
 				}
-				return nil, fmt.Errorf("遊戲已被刪除，因為沒有玩家剩下")
+				return nil, protocol.Errorf(protocol.ErrGameClosed, "遊戲已被刪除，因為沒有玩家剩下")
 			} else {
 				game.Players = append(game.Players[:i], game.Players[i+1:]...)
 				break
@@ -220,7 +884,7 @@ func (g *RedisGameManager) PlayerLeave(gameID string, uuid string) (*models.Game
 func (g *RedisGameManager) PlayerForceLeave(gameID string, uuid string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
@@ -231,9 +895,9 @@ func (g *RedisGameManager) PlayerForceLeave(gameID string, uuid string) (*models
 		if player.Uuid == uuid {
 			if len(game.Players) <= 1 {
 				if err := g.redisRepo.DeleteGame(ctx, gameID); err != nil {
-					return nil, fmt.Errorf("無法刪除遊戲: %v", err)
+					return nil, protocol.Errorf(protocol.ErrInternal, "無法刪除遊戲: %v", err)
 				}
-				return nil, fmt.Errorf("遊戲已被刪除，因為沒有玩家剩下")
+				return nil, protocol.Errorf(protocol.ErrGameClosed, "遊戲已被刪除，因為沒有玩家剩下")
 			} else {
 				game.Players = append(game.Players[:i], game.Players[i+1:]...)
 				break
@@ -252,24 +916,38 @@ func (g *RedisGameManager) PlayerForceLeave(gameID string, uuid string) (*models
 func (g *RedisGameManager) ResetGame(gameID string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
 	if game.Status == "playing" {
-		return nil, fmt.Errorf("遊戲正在進行中，無法重置")
+		return nil, protocol.Errorf(protocol.ErrInvalidState, "遊戲正在進行中，無法重置")
 	}
 	game.Round++
 	game.Status = "waiting"
 	game.CurrentTurn = 0
 	game.PlayersGuessed = make(map[string]bool)
-	game.Answer = rand.Intn(100) + 1
+	game.Answer = g.answerGenerator.Generate(game.MinRange, game.MaxRange)
+	game.Salt = ""
+	game.Commitment = ""
+	game.GuessRound = 0
+	game.RoundDeadline = time.Time{}
+	game.WinningTeam = 0
+	game.Rotation = 0
+	game.ItemLog = nil
+	game.GuessHistory = nil
 
 	for i := range game.Players {
 		game.Players[i].TurnOrder = i
 		game.Players[i].Guessed = false
 		game.Players[i].GuessNum = 0
+		game.Players[i].GuessCount = 0
+		game.Players[i].Score = 0
 		game.Players[i].Ready = false
+		game.Players[i].Eliminated = false
+		game.Players[i].Items = nil
+		game.Players[i].ExtraGuesses = 0
+		game.Players[i].Shielded = false
 	}
 
 	return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
@@ -279,7 +957,7 @@ func (g *RedisGameManager) ResetGame(gameID string) (*models.Game, error) {
 func (g *RedisGameManager) ForceGameReset(gameID string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
@@ -290,13 +968,27 @@ func (g *RedisGameManager) ForceGameReset(gameID string) (*models.Game, error) {
 	game.Status = "waiting"
 	game.CurrentTurn = 0
 	game.PlayersGuessed = make(map[string]bool)
-	game.Answer = rand.Intn(100) + 1
+	game.Answer = g.answerGenerator.Generate(game.MinRange, game.MaxRange)
+	game.Salt = ""
+	game.Commitment = ""
+	game.GuessRound = 0
+	game.RoundDeadline = time.Time{}
+	game.WinningTeam = 0
+	game.Rotation = 0
+	game.ItemLog = nil
+	game.GuessHistory = nil
 
 	for i := range game.Players {
 		game.Players[i].TurnOrder = i
 		game.Players[i].Guessed = false
 		game.Players[i].GuessNum = 0
+		game.Players[i].GuessCount = 0
+		game.Players[i].Score = 0
 		game.Players[i].Ready = false
+		game.Players[i].Eliminated = false
+		game.Players[i].Items = nil
+		game.Players[i].ExtraGuesses = 0
+		game.Players[i].Shielded = false
 	}
 
 	return game, g.redisRepo.SaveGame(ctx, gameID, game, 1*time.Hour)
@@ -306,7 +998,7 @@ func (g *RedisGameManager) ForceGameReset(gameID string) (*models.Game, error) {
 func (g *RedisGameManager) GetAGameStatus(gameID string) (*models.Game, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	game, err := g.redisRepo.GetGame(ctx, gameID)
+	game, err := g.loadGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"game/game"
+	"game/models"
+	"game/repository"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// 個人頁面列出的最近遊戲數
+const recentGamesLimit = 10
+
+// UserManager 個人頁面與個人資料
+type UserManager struct {
+	mysqlRepo    *repository.MySQLGameService
+	achievements *AchievementManager
+}
+
+func NewUserManager(mysqlRepo *repository.MySQLGameService, achievements *AchievementManager) *UserManager {
+	return &UserManager{mysqlRepo: mysqlRepo, achievements: achievements}
+}
+
+// 個人頁面：生涯統計、積分、最近的遊戲與成就；self 為 true 時附上 email
+func (u *UserManager) Profile(userID string, self bool) (*models.UserProfile, error) {
+	user, err := u.mysqlRepo.GetUserProfile(userID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到該使用者")
+	}
+
+	stats, err := u.mysqlRepo.GetProfileStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	if stats.GamesPlayed > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.GamesPlayed)
+	}
+	if stats.FavouriteMode, err = u.mysqlRepo.GetFavouriteMode(userID); err != nil {
+		return nil, err
+	}
+	outcomes, err := u.mysqlRepo.GetRecentOutcomes(userID, streakWindow)
+	if err != nil {
+		return nil, err
+	}
+	stats.CurrentStreak = game.CurrentStreak(outcomes)
+	stats.Rating = user.Rating
+
+	recentGames, err := u.mysqlRepo.GetRecentGames(userID, recentGamesLimit)
+	if err != nil {
+		return nil, err
+	}
+	achievements, err := u.achievements.Achievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.UserProfile{
+		ID:           user.ID,
+		Username:     user.Username,
+		AvatarURL:    user.AvatarURL,
+		CreatedAt:    user.CreatedAt,
+		Stats:        stats,
+		RecentGames:  recentGames,
+		Achievements: achievements,
+	}
+	if self {
+		verified := user.EmailVerifiedAt != nil
+		profile.Email = user.Email
+		profile.EmailVerified = &verified
+	}
+	return profile, nil
+}
+
+// 更新使用者名稱或頭像，nil 表示不修改；avatarURL 為空字串時移除頭像
+func (u *UserManager) UpdateProfile(userID string, username *string, avatarURL *string) (*models.UserProfile, error) {
+	fields := make(map[string]interface{})
+
+	if username != nil {
+		name := strings.TrimSpace(*username)
+		if len(name) < 3 || len(name) > 15 {
+			return nil, fmt.Errorf("Username長度必須在3到15個字元之間")
+		}
+		existing, err := u.mysqlRepo.GetUserByName(name)
+		if err == nil && existing.ID != userID {
+			return nil, fmt.Errorf("使用者名稱 %s 已被使用", name)
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		fields["username"] = name
+	}
+
+	if avatarURL != nil {
+		avatar := strings.TrimSpace(*avatarURL)
+		if avatar == "" {
+			fields["avatar_url"] = nil
+		} else {
+			if err := validateAvatarURL(avatar); err != nil {
+				return nil, err
+			}
+			fields["avatar_url"] = avatar
+		}
+	}
+
+	if len(fields) > 0 {
+		if err := u.mysqlRepo.UpdateUser(userID, fields); err != nil {
+			return nil, err
+		}
+	}
+	return u.Profile(userID, true)
+}
+
+// 頭像只接受 http/https 的絕對網址
+func validateAvatarURL(avatar string) error {
+	if utf8.RuneCountInString(avatar) > 255 {
+		return fmt.Errorf("頭像網址過長")
+	}
+	parsed, err := url.Parse(avatar)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("頭像網址格式不正確")
+	}
+	return nil
+}
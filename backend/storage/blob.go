@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"game/config"
+)
+
+// BlobStore 上傳檔案的儲存位置（頭像等），以 key 存取，URL 回傳對外的網址
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewBlobStore 依設定建立儲存位置，未設定 STORAGE_DRIVER 時使用本機檔案系統
+func NewBlobStore(cfg config.Storage) (BlobStore, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "local":
+		return NewLocalStore(cfg.LocalDir, cfg.PublicURL), nil
+	case "s3":
+		return NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.PublicURL)
+	default:
+		return nil, fmt.Errorf("不支援的儲存方式: %s", cfg.Driver)
+	}
+}
+
+// KeyOf 由 URL 取回 key，不是此儲存位置的網址時 ok 為 false
+func KeyOf(store BlobStore, url string) (string, bool) {
+	prefix := store.URL("")
+	if prefix == "" || !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(url, prefix), true
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 本機儲存的預設目錄與網址前綴（由 gin 的靜態檔案路由提供）
+const (
+	DefaultLocalDir       = "uploads"
+	DefaultLocalPublicURL = "/uploads"
+)
+
+// LocalStore 存放在本機目錄，適合單機部署與開發環境
+type LocalStore struct {
+	dir       string
+	publicURL string
+}
+
+func NewLocalStore(dir string, publicURL string) *LocalStore {
+	if dir == "" {
+		dir = DefaultLocalDir
+	}
+	if publicURL == "" {
+		publicURL = DefaultLocalPublicURL
+	}
+	return &LocalStore{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Dir 檔案存放的目錄
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// 先寫入暫存檔再改名，避免讀到寫到一半的檔案
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+// key 不可跳出存放目錄
+func (s *LocalStore) path(key string) (string, error) {
+	joined := filepath.Join(s.dir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(s.dir, joined)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("無效的檔案路徑: %s", key)
+	}
+	return joined, nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// ResizeSquare 由中央裁成正方形後縮放為 size x size
+func ResizeSquare(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GenerateToken 生成 n 位元組的隨機字串（十六進位），用於一次性的連結或邀請
+func GenerateToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand 失敗時退回 UUID，仍然不可預測
+		return GenerateUUID()
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,50 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 每輪結果寫入後評估成就
+type AchievementService interface {
+	UnlockAchievements(player *models.GamePlayers, totalPlayers int) ([]models.AchievementInfo, error)
+}
+
+// 評估玩家的成就，新達成的成就公告給整個房間（玩家本人另外會收到通知）
+func (h *ChatHub) unlockAchievements(roomID string, playerName string, record *models.GamePlayers, totalPlayers int) {
+	if h.Achievements == nil {
+		return
+	}
+	earned, err := h.Achievements.UnlockAchievements(record, totalPlayers)
+	if err != nil {
+		log.Printf("評估玩家 %s 的成就失敗: %v", playerName, err)
+		return
+	}
+	for _, achievement := range earned {
+		h.BroadcastGameMessage(roomID, &models.GameMessage{
+			Type:       models.EventAchievementUnlocked,
+			GameId:     roomID,
+			Message:    fmt.Sprintf("%s 達成成就「%s」：%s", playerName, achievement.Name, achievement.Description),
+			From:       "系統",
+			PlayerName: playerName,
+			Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+			GameInfo: map[string]interface{}{
+				"uuid":        record.UserID,
+				"code":        achievement.Code,
+				"name":        achievement.Name,
+				"description": achievement.Description,
+			},
+			Payload: protocol.AchievementEvent{
+				Uuid:        record.UserID,
+				PlayerName:  playerName,
+				Code:        achievement.Code,
+				Name:        achievement.Name,
+				Description: achievement.Description,
+			},
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"game/models"
+	"game/moderation"
+	"game/protocol"
 	"log"
 	"sync"
 	"time"
@@ -11,61 +13,113 @@ import (
 
 // 定義接口
 type GameManager interface {
-	CreateGame(gameID string, numPlayers int) error
+	CreateGame(gameID string, numPlayers int, options models.GameOptions) error
 	AddPlayer(gameID string, uuid string, name string) error
 	GetAGameStatus(gameID string) (*models.Game, error)
 	PlayerReady(gameID string, uuid string) (*models.Game, error)
 	StartGame(gameID string) (*models.Game, error)
 	PlayerLeave(gameID string, uuid string) (*models.Game, error)
 	PlayerForceLeave(gameID string, uuid string) (*models.Game, error)
-	GuessNumber(gameID string, uuid string, guess int) (bool, string, error)
+	GuessNumber(gameID string, uuid string, guess int) (*models.GuessResult, error)
+	SubmitSealedGuess(gameID string, uuid string, guess int) (*models.Game, bool, error)
+	RevealRound(gameID string, guessRound int) (*models.RoundResult, *models.Game, error)
+	ChooseTeam(gameID string, uuid string, team int) (*models.Game, error)
+	BalanceTeams(gameID string, uuid string) (*models.Game, error)
+	UseItem(gameID string, uuid string, item string) (*models.ItemResult, *models.Game, error)
 	ResetGame(gameID string) (*models.Game, error)
 	ForceGameReset(gameID string) (*models.Game, error)
+	MutePlayer(gameID string, hostUuid string, targetUuid string, duration time.Duration) (*models.Game, error)
+	UnmutePlayer(gameID string, hostUuid string, targetUuid string) (*models.Game, error)
 }
 
 type MySQLGameService interface {
 	GetUsers() ([]models.Users, error)
-	GameResult(gameID string, userID *string, resultRound int, answer int, totalPlayers *int) error
-	GamePlayer(gameID string, userID string, gameResultRound int, turnOrder int) error
+	GameResult(gameResult *models.GameResults) error
+	GamePlayer(gamePlayer *models.GamePlayers) error
+	ChatReport(report *models.ChatReports) error
+	PlayerStats(username string) (*models.PlayerStats, error)
+	UpdateRatings(players []string, winners map[string]bool) (map[string]models.RatingChange, error)
+}
+
+// 房間聊天紀錄的儲存
+type ChatHistory interface {
+	AppendChat(gameID string, line models.ChatLine) error
+	ChatHistory(gameID string) ([]models.ChatLine, error)
+	ArchiveChat(gameID string, round int, since time.Time) error
 }
 
 type ChatHub struct {
-	Rooms        map[string]*Room
-	Join         chan *Client
-	Leave        chan *Client
-	Broadcast    chan []byte
-	mu           sync.RWMutex
-	GameManager  GameManager
-	MySQLService MySQLGameService
+	Rooms          map[string]*Room
+	Join           chan *Client
+	Leave          chan *Client
+	Broadcast      chan []byte
+	mu             sync.RWMutex
+	GameManager    GameManager
+	MySQLService   MySQLGameService
+	RateLimiter    *RateLimiter
+	Moderator      *moderation.Moderator
+	ChatHistory    ChatHistory
+	DirectMessages DirectMessageQueue
+	Commands       *CommandRegistry
+	Friends        FriendService
+	Notifier       Notifier
+	Achievements   AchievementService
+
+	roundMu     sync.Mutex // 同時猜測模式揭曉時避免計時器與最後一位玩家重複揭曉
+	timerMu     sync.Mutex
+	roundTimers map[string]*time.Timer // 同時猜測模式每個房間的回合計時器
+
+	userMu sync.RWMutex
+	users  map[string]map[*Client]bool      // 玩家 UUID 對應的所有房間連線，用於私訊與在線狀態
+	lobby  map[string]map[*LobbyClient]bool // 玩家 UUID 對應的所有大廳連線
 }
 
-func NewChatHub(gameManager GameManager, mySQLService MySQLGameService) *ChatHub {
+func NewChatHub(gameManager GameManager, mySQLService MySQLGameService, rateLimiter *RateLimiter, moderator *moderation.Moderator, chatHistory ChatHistory, directMessages DirectMessageQueue, friends FriendService, notifier Notifier, achievements AchievementService) *ChatHub {
 	return &ChatHub{
-		Rooms:        make(map[string]*Room),
-		Join:         make(chan *Client, 256),
-		Leave:        make(chan *Client, 256),
-		Broadcast:    make(chan []byte, 256),
-		GameManager:  gameManager,
-		MySQLService: mySQLService,
+		Rooms:          make(map[string]*Room),
+		Join:           make(chan *Client, 256),
+		Leave:          make(chan *Client, 256),
+		Broadcast:      make(chan []byte, 256),
+		GameManager:    gameManager,
+		MySQLService:   mySQLService,
+		RateLimiter:    rateLimiter,
+		Moderator:      moderator,
+		ChatHistory:    chatHistory,
+		DirectMessages: directMessages,
+		Commands:       defaultCommands(),
+		Friends:        friends,
+		Notifier:       notifier,
+		Achievements:   achievements,
+		roundTimers:    make(map[string]*time.Timer),
+		users:          make(map[string]map[*Client]bool),
+		lobby:          make(map[string]map[*LobbyClient]bool),
 	}
 }
 
 func (h *ChatHub) Run() {
 	log.Printf("ChatHub 正在運行...")
+	go h.presenceLoop()
 
 	for {
 		select {
 		case client := <-h.Join:
 			log.Printf("收到加入請求: %s 要加入房間 %s", client.PlayerName, client.RoomID)
 
+			h.mu.Lock()
 			if _, ok := h.Rooms[client.RoomID]; !ok {
 				h.Rooms[client.RoomID] = NewRoom(client.RoomID)
 				log.Printf("創建新房間: %s", client.RoomID)
 			}
 			h.Rooms[client.RoomID].Clients[client] = true
+			playerCount := len(h.Rooms[client.RoomID].Clients)
+			h.mu.Unlock()
+			h.indexUser(client)
+			if h.RateLimiter != nil {
+				h.RateLimiter.attach(client)
+			}
 
 			log.Printf("玩家 %s 加入聊天室 %s，目前聊天室人數：%d",
-				client.PlayerName, client.RoomID, len(h.Rooms[client.RoomID].Clients))
+				client.PlayerName, client.RoomID, playerCount)
 
 			gameMsg := models.GameMessage{
 				Type:        models.EventPlayerJoined,
@@ -73,12 +127,40 @@ func (h *ChatHub) Run() {
 				Message:     fmt.Sprintf("玩家 %s 加入了聊天室", client.PlayerName),
 				From:        "系統",
 				PlayerName:  client.PlayerName,
-				PlayerCount: len(h.Rooms[client.RoomID].Clients),
+				PlayerCount: playerCount,
 				Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+				Payload: protocol.PresenceEvent{
+					PlayerName:  client.PlayerName,
+					PlayerCount: playerCount,
+				},
 			}
 
 			h.BroadcastGameMessage(client.RoomID, &gameMsg)
 
+			if client.ProtocolVersion >= protocol.Version2 {
+				h.SendToClient(client, &models.GameMessage{
+					Type:      protocol.EventWelcome,
+					GameId:    client.RoomID,
+					Message:   fmt.Sprintf("已連線，使用協定 v%d", client.ProtocolVersion),
+					From:      "系統",
+					Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+					Payload: protocol.WelcomeEvent{
+						Version:           client.ProtocolVersion,
+						SupportedVersions: protocol.SupportedVersions(),
+						PlayerUuid:        client.PlayerUuid,
+						PlayerName:        client.PlayerName,
+					},
+				})
+			}
+
+			// 新連線先收到完整狀態與聊天紀錄，其他人收到更新後的房間狀態
+			go func(client *Client) {
+				h.sendSync(client)
+				h.sendChatHistory(client)
+				h.BroadcastRoomStatus(client.RoomID)
+				h.refreshPresence(client.PlayerUuid)
+			}(client)
+
 		case client := <-h.Leave:
 			log.Printf("收到離開請求: %s 要離開聊天室 %s", client.PlayerName, client.RoomID)
 
@@ -87,11 +169,16 @@ func (h *ChatHub) Run() {
 				if _, exists := room.Clients[client]; exists {
 					delete(room.Clients, client)
 					close(client.Send)
+					h.unindexUser(client)
+					if h.RateLimiter != nil {
+						h.RateLimiter.detach(client)
+					}
 
-					go func(roomID, playerName string) {
+					go func(roomID, playerUuid string) {
+						h.refreshPresence(playerUuid)
 						time.Sleep(100 * time.Millisecond)
-						h.broadcastRoomStatusAfterLeave(roomID)
-					}(client.RoomID, client.PlayerName)
+						h.BroadcastRoomStatus(roomID)
+					}(client.RoomID, client.PlayerUuid)
 
 					log.Printf("玩家 %s 已從房間 %s 移除", client.PlayerName, client.RoomID)
 				}
@@ -111,6 +198,8 @@ func (h *ChatHub) Run() {
 }
 
 func (h *ChatHub) BroadcastToRoom(roomID string, message *models.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	if room, ok := h.Rooms[roomID]; ok {
 		jsonMessage, _ := json.Marshal(message)
 		for client := range room.Clients {
@@ -119,6 +208,7 @@ func (h *ChatHub) BroadcastToRoom(roomID string, message *models.Message) {
 			default:
 				close(client.Send)
 				delete(room.Clients, client)
+				h.unindexUser(client)
 			}
 		}
 	}
@@ -126,50 +216,105 @@ func (h *ChatHub) BroadcastToRoom(roomID string, message *models.Message) {
 
 // 廣播遊戲訊息到指定房間
 func (h *ChatHub) BroadcastGameMessage(roomID string, gameMsg *models.GameMessage) {
+	// 傳送佇列塞滿時會移除連線，需要寫入鎖
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	if room, ok := h.Rooms[roomID]; ok {
-		jsonMessage, err := json.Marshal(gameMsg)
-		if err != nil {
-			log.Printf("序列化訊息失敗: %v", err)
-			return
-		}
+		// 序號遞增與送進佇列在同一個鎖內，確保每個客戶端收到的序號是連續且遞增的
+		room.seqMu.Lock()
+		defer room.seqMu.Unlock()
+		room.lastSeq++
+		gameMsg.Seq = room.lastSeq
 
-		log.Printf("廣播到房間 %s: %s", roomID, string(jsonMessage))
+		encoded := newEncodedMessage(gameMsg)
 		for client := range room.Clients {
+			jsonMessage, err := encoded.forClient(client)
+			if err != nil {
+				// 只略過這個連線，使用其他編碼的玩家照常收到
+				log.Printf("序列化訊息給 %s 失敗: %v", client.PlayerUuid, err)
+				continue
+			}
 			select {
 			case client.Send <- jsonMessage:
 			default:
 				close(client.Send)
 				delete(room.Clients, client)
+				h.unindexUser(client)
 			}
 		}
+		log.Printf("廣播到房間 %s: #%d %s", roomID, gameMsg.Seq, gameMsg.Type)
 	}
 }
 
-func (h *ChatHub) broadcastRoomStatusAfterLeave(roomID string) {
+// 只傳送給房間內指定的玩家（例如隊伍頻道），同樣不佔用房間序號
+// 會從連線、REST handler 等不同 goroutine 呼叫，持有讀取鎖時 Leave 不會關閉傳送佇列
+func (h *ChatHub) SendToPlayers(roomID string, uuids []string, gameMsg *models.GameMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if room, ok := h.Rooms[roomID]; ok {
+		encoded := newEncodedMessage(gameMsg)
+
+		targets := make(map[string]bool, len(uuids))
+		for _, uuid := range uuids {
+			targets[uuid] = true
+		}
+		for client := range room.Clients {
+			if !targets[client.PlayerUuid] {
+				continue
+			}
+			jsonMessage, err := encoded.forClient(client)
+			if err != nil {
+				// 只略過這個連線，使用其他編碼的玩家照常收到
+				log.Printf("序列化訊息給 %s 失敗: %v", client.PlayerUuid, err)
+				continue
+			}
+			select {
+			case client.Send <- jsonMessage:
+			default:
+				log.Printf("玩家 %s 的傳送佇列已滿，丟棄訊息 %s", client.PlayerName, gameMsg.Type)
+			}
+		}
+	}
+}
+
+// 只傳送給單一連線；不佔用房間序號，避免其他玩家誤判漏收
+func (h *ChatHub) SendToClient(client *Client, gameMsg *models.GameMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	// 已被移出房間的連線，傳送佇列已關閉
+	if room, ok := h.Rooms[client.RoomID]; !ok || !room.Clients[client] {
+		return
+	}
+	jsonMessage, err := newEncodedMessage(gameMsg).forClient(client)
+	if err != nil {
+		log.Printf("序列化訊息失敗: %v", err)
+		return
+	}
+	select {
+	case client.Send <- jsonMessage:
+	default:
+		log.Printf("玩家 %s 的傳送佇列已滿，丟棄訊息 %s", client.PlayerName, gameMsg.Type)
+	}
+}
+
+// 廣播完整房間狀態
+func (h *ChatHub) BroadcastRoomStatus(roomID string) {
 	gameState, err := h.GameManager.GetAGameStatus(roomID)
 	if err != nil {
 		log.Printf("獲取遊戲狀態失敗: %v", err)
 		return
 	}
 
-	players := make([]map[string]interface{}, 0)
-	readyCount := 0
-	totalPlayers := len(gameState.Players)
-
-	for _, player := range gameState.Players {
-		players = append(players, map[string]interface{}{
-			"uuid":    player.Uuid,
-			"name":    player.Name,
-			"isReady": player.Ready,
-		})
+	h.BroadcastGameMessage(roomID, roomStatusMessage(roomID, gameState))
+	log.Printf("✅ 成功廣播房間 %s 的狀態", roomID)
+}
 
-		if player.Ready {
-			readyCount++
-		}
-	}
+func roomStatusMessage(roomID string, gameState *models.Game) *models.GameMessage {
+	infos, players, readyCount := playerList(gameState)
+	totalPlayers := len(gameState.Players)
 
-	roomStatusMsg := models.GameMessage{
-		Type:    "room_status_update",
+	return &models.GameMessage{
+		Type:    protocol.EventRoomStatus,
 		GameId:  roomID,
 		Message: fmt.Sprintf("房間狀態更新: %d/%d 玩家，%d/%d 已準備", totalPlayers, gameState.NumOfPeople, readyCount, totalPlayers),
 		From:    "系統",
@@ -181,10 +326,180 @@ func (h *ChatHub) broadcastRoomStatusAfterLeave(roomID string) {
 			"gameStatus":     gameState.Status,
 			"minRange":       gameState.MinRange,
 			"maxRange":       gameState.MaxRange,
+			"mode":           gameState.Mode,
+		},
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Payload: protocol.RoomStatusEvent{
+			Players:        infos,
+			MaxPlayers:     gameState.NumOfPeople,
+			CurrentPlayers: totalPlayers,
+			ReadyCount:     readyCount,
+			GameStatus:     gameState.Status,
+			MinRange:       gameState.MinRange,
+			MaxRange:       gameState.MaxRange,
+			Mode:           gameState.Mode,
 		},
+	}
+}
+
+// 玩家公開資訊，同時回傳舊版格式與已準備人數
+func playerList(game *models.Game) ([]protocol.PlayerInfo, []map[string]interface{}, int) {
+	infos := make([]protocol.PlayerInfo, 0, len(game.Players))
+	players := make([]map[string]interface{}, 0, len(game.Players))
+	readyCount := 0
+	for _, player := range game.Players {
+		info := protocol.NewPlayerInfo(player)
+		infos = append(infos, info)
+		players = append(players, map[string]interface{}{
+			"uuid":       info.Uuid,
+			"name":       info.Name,
+			"avatar":     info.Avatar,
+			"isReady":    info.IsReady,
+			"team":       info.Team,
+			"eliminated": info.Eliminated,
+		})
+		if player.Ready {
+			readyCount++
+		}
+	}
+	return infos, players, readyCount
+}
+
+// 公告遊戲已重置，並送出重置後的房間狀態
+func (h *ChatHub) broadcastGameReset(roomID string, game *models.Game) {
+	resetMsg := models.GameMessage{
+		Type:      models.EventGameReset,
+		GameId:    roomID,
+		Message:   "遊戲已重置，請重新開始",
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Payload:   protocol.EmptyEvent{},
+	}
+	h.BroadcastGameMessage(roomID, &resetMsg)
+	h.BroadcastGameMessage(roomID, roomStatusMessage(roomID, game))
+}
+
+// 公告輪到的玩家或隊伍
+func (h *ChatHub) broadcastTurn(roomID string, turn protocol.TurnEvent) {
+	message := fmt.Sprintf("輪到 %s 猜測", turn.PlayerName)
+	if turn.Team > 0 {
+		message = fmt.Sprintf("輪到第 %d 隊猜測", turn.Team)
+	}
+	h.BroadcastGameMessage(roomID, &models.GameMessage{
+		Type:      protocol.EventPlayerTurn,
+		GameId:    roomID,
+		Message:   message,
+		From:      "系統",
 		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"CurrentTurn": turn.CurrentTurn,
+		},
+		Payload: turn,
+	})
+}
+
+// 檢查事件是否超過限流，未設定限流器時一律放行
+func (h *ChatHub) allowEvent(client *Client, eventType string) (bool, time.Duration, bool) {
+	if h.RateLimiter == nil {
+		return true, 0, false
 	}
+	return h.RateLimiter.allow(client, eventType)
+}
+
+// 無法解析的訊框是否還能回覆錯誤，以及是否應斷線
+func (h *ChatHub) allowMalformed(client *Client) (bool, bool) {
+	if h.RateLimiter == nil {
+		return true, false
+	}
+	return h.RateLimiter.allowMalformed(client)
+}
+
+// 房間目前的最後序號，房間不存在時為 0
+func (h *ChatHub) LastSeq(roomID string) int64 {
+	if room := h.room(roomID); room != nil {
+		return room.LastSeq()
+	}
+	return 0
+}
+
+// 取得房間，房間不存在時回傳 nil
+func (h *ChatHub) room(roomID string) *Room {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.Rooms[roomID]
+}
+
+// 房間內目前所有連線的快照
+func (h *ChatHub) roomClients(roomID string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	room, ok := h.Rooms[roomID]
+	if !ok {
+		return nil
+	}
+	clients := make([]*Client, 0, len(room.Clients))
+	for client := range room.Clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
 
-	h.BroadcastGameMessage(roomID, &roomStatusMsg)
-	log.Printf("✅ 成功廣播玩家離開後的房間狀態")
+// 儲存一輪遊戲結果與所有玩家的參與紀錄到 MySQL
+func (h *ChatHub) persistGameResult(roomID string, game *models.Game, winnerUuid string) {
+	go func() {
+		totalPlayers := len(game.Players)
+		var winningTeam *int
+		if game.Mode == models.ModeTeam {
+			winningTeam = &game.WinningTeam
+		}
+		err := h.MySQLService.GameResult(&models.GameResults{
+			GameID:       roomID,
+			WinnerID:     &winnerUuid,
+			Round:        game.Round,
+			Answer:       game.Answer,
+			TotalPlayers: &totalPlayers,
+			Mode:         game.Mode,
+			WinningTeam:  winningTeam,
+			Salt:         game.Salt,
+			Commitment:   game.Commitment,
+		})
+		if err != nil {
+			// 玩家紀錄與積分都依附在這一輪的結果上，沒有結果就不再寫入
+			log.Printf("儲存遊戲結果到 MySQL 失敗: %v", err)
+			return
+		}
+		// 只有成功寫入參與紀錄的玩家才會更新積分
+		players := make([]string, 0, totalPlayers)
+		winners := make(map[string]bool, totalPlayers)
+		for _, player := range game.Players {
+			score := player.Score
+			guessCount := player.GuessCount
+			won := player.Uuid == winnerUuid || (winningTeam != nil && player.Team == *winningTeam)
+			record := &models.GamePlayers{
+				GameID:           roomID,
+				UserID:           player.Uuid,
+				GameResultsRound: game.Round,
+				TurnOrder:        player.TurnOrder,
+				Score:            &score,
+				GuessCount:       &guessCount,
+				Team:             player.Team,
+				// 隊伍模式中獲勝隊伍的所有成員都算獲勝
+				Won: won,
+			}
+			h.notifyMatchResult(roomID, game, player, won)
+			if err = h.MySQLService.GamePlayer(record); err != nil {
+				log.Printf("儲存玩家參與結果到 MySQL 失敗: %v", err)
+				continue
+			}
+			players = append(players, player.Uuid)
+			winners[player.Uuid] = won
+			h.unlockAchievements(roomID, player.Name, record, totalPlayers)
+		}
+		h.updateRatings(roomID, game.Round, players, winners)
+		if h.ChatHistory != nil {
+			if err := h.ChatHistory.ArchiveChat(roomID, game.Round, game.StartedAt); err != nil {
+				log.Printf("封存房間 %s 第 %d 輪聊天紀錄失敗: %v", roomID, game.Round, err)
+			}
+		}
+	}()
 }
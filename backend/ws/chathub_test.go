@@ -0,0 +1,57 @@
+package ws
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"game/models"
+)
+
+// 只實作測試會用到的方法，其餘方法沿用嵌入的 nil 介面
+type fakeGameManager struct {
+	GameManager
+}
+
+func (fakeGameManager) GetAGameStatus(gameID string) (*models.Game, error) {
+	return nil, errors.New("找不到遊戲")
+}
+
+// REST handler 與對戰結果的 goroutine 推送通知時，連線可能同時離開房間
+func TestPushNotificationDuringLeave(t *testing.T) {
+	hub := NewChatHub(fakeGameManager{}, nil, nil, nil, nil, nil, nil, nil, nil)
+	go hub.Run()
+	notification := &models.Notifications{Type: models.NotifyMatchResult, Title: "對戰獲勝", CreatedAt: time.Now()}
+
+	for i := 0; i < 100; i++ {
+		client := NewClient(hub, nil, "room", "u1", "alice", "", 0, "")
+		hub.Join <- client
+		<-client.Send // 加入房間的廣播，確認 Join 已處理
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range client.Send {
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					hub.PushNotification("u1", notification)
+				}
+			}
+		}()
+
+		hub.Leave <- client
+		time.Sleep(time.Millisecond)
+		close(stop)
+		wg.Wait()
+	}
+}
@@ -2,12 +2,16 @@ package ws
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	gamepkg "game/game"
 	"game/models"
+	"game/protocol"
 
 	"github.com/gorilla/websocket"
 )
@@ -18,7 +22,38 @@ type Client struct {
 	RoomID     string
 	PlayerUuid string
 	PlayerName string
+	SessionID  string // 登入的 session，撤銷時中斷連線
 	Conn       *websocket.Conn
+
+	ProtocolVersion int    // 連線時協商的協定版本，見 protocol 套件
+	Encoding        string // 以 subprotocol 協商的編碼：json（預設）、msgpack 或 protobuf
+
+	// 目前處理中的請求，ReadPump 逐一處理訊息，因此不需要加鎖
+	requestId     string
+	requestFailed bool
+	disconnected  bool // 連線已中斷，錯誤只記錄不回傳
+
+	kicked atomic.Bool // 被房主踢出，已從遊戲中移除，斷線時不需再次離開
+
+	limiter *connectionLimiter // 此連線的限流狀態，只在 ReadPump 中使用
+}
+
+// NewClient 建立房間連線，需在啟動 ReadPump/WritePump 前建立完成
+func NewClient(hub *ChatHub, conn *websocket.Conn, roomID string, playerUuid string, playerName string, sessionID string, protocolVersion int, encoding string) *Client {
+	return &Client{
+		ChatHub:    hub,
+		Conn:       conn,
+		Send:       make(chan []byte, 256),
+		RoomID:     roomID,
+		PlayerUuid: playerUuid,
+		PlayerName: playerName,
+		SessionID:  sessionID,
+
+		ProtocolVersion: protocolVersion,
+		Encoding:        encoding,
+
+		limiter: newConnectionLimiter(),
+	}
 }
 
 // ReadPump 處理從客戶端接收的訊息
@@ -26,8 +61,9 @@ func (c *Client) ReadPump() {
 	var leftGame bool // 是否離開遊戲
 	defer func() {
 		// 強制關閉瀏覽器斷線websocket連接
+		c.disconnected = true
 		c.ChatHub.Leave <- c
-		if !leftGame {
+		if !leftGame && !c.kicked.Load() {
 			c.handleForceLeftGame()
 			c.handleForceGameReset()
 		}
@@ -42,7 +78,7 @@ func (c *Client) ReadPump() {
 	})
 
 	for {
-		_, message, err := c.Conn.ReadMessage()
+		messageType, message, err := c.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
@@ -50,41 +86,106 @@ func (c *Client) ReadPump() {
 			break
 		}
 
-		log.Printf("收到客戶端訊息: %s", string(message))
+		c.requestId = ""
+		c.requestFailed = false
 
-		var msg models.Message
-		if err := json.Unmarshal(message, &msg); err == nil {
-			msg.GameId = c.RoomID
-			msg.From = c.PlayerName
-
-			switch msg.Type {
-			case models.EventChat:
-				c.handleChat(msg)
-			case models.EventAuthenticate:
-				c.handleAuthenticate(msg)
-			case models.EventJoinGame:
-				c.handleJoinGame()
-			case models.EventLeftGame:
-				leftGame = true
-				c.handleLeftGame()
-			case models.EventStartGame:
-				c.handleStartGame()
-			case models.EventPlayerGuess:
-				c.handlePlayerGuess(msg)
-			case models.EventPlayerReady:
-				c.handleGameReady(msg)
-			case models.EventGameReset:
-				c.handleGameReset()
-			default:
-				c.ChatHub.BroadcastToRoom(c.RoomID, &msg)
+		// 二進位訊框依協商的編碼轉成 JSON，文字訊框一律視為 JSON
+		if messageType == websocket.BinaryMessage {
+			message, err = protocol.CodecFor(c.Encoding).ToJSON(message)
+			if err != nil {
+				log.Printf("解析訊息失敗: %v", err)
+				if c.rejectMalformed(err) {
+					break
+				}
+				continue
 			}
-		} else {
+		}
+
+		log.Printf("收到客戶端訊息: %s", string(message))
+
+		msg, err := protocol.DecodeInbound(message)
+		if err != nil {
 			log.Printf("解析訊息失敗: %v", err)
+			if c.rejectMalformed(err) {
+				break
+			}
+			continue
 		}
+
+		c.requestId = msg.RequestId
+
+		if allowed, retryAfter, disconnect := c.ChatHub.allowEvent(c, msg.Type); !allowed {
+			c.sendError(protocol.RateLimited(msg.Type, retryAfter))
+			if disconnect {
+				c.closeRateLimited()
+				break
+			}
+			continue
+		}
+
+		body := msg.Body()
+		switch msg.Type {
+		case models.EventChat:
+			c.handleChat(body)
+		case models.EventAuthenticate:
+			c.handleAuthenticate()
+		case models.EventJoinGame:
+			c.handleJoinGame()
+		case models.EventLeftGame:
+			leftGame = true
+			c.handleLeftGame()
+		case models.EventStartGame:
+			c.handleStartGame()
+		case models.EventPlayerGuess:
+			c.handlePlayerGuess(body)
+		case models.EventPlayerReady:
+			c.handleGameReady()
+		case models.EventGameReset:
+			c.handleGameReset()
+		case models.EventChooseTeam:
+			c.handleChooseTeam(body)
+		case models.EventBalanceTeams:
+			c.handleBalanceTeams()
+		case models.EventTeamChat:
+			c.handleTeamChat(body)
+		case models.EventUseItem:
+			c.handleUseItem(body)
+		case models.EventMute:
+			c.handleMute(body)
+		case models.EventUnmute:
+			c.handleUnmute(body)
+		case models.EventReport:
+			c.handleReport(body)
+		case models.EventDirectMessage:
+			c.handleDirectMessage(body)
+		case models.EventInvite:
+			c.handleInvite(body)
+		case protocol.EventResync:
+			c.ChatHub.sendSync(c)
+		default:
+			if c.ProtocolVersion >= protocol.Version2 {
+				c.sendError(protocol.Errorf(protocol.ErrUnknownType, "未知的事件類型: %s", msg.Type))
+				continue
+			}
+			// 舊版協定：未知類型的訊息原樣轉發到房間
+			var legacy models.Message
+			if err := json.Unmarshal(message, &legacy); err == nil {
+				legacy.GameId = c.RoomID
+				legacy.From = c.PlayerName
+				c.ChatHub.BroadcastToRoom(c.RoomID, &legacy)
+			}
+		}
+
+		c.sendAck(msg.Type)
 	}
 }
 
 func (c *Client) WritePump() {
+	frameType := websocket.TextMessage
+	if protocol.CodecFor(c.Encoding).Binary() {
+		frameType = websocket.BinaryMessage
+	}
+
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
 		ticker.Stop()
@@ -100,12 +201,16 @@ func (c *Client) WritePump() {
 				return
 			}
 
-			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			if err := c.Conn.WriteMessage(frameType, message); err != nil {
 				log.Printf("發送訊息失敗給 %s: %v", c.PlayerName, err)
 				return
 			}
 
-			log.Printf("✅ 發送訊息給 %s: %s", c.PlayerName, string(message))
+			if frameType == websocket.TextMessage {
+				log.Printf("✅ 發送訊息給 %s: %s", c.PlayerName, string(message))
+			} else {
+				log.Printf("✅ 發送訊息給 %s: %d bytes (%s)", c.PlayerName, len(message), c.Encoding)
+			}
 
 		case <-ticker.C:
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
@@ -117,60 +222,132 @@ func (c *Client) WritePump() {
 }
 
 // 處理方法
-func (c *Client) handleChat(msg models.Message) {
-	var messageContent string
-	switch v := msg.Message.(type) {
-	case string:
-		messageContent = v
-	case map[string]interface{}:
-		if msgText, ok := v["text"].(string); ok {
-			messageContent = msgText
-		} else {
-			messageContent = fmt.Sprintf("%v", v)
+
+// 無法解析的訊框同樣計入限流與違規紀錄，回傳是否因多次違規應斷線
+func (c *Client) rejectMalformed(err error) bool {
+	reply, disconnect := c.ChatHub.allowMalformed(c)
+	if reply {
+		c.sendError(err)
+	}
+	if disconnect {
+		c.closeRateLimited()
+	}
+	return disconnect
+}
+
+// 多次超過限流，通知客戶端後由 ReadPump 中斷連線
+func (c *Client) closeRateLimited() {
+	log.Printf("玩家 %s 多次超過限流，中斷連線", c.PlayerName)
+	c.Conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, protocol.ErrRateLimited),
+		time.Now().Add(time.Second))
+}
+
+// 傳送錯誤訊息給送出請求的玩家，附上穩定的錯誤碼與請求的 requestId
+func (c *Client) sendError(err error) {
+	c.requestFailed = true
+	if c.disconnected {
+		log.Printf("玩家 %s 已斷線，略過錯誤訊息: %v", c.PlayerName, err)
+		return
+	}
+
+	payload := protocol.Error{Code: protocol.CodeOf(err), Message: err.Error()}
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) {
+		payload = *protocolErr
+	}
+	errorMsg := models.GameMessage{
+		Type:      protocol.EventError,
+		GameId:    c.RoomID,
+		Message:   payload.Message,
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Code:      payload.Code,
+		RequestId: c.requestId,
+		Payload:   payload,
+	}
+	if payload.RetryAfterMs > 0 {
+		errorMsg.GameInfo = map[string]interface{}{"retryAfterMs": payload.RetryAfterMs}
+	}
+	c.ChatHub.SendToClient(c, &errorMsg)
+}
+
+// 帶有 requestId 的請求處理成功時回覆 ack，未帶 requestId 的舊版客戶端不會收到
+func (c *Client) sendAck(requestType string) {
+	if c.requestId == "" || c.requestFailed || c.disconnected {
+		return
+	}
+	c.ChatHub.SendToClient(c, &models.GameMessage{
+		Type:      protocol.EventAck,
+		GameId:    c.RoomID,
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		RequestId: c.requestId,
+		Payload:   protocol.AckEvent{RequestType: requestType, LastSeq: c.ChatHub.LastSeq(c.RoomID)},
+	})
+}
+
+func (c *Client) handleChat(body json.RawMessage) {
+	req, err := protocol.DecodeChat(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	if strings.HasPrefix(req.Text, commandPrefix) {
+		if err := c.ChatHub.Commands.Execute(c, req.Text); err != nil {
+			c.sendError(err)
 		}
-	default:
-		messageContent = fmt.Sprintf("%v", v)
+		return
+	}
+
+	// 房間不存在時仍可聊天，只是無法檢查禁言
+	game, _ := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+	text, filtered, err := c.moderateChat(game, req.Text, models.ChatChannelRoom)
+	if err != nil {
+		c.sendError(err)
+		return
 	}
 
 	c.ChatHub.BroadcastGameMessage(c.RoomID, &models.GameMessage{
 		Type:       models.EventChat,
 		GameId:     c.RoomID,
-		Message:    messageContent,
+		Message:    text,
 		From:       c.PlayerName,
 		PlayerName: c.PlayerName,
 		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		Payload:    protocol.ChatEvent{From: c.PlayerName, Text: text, Filtered: filtered},
 	})
+	c.ChatHub.saveChat(c, text, filtered)
 }
 
-func (c *Client) handleAuthenticate(msg models.Message) {
-	log.Printf("玩家 %s 認證成功，加入遊戲 %s", msg.From, msg.GameId)
+func (c *Client) handleAuthenticate() {
+	log.Printf("玩家 %s 認證成功，加入遊戲 %s", c.PlayerName, c.RoomID)
 
 	authMsg := models.GameMessage{
 		Type:       models.EventAuthenticate,
-		GameId:     msg.GameId,
+		GameId:     c.RoomID,
 		Message:    "認證成功",
 		From:       "系統",
-		PlayerName: msg.From,
+		PlayerName: c.PlayerName,
 		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		Payload:    protocol.AuthEvent{PlayerName: c.PlayerName},
 	}
-	c.ChatHub.BroadcastGameMessage(msg.GameId, &authMsg)
+	c.ChatHub.BroadcastGameMessage(c.RoomID, &authMsg)
 }
 
 func (c *Client) handleJoinGame() {
 	err := c.ChatHub.GameManager.AddPlayer(c.RoomID, c.PlayerUuid, c.PlayerName)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   err.Error(),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(err)
 		return
 	}
 
-	gameState, _ := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+	gameState, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
 	joinMsg := models.GameMessage{
 		Type:        models.EventPlayerJoined,
 		GameId:      c.RoomID,
@@ -179,6 +356,7 @@ func (c *Client) handleJoinGame() {
 		PlayerName:  c.PlayerName,
 		PlayerCount: len(gameState.Players),
 		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+		Payload:     protocol.PresenceEvent{PlayerName: c.PlayerName, PlayerCount: len(gameState.Players)},
 	}
 	c.ChatHub.BroadcastGameMessage(c.RoomID, &joinMsg)
 }
@@ -186,48 +364,26 @@ func (c *Client) handleJoinGame() {
 func (c *Client) handleLeftGame() {
 	_, err := c.ChatHub.GameManager.PlayerLeave(c.RoomID, c.PlayerUuid)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   fmt.Sprintf("離開遊戲失敗: %s", err.Error()),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
-		return
-	}
-
-	gameState, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
-	if err != nil {
+		c.sendError(protocol.Wrap(err, "離開遊戲失敗"))
 		return
 	}
-
-	leftMsg := models.GameMessage{
-		Type:        models.EventPlayerLeft,
-		GameId:      c.RoomID,
-		Message:     fmt.Sprintf("玩家 %s 離開了遊戲", c.PlayerName),
-		From:        "系統",
-		PlayerName:  c.PlayerName,
-		PlayerCount: len(gameState.Players),
-		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
-	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &leftMsg)
+	c.broadcastPlayerLeft()
 }
 
 func (c *Client) handleStartGame() {
 	game, err := c.ChatHub.GameManager.StartGame(c.RoomID)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   err.Error(),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(err)
 		return
 	}
 
+	players, _, _ := playerList(game)
+	started := protocol.GameStartedEvent{
+		Mode:             game.Mode,
+		Players:          players,
+		Commitment:       game.Commitment,
+		CommitmentScheme: gamepkg.CommitmentScheme,
+	}
 	startMsg := models.GameMessage{
 		Type:      models.EventGameStarted,
 		GameId:    c.RoomID,
@@ -235,95 +391,115 @@ func (c *Client) handleStartGame() {
 		From:      "系統",
 		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
 		GameInfo: map[string]interface{}{
-			"Players": game.Players,
+			"Players":          game.Players,
+			"commitment":       game.Commitment,
+			"commitmentScheme": gamepkg.CommitmentScheme,
+			"mode":             game.Mode,
 		},
 	}
+	if game.Mode == models.ModeSimultaneous {
+		startMsg.Message = fmt.Sprintf("遊戲開始了！同時猜測模式，每回合 %d 秒內提交猜測", game.RoundSeconds)
+		startMsg.GameInfo["guessRound"] = game.GuessRound
+		startMsg.GameInfo["roundDeadline"] = game.RoundDeadline.Format(time.RFC3339)
+		started.GuessRound = game.GuessRound
+		started.RoundDeadline = game.RoundDeadline.Format(time.RFC3339)
+	}
+	startMsg.Payload = started
 	c.ChatHub.BroadcastGameMessage(c.RoomID, &startMsg)
+
+	if game.Mode == models.ModeSimultaneous {
+		c.ChatHub.scheduleRoundReveal(c.RoomID, game.GuessRound, game.RoundDeadline)
+	}
 }
 
-func (c *Client) handlePlayerGuess(msg models.Message) {
-	var guessNum int
-	var err error
-
-	switch v := msg.Message.(type) {
-	case string:
-		guessNum, err = strconv.Atoi(v)
-	case float64:
-		guessNum = int(v)
-	case int:
-		guessNum = v
-	default:
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   "猜測格式錯誤",
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+func (c *Client) handlePlayerGuess(body json.RawMessage) {
+	req, err := protocol.DecodeGuess(body)
+	if err != nil {
+		c.sendError(err)
 		return
 	}
+	guessNum := req.Guess
 
-	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   "請輸入有效的數字",
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+	if current, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID); err == nil && current.Mode == models.ModeSimultaneous {
+		c.handleSealedGuess(guessNum)
 		return
 	}
 
-	isCorrect, result, err := c.ChatHub.GameManager.GuessNumber(c.RoomID, c.PlayerUuid, guessNum)
+	result, err := c.ChatHub.GameManager.GuessNumber(c.RoomID, c.PlayerUuid, guessNum)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   err.Error(),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(err)
 		return
 	}
 
 	game, _ := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
 
 	var eventType string
-	if isCorrect {
+	if result.Finished {
 		eventType = models.EventGameOver
 		// 儲存遊戲結果到 MySQL
-		go func() {
-
-			totalPlayers := len(game.Players)
-			err = c.ChatHub.MySQLService.GameResult(c.RoomID, &c.PlayerUuid, game.Round, guessNum, &totalPlayers)
-			if err != nil {
-				log.Printf("儲存遊戲結果到 MySQL 失敗: %v", err)
-			}
-			for _, player := range game.Players {
-				err = c.ChatHub.MySQLService.GamePlayer(c.RoomID, player.Uuid, game.Round, player.TurnOrder)
-				if err != nil {
-					log.Printf("儲存玩家參與結果到 MySQL 失敗: %v", err)
-				}
-			}
-
-		}()
-
+		c.ChatHub.persistGameResult(c.RoomID, game, result.WinnerUuid)
 	} else {
 		eventType = models.EventPlayerGuess
 	}
 
+	guess := protocol.GuessEvent{PlayerName: c.PlayerName, Guess: guessNum, Feedback: result.Feedback}
 	guessMsg := models.GameMessage{
 		Type:       eventType,
 		GameId:     c.RoomID,
-		Message:    fmt.Sprintf("玩家 %s 猜測 %d，結果：%s", c.PlayerName, guessNum, result),
+		Message:    fmt.Sprintf("玩家 %s 猜測 %d，結果：%s", c.PlayerName, guessNum, result.Message),
 		From:       "系統",
 		PlayerName: c.PlayerName,
 		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo:   map[string]interface{}{},
+		Payload:    guess,
+	}
+	if result.Feedback != nil {
+		// 結構化回饋，客戶端不需要解析中文句子
+		guessMsg.GameInfo["feedback"] = result.Feedback
+	}
+	if result.Finished {
+		// 揭露答案與鹽值，客戶端可比對開局時的承諾值
+		guessMsg.GameInfo["answer"] = game.Answer
+		guessMsg.GameInfo["salt"] = game.Salt
+		guessMsg.GameInfo["commitment"] = game.Commitment
+		guessMsg.GameInfo["round"] = game.Round
+		over := protocol.GameOverEvent{
+			WinnerName: c.PlayerName,
+			Answer:     game.Answer,
+			Salt:       game.Salt,
+			Commitment: game.Commitment,
+			Round:      game.Round,
+			LastGuess:  &guess,
+		}
+		if game.Mode == models.ModeTeam {
+			guessMsg.Message = fmt.Sprintf("玩家 %s 猜測 %d，結果：%s 第 %d 隊獲勝！", c.PlayerName, guessNum, result.Message, game.WinningTeam)
+			guessMsg.GameInfo["winningTeam"] = game.WinningTeam
+			over.WinningTeam = game.WinningTeam
+		}
+		if result.Elimination == nil {
+			// 淘汰模式的結束事件由 broadcastLastStanding 另外送出
+			guessMsg.Payload = over
+		}
+	}
+
+	if result.Elimination != nil {
+		// 先送出本次猜測，再公告淘汰結果，最後才是遊戲結束
+		finished := guessMsg.Type == models.EventGameOver
+		guessMsg.Type = models.EventPlayerGuess
+		c.ChatHub.BroadcastGameMessage(c.RoomID, &guessMsg)
+		c.ChatHub.broadcastElimination(c.RoomID, result.Elimination, len(game.ActivePlayers()))
+		if finished {
+			c.ChatHub.broadcastLastStanding(c.RoomID, game, result.WinnerUuid)
+			return
+		}
+	} else {
+		c.ChatHub.BroadcastGameMessage(c.RoomID, &guessMsg)
+	}
+
+	// 連猜的第一次猜測不換人
+	if result.ExtraGuess {
+		return
 	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &guessMsg)
 
 	var turnIndex int
 	for i, player := range game.Players {
@@ -335,31 +511,20 @@ func (c *Client) handlePlayerGuess(msg models.Message) {
 	if game.Status == "finished" {
 		return
 	}
-	turnMsg := models.GameMessage{
-		Type:      "player_turn",
-		GameId:    c.RoomID,
-		Message:   fmt.Sprintf("輪到 %s 猜測", game.Players[turnIndex].Name),
-		From:      "系統",
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		GameInfo: map[string]interface{}{
-			"CurrentTurn": game.CurrentTurn,
-		},
+	turn := protocol.TurnEvent{CurrentTurn: game.CurrentTurn, PlayerName: game.Players[turnIndex].Name}
+	if game.Mode == models.ModeTeam {
+		turn = protocol.TurnEvent{CurrentTurn: game.CurrentTurn, Team: game.CurrentTurn + 1}
+	} else if game.Mode == models.ModeElimination {
+		// 淘汰模式會跳過已淘汰的玩家
+		turn.PlayerName = game.GetCurrentPlayer().Name
 	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &turnMsg)
-
+	c.ChatHub.broadcastTurn(c.RoomID, turn)
 }
 
-func (c *Client) handleGameReady(msg models.Message) {
+func (c *Client) handleGameReady() {
 	game, err := c.ChatHub.GameManager.PlayerReady(c.RoomID, c.PlayerUuid)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   err.Error(),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(err)
 		return
 	}
 
@@ -371,8 +536,9 @@ func (c *Client) handleGameReady(msg models.Message) {
 		}
 	}
 
+	ready := readyPlayer != nil && readyPlayer.Ready
 	var statusMessage string
-	if readyPlayer != nil && readyPlayer.Ready {
+	if ready {
 		statusMessage = fmt.Sprintf("玩家 %s 已準備就緒", c.PlayerName)
 	} else {
 		statusMessage = fmt.Sprintf("玩家 %s 取消準備", c.PlayerName)
@@ -385,6 +551,7 @@ func (c *Client) handleGameReady(msg models.Message) {
 		From:       "系統",
 		PlayerName: c.PlayerName,
 		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		Payload:    protocol.PlayerReadyEvent{PlayerName: c.PlayerName, Ready: ready},
 	}
 	c.ChatHub.BroadcastGameMessage(c.RoomID, &readyMsg)
 }
@@ -398,87 +565,19 @@ func (c *Client) handleGameReset() {
 
 	game, err := gameManager.ResetGame(c.RoomID)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   fmt.Sprintf("重置遊戲失敗: %s", err.Error()),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(protocol.Wrap(err, "重置遊戲失敗"))
 		return
 	}
-
-	resetMsg := models.GameMessage{
-		Type:      models.EventGameReset,
-		GameId:    c.RoomID,
-		Message:   "遊戲已重置，請重新開始",
-		From:      "系統",
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &resetMsg)
-
-	players := make([]map[string]interface{}, 0)
-	readyCount := 0
-	for _, player := range game.Players {
-		players = append(players, map[string]interface{}{
-			"uuid":    player.Uuid,
-			"name":    player.Name,
-			"isReady": player.Ready,
-		})
-
-		if player.Ready {
-			readyCount++
-		}
-	}
-	// game, _ := gameManager.GetAGameStatus(c.RoomID)
-	roomStatusMsg := models.GameMessage{
-		Type:    "room_status_update",
-		GameId:  c.RoomID,
-		Message: fmt.Sprintf("房間狀態更新: %d/%d 玩家，%d/%d 已準備", len(game.Players), game.NumOfPeople, readyCount, len(game.Players)),
-		From:    "系統",
-		Players: players,
-		GameInfo: map[string]interface{}{
-			"maxPlayers":     game.NumOfPeople,
-			"currentPlayers": len(game.Players),
-			"gameStatus":     game.Status,
-			"minRange":       game.MinRange,
-			"maxRange":       game.MaxRange,
-		},
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &roomStatusMsg)
+	c.ChatHub.broadcastGameReset(c.RoomID, game)
 }
 
 func (c *Client) handleForceLeftGame() {
 	_, err := c.ChatHub.GameManager.PlayerForceLeave(c.RoomID, c.PlayerUuid)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   fmt.Sprintf("離開遊戲失敗: %s", err.Error()),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(protocol.Wrap(err, "離開遊戲失敗"))
 		return
 	}
-
-	gameState, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
-	if err != nil {
-		return
-	}
-
-	leftMsg := models.GameMessage{
-		Type:        models.EventPlayerLeft,
-		GameId:      c.RoomID,
-		Message:     fmt.Sprintf("玩家 %s 離開了遊戲", c.PlayerName),
-		From:        "系統",
-		PlayerName:  c.PlayerName,
-		PlayerCount: len(gameState.Players),
-		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
-	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &leftMsg)
+	c.broadcastPlayerLeft()
 }
 
 func (c *Client) handleForceGameReset() {
@@ -490,54 +589,28 @@ func (c *Client) handleForceGameReset() {
 
 	game, err := gameManager.ForceGameReset(c.RoomID)
 	if err != nil {
-		errorMsg := models.GameMessage{
-			Type:      "error",
-			GameId:    c.RoomID,
-			Message:   fmt.Sprintf("重置遊戲失敗: %s", err.Error()),
-			From:      "系統",
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-		}
-		c.ChatHub.BroadcastGameMessage(c.RoomID, &errorMsg)
+		c.sendError(protocol.Wrap(err, "重置遊戲失敗"))
 		return
 	}
+	c.ChatHub.broadcastGameReset(c.RoomID, game)
+}
 
-	resetMsg := models.GameMessage{
-		Type:      models.EventGameReset,
-		GameId:    c.RoomID,
-		Message:   "遊戲已重置，請重新開始",
-		From:      "系統",
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+// 公告玩家離開遊戲
+func (c *Client) broadcastPlayerLeft() {
+	gameState, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+	if err != nil {
+		return
 	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &resetMsg)
 
-	players := make([]map[string]interface{}, 0)
-	readyCount := 0
-	for _, player := range game.Players {
-		players = append(players, map[string]interface{}{
-			"uuid":    player.Uuid,
-			"name":    player.Name,
-			"isReady": player.Ready,
-		})
-
-		if player.Ready {
-			readyCount++
-		}
-	}
-	// game, _ := gameManager.GetAGameStatus(c.RoomID)
-	roomStatusMsg := models.GameMessage{
-		Type:    "room_status_update",
-		GameId:  c.RoomID,
-		Message: fmt.Sprintf("房間狀態更新: %d/%d 玩家，%d/%d 已準備", len(game.Players), game.NumOfPeople, readyCount, len(game.Players)),
-		From:    "系統",
-		Players: players,
-		GameInfo: map[string]interface{}{
-			"maxPlayers":     game.NumOfPeople,
-			"currentPlayers": len(game.Players),
-			"gameStatus":     game.Status,
-			"minRange":       game.MinRange,
-			"maxRange":       game.MaxRange,
-		},
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	leftMsg := models.GameMessage{
+		Type:        models.EventPlayerLeft,
+		GameId:      c.RoomID,
+		Message:     fmt.Sprintf("玩家 %s 離開了遊戲", c.PlayerName),
+		From:        "系統",
+		PlayerName:  c.PlayerName,
+		PlayerCount: len(gameState.Players),
+		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+		Payload:     protocol.PresenceEvent{PlayerName: c.PlayerName, PlayerCount: len(gameState.Players)},
 	}
-	c.ChatHub.BroadcastGameMessage(c.RoomID, &roomStatusMsg)
+	c.ChatHub.BroadcastGameMessage(c.RoomID, &leftMsg)
 }
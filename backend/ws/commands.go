@@ -0,0 +1,227 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 聊天訊息以此開頭時視為指令
+const commandPrefix = "/"
+
+// ArgRange 參數的最大寬度（to-from），避免計算亂數時溢位
+const maxRangeWidth = 1_000_000_000
+
+// CommandPermission 誰可以執行指令
+type CommandPermission int
+
+const (
+	PermissionEveryone CommandPermission = iota
+	PermissionHost                       // 只有房主
+)
+
+// ArgKind 指令參數的種類
+type ArgKind int
+
+const (
+	ArgWord  ArgKind = iota // 單一字詞
+	ArgInt                  // 整數
+	ArgRange                // 範圍，例如 1-100
+	ArgText                 // 剩下的所有文字，只能是最後一個參數
+)
+
+// CommandArg 指令參數的定義
+type CommandArg struct {
+	Name     string
+	Kind     ArgKind
+	Optional bool
+}
+
+// Command 聊天指令；新增指令只需註冊到 CommandRegistry，不必修改 ReadPump
+type Command struct {
+	Name        string
+	Description string
+	Permission  CommandPermission
+	Args        []CommandArg
+	Event       string // 與此 WebSocket 事件共用限流規則，例如 /ready 與 player_ready
+	Run         func(ctx *CommandContext) error
+}
+
+// Usage 指令用法，例如 /roll [範圍]
+func (cmd *Command) Usage() string {
+	usage := commandPrefix + cmd.Name
+	for _, arg := range cmd.Args {
+		if arg.Optional {
+			usage += " [" + arg.Name + "]"
+		} else {
+			usage += " <" + arg.Name + ">"
+		}
+	}
+	return usage
+}
+
+// CommandContext 執行指令時的資訊與回覆方式
+type CommandContext struct {
+	Client  *Client
+	Game    *models.Game // 房間不存在時為 nil
+	Command *Command
+	args    map[string]interface{}
+}
+
+// Has 選填參數是否有提供
+func (ctx *CommandContext) Has(name string) bool {
+	_, ok := ctx.args[name]
+	return ok
+}
+
+func (ctx *CommandContext) String(name string) string {
+	value, _ := ctx.args[name].(string)
+	return value
+}
+
+func (ctx *CommandContext) Int(name string) int {
+	value, _ := ctx.args[name].(int)
+	return value
+}
+
+func (ctx *CommandContext) Range(name string) (int, int) {
+	value, _ := ctx.args[name].([2]int)
+	return value[0], value[1]
+}
+
+// Reply 只回覆給執行指令的玩家
+func (ctx *CommandContext) Reply(format string, args ...interface{}) {
+	ctx.Client.ChatHub.SendToClient(ctx.Client, ctx.result(fmt.Sprintf(format, args...), false))
+}
+
+// Announce 公告給整個房間
+func (ctx *CommandContext) Announce(format string, args ...interface{}) {
+	ctx.Client.ChatHub.BroadcastGameMessage(ctx.Client.RoomID, ctx.result(fmt.Sprintf(format, args...), true))
+}
+
+func (ctx *CommandContext) result(text string, public bool) *models.GameMessage {
+	return &models.GameMessage{
+		Type:       protocol.EventCommand,
+		GameId:     ctx.Client.RoomID,
+		Message:    text,
+		From:       "系統",
+		PlayerName: ctx.Client.PlayerName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"command": ctx.Command.Name,
+			"public":  public,
+		},
+		Payload: protocol.CommandResultEvent{
+			Command: ctx.Command.Name,
+			From:    ctx.Client.PlayerName,
+			Text:    text,
+			Public:  public,
+		},
+	}
+}
+
+// CommandRegistry 已註冊的聊天指令
+type CommandRegistry struct {
+	commands map[string]*Command
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{commands: make(map[string]*Command)}
+}
+
+// Register 註冊指令，同名的指令會被覆蓋
+func (r *CommandRegistry) Register(cmd *Command) {
+	if _, exists := r.commands[cmd.Name]; exists {
+		log.Printf("聊天指令 /%s 重複註冊，使用新的定義", cmd.Name)
+	}
+	r.commands[cmd.Name] = cmd
+}
+
+// Commands 依名稱排序的所有指令
+func (r *CommandRegistry) Commands() []*Command {
+	commands := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// Execute 解析並執行一則以 / 開頭的聊天訊息
+func (r *CommandRegistry) Execute(client *Client, text string) error {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(text), commandPrefix), " ")
+	cmd, ok := r.commands[strings.ToLower(name)]
+	if !ok {
+		return protocol.Errorf(protocol.ErrUnknownCommand, "未知的指令 /%s，輸入 /help 查看可用指令", name)
+	}
+	if cmd.Event != "" {
+		if allowed, retryAfter, _ := client.ChatHub.allowEvent(client, cmd.Event); !allowed {
+			return protocol.RateLimited(cmd.Event, retryAfter)
+		}
+	}
+
+	game, _ := client.ChatHub.GameManager.GetAGameStatus(client.RoomID)
+	if cmd.Permission == PermissionHost && (game == nil || !game.IsHost(client.PlayerUuid)) {
+		return protocol.Errorf(protocol.ErrNotHost, "只有房主可以使用 /%s", cmd.Name)
+	}
+
+	args, err := parseArgs(cmd, rest)
+	if err != nil {
+		return err
+	}
+	return cmd.Run(&CommandContext{Client: client, Game: game, Command: cmd, args: args})
+}
+
+// 依指令定義解析參數，參數以空白分隔
+func parseArgs(cmd *Command, rest string) (map[string]interface{}, error) {
+	usageErr := protocol.Errorf(protocol.ErrInvalidPayload, "用法：%s", cmd.Usage())
+	args := make(map[string]interface{})
+	for _, arg := range cmd.Args {
+		rest = strings.TrimSpace(rest)
+		var token string
+		if arg.Kind == ArgText {
+			token, rest = rest, ""
+		} else {
+			token, rest, _ = strings.Cut(rest, " ")
+		}
+		if token == "" {
+			if !arg.Optional {
+				return nil, usageErr
+			}
+			continue
+		}
+
+		switch arg.Kind {
+		case ArgInt:
+			value, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, usageErr
+			}
+			args[arg.Name] = value
+		case ArgRange:
+			low, high, ok := strings.Cut(token, "-")
+			from, err1 := strconv.Atoi(low)
+			to, err2 := strconv.Atoi(high)
+			if !ok || err1 != nil || err2 != nil || from > to {
+				return nil, usageErr
+			}
+			// from <= to 時以無號數相減不會溢位
+			if uint64(to)-uint64(from) > maxRangeWidth {
+				return nil, protocol.Errorf(protocol.ErrInvalidPayload, "範圍不可超過 %d", maxRangeWidth)
+			}
+			args[arg.Name] = [2]int{from, to}
+		default:
+			args[arg.Name] = token
+		}
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, usageErr
+	}
+	return args, nil
+}
@@ -0,0 +1,205 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"game/models"
+	"game/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// 內建的聊天指令
+func defaultCommands() *CommandRegistry {
+	registry := NewCommandRegistry()
+	registry.Register(&Command{
+		Name:        "help",
+		Description: "列出可用的指令",
+		Run:         runHelp,
+	})
+	registry.Register(&Command{
+		Name:        "roll",
+		Description: "擲骰子，預設 1-100",
+		Args:        []CommandArg{{Name: "範圍", Kind: ArgRange, Optional: true}},
+		Run:         runRoll,
+	})
+	registry.Register(&Command{
+		Name:        "ready",
+		Description: "準備或取消準備",
+		Event:       models.EventPlayerReady,
+		Run: func(ctx *CommandContext) error {
+			ctx.Client.handleGameReady()
+			return nil
+		},
+	})
+	registry.Register(&Command{
+		Name:        "kick",
+		Description: "將玩家踢出房間",
+		Permission:  PermissionHost,
+		Args:        []CommandArg{{Name: "名稱", Kind: ArgWord}},
+		Run:         runKick,
+	})
+	registry.Register(&Command{
+		Name:        "hint",
+		Description: "依目前的猜測紀錄提示答案範圍",
+		Run:         runHint,
+	})
+	registry.Register(&Command{
+		Name:        "stats",
+		Description: "查詢玩家的生涯統計，預設為自己",
+		Args:        []CommandArg{{Name: "名稱", Kind: ArgWord, Optional: true}},
+		Run:         runStats,
+	})
+	registry.Register(&Command{
+		Name:        "w",
+		Description: "對房間內的玩家說悄悄話",
+		Args:        []CommandArg{{Name: "名稱", Kind: ArgWord}, {Name: "訊息", Kind: ArgText}},
+		Run: func(ctx *CommandContext) error {
+			return ctx.Client.whisper(ctx.String("名稱"), ctx.String("訊息"))
+		},
+	})
+	return registry
+}
+
+func runHelp(ctx *CommandContext) error {
+	isHost := ctx.Game != nil && ctx.Game.IsHost(ctx.Client.PlayerUuid)
+	lines := []string{"可用的指令："}
+	for _, cmd := range ctx.Client.ChatHub.Commands.Commands() {
+		if cmd.Permission == PermissionHost && !isHost {
+			continue
+		}
+		line := fmt.Sprintf("%s：%s", cmd.Usage(), cmd.Description)
+		if cmd.Permission == PermissionHost {
+			line += "（房主）"
+		}
+		lines = append(lines, line)
+	}
+	ctx.Reply("%s", strings.Join(lines, "\n"))
+	return nil
+}
+
+func runRoll(ctx *CommandContext) error {
+	from, to := 1, 100
+	if ctx.Has("範圍") {
+		from, to = ctx.Range("範圍")
+	}
+	ctx.Announce("%s 擲骰（%d-%d）：%d", ctx.Client.PlayerName, from, to, roll(from, to))
+	return nil
+}
+
+// 在 from 到 to（含）之間取亂數，寬度已由 parseArgs 限制在 maxRangeWidth 以內
+func roll(from, to int) int {
+	return from + int(rand.Int64N(int64(to-from)+1))
+}
+
+// 房主踢人：只能在遊戲開始前或結束後，避免打亂進行中的回合
+func runKick(ctx *CommandContext) error {
+	name := ctx.String("名稱")
+	if ctx.Game.Status == "playing" {
+		return protocol.Errorf(protocol.ErrInvalidState, "遊戲進行中無法踢出玩家")
+	}
+	var target *models.Player
+	for i := range ctx.Game.Players {
+		if ctx.Game.Players[i].Name == name {
+			target = &ctx.Game.Players[i]
+			break
+		}
+	}
+	if target == nil {
+		return protocol.Errorf(protocol.ErrNotInGame, "房間內沒有玩家 %s", name)
+	}
+	if target.Uuid == ctx.Client.PlayerUuid {
+		return protocol.Errorf(protocol.ErrInvalidPayload, "不能踢出自己")
+	}
+
+	client := ctx.Client
+	game, err := client.ChatHub.GameManager.PlayerForceLeave(client.RoomID, target.Uuid)
+	if err != nil {
+		return err
+	}
+	log.Printf("房主 %s 將 %s 踢出房間 %s", client.PlayerName, target.Name, client.RoomID)
+
+	client.ChatHub.BroadcastGameMessage(client.RoomID, &models.GameMessage{
+		Type:        models.EventPlayerKicked,
+		GameId:      client.RoomID,
+		Message:     fmt.Sprintf("玩家 %s 被房主踢出房間", target.Name),
+		From:        "系統",
+		PlayerName:  target.Name,
+		PlayerCount: len(game.Players),
+		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+		Payload:     protocol.PresenceEvent{PlayerName: target.Name, PlayerCount: len(game.Players)},
+	})
+	client.ChatHub.disconnectPlayer(client.RoomID, target.Uuid)
+	client.ChatHub.BroadcastRoomStatus(client.RoomID)
+	return nil
+}
+
+// 中斷玩家在房間內的所有連線，先讓通知送達再關閉
+func (h *ChatHub) disconnectPlayer(roomID string, uuid string) {
+	for _, client := range h.clientsOf(uuid) {
+		if client.RoomID != roomID {
+			continue
+		}
+		client.kicked.Store(true)
+		time.AfterFunc(500*time.Millisecond, func() {
+			client.Conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "kicked"),
+				time.Now().Add(time.Second))
+			client.Conn.Close()
+		})
+	}
+}
+
+// 只依已公開的猜測回饋推算範圍，不會洩漏答案
+func runHint(ctx *CommandContext) error {
+	game := ctx.Game
+	if game == nil || game.Status != "playing" {
+		return protocol.Errorf(protocol.ErrInvalidState, "遊戲尚未開始")
+	}
+	low, high := game.MinRange, game.MaxRange
+	count := 0
+	for _, record := range game.GuessHistory {
+		if record.Rotation != game.Rotation || record.Feedback == nil {
+			continue
+		}
+		count++
+		switch record.Feedback.Direction {
+		case models.DirectionTooSmall:
+			if record.Guess+1 > low {
+				low = record.Guess + 1
+			}
+		case models.DirectionTooBig:
+			if record.Guess-1 < high {
+				high = record.Guess - 1
+			}
+		}
+	}
+	if game.FeedbackMode != "" && game.FeedbackMode != models.FeedbackDirection {
+		ctx.Reply("此房間的回饋模式無法推算範圍，答案介於 %d ~ %d，本題已猜 %d 次", game.MinRange, game.MaxRange, count)
+		return nil
+	}
+	ctx.Reply("依目前的猜測紀錄，答案介於 %d ~ %d（本題已猜 %d 次）", low, high, count)
+	return nil
+}
+
+func runStats(ctx *CommandContext) error {
+	name := ctx.Client.PlayerName
+	if ctx.Has("名稱") {
+		name = ctx.String("名稱")
+	}
+	stats, err := ctx.Client.ChatHub.MySQLService.PlayerStats(name)
+	if err != nil {
+		return err
+	}
+	rate := 0.0
+	if stats.GamesPlayed > 0 {
+		rate = float64(stats.Wins) * 100 / float64(stats.GamesPlayed)
+	}
+	ctx.Reply("%s：共 %d 局，勝 %d 局（勝率 %.1f%%），累計猜測 %d 次",
+		stats.Username, stats.GamesPlayed, stats.Wins, rate, stats.TotalGuesses)
+	return nil
+}
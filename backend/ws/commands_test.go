@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	roll := &Command{Name: "roll", Args: []CommandArg{{Name: "範圍", Kind: ArgRange, Optional: true}}}
+	whisper := &Command{Name: "w", Args: []CommandArg{{Name: "名稱", Kind: ArgWord}, {Name: "訊息", Kind: ArgText}}}
+	mute := &Command{Name: "mute", Args: []CommandArg{{Name: "分鐘", Kind: ArgInt}}}
+
+	tests := []struct {
+		name    string
+		cmd     *Command
+		rest    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"選填參數省略", roll, "", map[string]interface{}{}, false},
+		{"範圍", roll, "1-6", map[string]interface{}{"範圍": [2]int{1, 6}}, false},
+		{"前後空白", roll, "  10-20 ", map[string]interface{}{"範圍": [2]int{10, 20}}, false},
+		{"單一數字", roll, "5-5", map[string]interface{}{"範圍": [2]int{5, 5}}, false},
+		{"寬度剛好在上限", roll, "0-1000000000", map[string]interface{}{"範圍": [2]int{0, maxRangeWidth}}, false},
+		{"寬度超過上限", roll, "0-1000000001", nil, true},
+		{"int64 上限不會溢位", roll, "0-9223372036854775807", nil, true},
+		{"超過 int 範圍", roll, "0-9223372036854775808", nil, true},
+		{"起點大於終點", roll, "6-1", nil, true},
+		{"缺少分隔", roll, "100", nil, true},
+		{"不是數字", roll, "a-b", nil, true},
+		{"多餘的參數", roll, "1-6 7", nil, true},
+		{"剩下的文字都屬於最後一個參數", whisper, "bob 你好 嗎", map[string]interface{}{"名稱": "bob", "訊息": "你好 嗎"}, false},
+		{"缺少必填參數", whisper, "bob", nil, true},
+		{"整數", mute, "5", map[string]interface{}{"分鐘": 5}, false},
+		{"整數格式錯誤", mute, "五", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseArgs(tt.cmd, tt.rest)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseArgs(%q) 錯誤 = %v，預期錯誤 %v", tt.name, tt.rest, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseArgs(%q) = %v，預期 %v", tt.name, tt.rest, got, tt.want)
+		}
+	}
+}
+
+func TestRollBounds(t *testing.T) {
+	tests := []struct {
+		from, to int
+	}{
+		{1, 100},
+		{5, 5},
+		{0, 1},
+		{0, maxRangeWidth},
+		{maxRangeWidth, 2 * maxRangeWidth},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			if got := roll(tt.from, tt.to); got < tt.from || got > tt.to {
+				t.Fatalf("roll(%d, %d) = %d，超出範圍", tt.from, tt.to, got)
+			}
+		}
+	}
+
+	// 小範圍內每個值都應該出現
+	seen := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		seen[roll(1, 3)] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("roll(1, 3) 只出現 %v", seen)
+	}
+}
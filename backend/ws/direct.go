@@ -0,0 +1,174 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 收件人不在線上時暫存私訊
+type DirectMessageQueue interface {
+	QueueDirectMessage(message models.DirectMessage) error
+}
+
+// 依玩家 UUID 索引所有連線（可能同時在多個房間）；目前為單機部署，跨節點需改用 Redis Pub/Sub 轉送
+func (h *ChatHub) indexUser(client *Client) {
+	h.userMu.Lock()
+	defer h.userMu.Unlock()
+	if h.users[client.PlayerUuid] == nil {
+		h.users[client.PlayerUuid] = make(map[*Client]bool)
+	}
+	h.users[client.PlayerUuid][client] = true
+}
+
+func (h *ChatHub) unindexUser(client *Client) {
+	h.userMu.Lock()
+	defer h.userMu.Unlock()
+	delete(h.users[client.PlayerUuid], client)
+	if len(h.users[client.PlayerUuid]) == 0 {
+		delete(h.users, client.PlayerUuid)
+	}
+}
+
+// 玩家目前所有的連線
+func (h *ChatHub) clientsOf(uuid string) []*Client {
+	h.userMu.RLock()
+	defer h.userMu.RUnlock()
+	clients := make([]*Client, 0, len(h.users[uuid]))
+	for client := range h.users[uuid] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// 房間內的悄悄話，只有對方與自己收得到；由 /w 指令呼叫
+func (c *Client) whisper(name string, content string) error {
+	var targets []*Client
+	for _, client := range c.ChatHub.roomClients(c.RoomID) {
+		if client.PlayerName == name {
+			targets = append(targets, client)
+		}
+	}
+	if len(targets) == 0 {
+		return protocol.Errorf(protocol.ErrNotInGame, "房間內沒有玩家 %s", name)
+	}
+	if targets[0].PlayerUuid == c.PlayerUuid {
+		return protocol.Errorf(protocol.ErrInvalidPayload, "不能對自己說悄悄話")
+	}
+
+	game, _ := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+	content, filtered, err := c.moderateChat(game, content, models.ChatChannelWhisper)
+	if err != nil {
+		return err
+	}
+
+	msg := &models.GameMessage{
+		Type:       models.EventWhisper,
+		GameId:     c.RoomID,
+		Message:    content,
+		From:       c.PlayerName,
+		PlayerName: name,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"fromUuid": c.PlayerUuid,
+			"toUuid":   targets[0].PlayerUuid,
+		},
+		Payload: protocol.PrivateMessageEvent{
+			FromUuid: c.PlayerUuid,
+			From:     c.PlayerName,
+			ToUuid:   targets[0].PlayerUuid,
+			To:       name,
+			Text:     content,
+			Filtered: filtered,
+		},
+	}
+	for _, target := range append(targets, c) {
+		c.ChatHub.SendToClient(target, msg)
+	}
+	return nil
+}
+
+// 跨房間私訊：送到對方所有的連線，不在線上時存入收件匣
+func (c *Client) handleDirectMessage(body json.RawMessage) {
+	req, err := protocol.DecodeDirectMessage(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	if req.To == c.PlayerUuid {
+		c.sendError(protocol.Errorf(protocol.ErrInvalidPayload, "不能私訊自己"))
+		return
+	}
+
+	text, filtered := strings.TrimSpace(req.Text), false
+	if c.ChatHub.Moderator != nil {
+		if text, filtered, err = c.ChatHub.Moderator.Moderate(text); err != nil {
+			c.sendError(err)
+			return
+		}
+	}
+
+	event := protocol.PrivateMessageEvent{
+		FromUuid: c.PlayerUuid,
+		From:     c.PlayerName,
+		ToUuid:   req.To,
+		Text:     text,
+		Filtered: filtered,
+	}
+	targets := c.ChatHub.clientsOf(req.To)
+	if len(targets) > 0 {
+		event.To = targets[0].PlayerName
+		for _, target := range targets {
+			c.ChatHub.SendToClient(target, directMessage(target.RoomID, event))
+		}
+	} else {
+		if c.ChatHub.DirectMessages == nil {
+			c.sendError(protocol.Errorf(protocol.ErrUserNotFound, "對方不在線上"))
+			return
+		}
+		err := c.ChatHub.DirectMessages.QueueDirectMessage(models.DirectMessage{
+			FromUuid: c.PlayerUuid,
+			FromName: c.PlayerName,
+			ToUuid:   req.To,
+			Text:     text,
+			Filtered: filtered,
+			SentAt:   time.Now(),
+		})
+		if err != nil {
+			log.Printf("暫存私訊給 %s 失敗: %v", req.To, err)
+			c.sendError(err)
+			return
+		}
+		event.Queued = true
+	}
+
+	// 寄件人的副本
+	c.ChatHub.SendToClient(c, directMessage(c.RoomID, event))
+}
+
+func directMessage(roomID string, event protocol.PrivateMessageEvent) *models.GameMessage {
+	message := fmt.Sprintf("%s 私訊：%s", event.From, event.Text)
+	if event.Queued {
+		message = "對方不在線上，私訊已存入收件匣"
+	}
+	return &models.GameMessage{
+		Type:       models.EventDirectMessage,
+		GameId:     roomID,
+		Message:    message,
+		From:       event.From,
+		PlayerName: event.To,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"fromUuid": event.FromUuid,
+			"toUuid":   event.ToUuid,
+			"text":     event.Text,
+			"queued":   event.Queued,
+		},
+		Payload: event,
+	}
+}
@@ -0,0 +1,70 @@
+package ws
+
+import (
+	"fmt"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 淘汰模式：公告本輪被淘汰的玩家，並揭露本輪答案與下一題的承諾值
+func (h *ChatHub) broadcastElimination(roomID string, elimination *models.Elimination, remaining int) {
+	message := fmt.Sprintf("第 %d 輪結束，答案是 %d，全員猜中沒有人被淘汰", elimination.Rotation, elimination.Answer)
+	if elimination.ShieldedUuid != "" {
+		message = fmt.Sprintf("第 %d 輪結束，答案是 %d，%s 猜 %d 離答案最遠，但護盾擋下了這次淘汰",
+			elimination.Rotation, elimination.Answer, elimination.Name, elimination.Guess)
+	} else if elimination.Uuid != "" {
+		message = fmt.Sprintf("第 %d 輪結束，答案是 %d，%s 猜 %d 離答案最遠，遭到淘汰！剩下 %d 位玩家",
+			elimination.Rotation, elimination.Answer, elimination.Name, elimination.Guess, remaining)
+	}
+
+	h.BroadcastGameMessage(roomID, &models.GameMessage{
+		Type:       models.EventPlayerEliminated,
+		GameId:     roomID,
+		Message:    message,
+		From:       "系統",
+		PlayerName: elimination.Name,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"elimination": elimination,
+			"remaining":   remaining,
+		},
+		Payload: protocol.EliminationEvent{Elimination: elimination, Remaining: remaining},
+	})
+}
+
+// 淘汰模式：最後存活的玩家獲勝
+func (h *ChatHub) broadcastLastStanding(roomID string, game *models.Game, winnerUuid string) {
+	winnerName := ""
+	for _, player := range game.Players {
+		if player.Uuid == winnerUuid {
+			winnerName = player.Name
+			break
+		}
+	}
+
+	h.BroadcastGameMessage(roomID, &models.GameMessage{
+		Type:       models.EventGameOver,
+		GameId:     roomID,
+		Message:    fmt.Sprintf("遊戲結束！%s 是最後存活的玩家", winnerName),
+		From:       "系統",
+		PlayerName: winnerName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"answer":     game.Answer,
+			"salt":       game.Salt,
+			"commitment": game.Commitment,
+			"round":      game.Round,
+			"rotation":   game.Rotation,
+		},
+		Payload: protocol.GameOverEvent{
+			WinnerName: winnerName,
+			Answer:     game.Answer,
+			Salt:       game.Salt,
+			Commitment: game.Commitment,
+			Round:      game.Round,
+			Rotation:   game.Rotation,
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package ws
+
+import (
+	"game/models"
+	"game/protocol"
+)
+
+// 同一則訊息依客戶端的協定版本與編碼各序列化一次，廣播時不必每個連線都重新序列化
+type encodedMessage struct {
+	msg   *models.GameMessage
+	cache map[encodingKey][]byte
+}
+
+type encodingKey struct {
+	version  int
+	encoding string
+}
+
+func newEncodedMessage(msg *models.GameMessage) *encodedMessage {
+	return &encodedMessage{msg: msg, cache: make(map[encodingKey][]byte)}
+}
+
+func (e *encodedMessage) forClient(client *Client) ([]byte, error) {
+	key := encodingKey{version: client.ProtocolVersion, encoding: client.Encoding}
+	if data, ok := e.cache[key]; ok {
+		return data, nil
+	}
+	data, err := protocol.Encode(e.msg, key.version, key.encoding)
+	if err != nil {
+		return nil, err
+	}
+	e.cache[key] = data
+	return data, nil
+}
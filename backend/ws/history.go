@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 將已廣播的聊天訊息（過濾後的文字）存入房間的聊天紀錄
+func (h *ChatHub) saveChat(client *Client, text string, filtered bool) {
+	if h.ChatHistory == nil {
+		return
+	}
+	err := h.ChatHistory.AppendChat(client.RoomID, models.ChatLine{
+		Uuid:     client.PlayerUuid,
+		Name:     client.PlayerName,
+		Text:     text,
+		Filtered: filtered,
+		SentAt:   time.Now(),
+	})
+	if err != nil {
+		log.Printf("儲存房間 %s 聊天紀錄失敗: %v", client.RoomID, err)
+	}
+}
+
+// 剛加入的玩家收到房間最近的聊天紀錄
+func (h *ChatHub) sendChatHistory(client *Client) {
+	if h.ChatHistory == nil {
+		return
+	}
+	lines, err := h.ChatHistory.ChatHistory(client.RoomID)
+	if err != nil {
+		log.Printf("讀取房間 %s 聊天紀錄失敗: %v", client.RoomID, err)
+		return
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	h.SendToClient(client, &models.GameMessage{
+		Type:      protocol.EventChatHistory,
+		GameId:    client.RoomID,
+		Message:   "最近的聊天紀錄",
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"messages": lines,
+		},
+		Payload: protocol.ChatHistoryEvent{Messages: lines},
+	})
+}
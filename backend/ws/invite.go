@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 邀請好友加入目前的房間：對方必須是好友且在大廳，邀請以一次性 token 送到對方的大廳連線
+func (c *Client) handleInvite(body json.RawMessage) {
+	req, err := protocol.DecodeInvite(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	if c.ChatHub.Friends == nil {
+		c.sendError(protocol.Errorf(protocol.ErrInternal, "好友功能未啟用"))
+		return
+	}
+	if req.To == c.PlayerUuid {
+		c.sendError(protocol.Errorf(protocol.ErrInvalidPayload, "不能邀請自己"))
+		return
+	}
+
+	friends, err := c.ChatHub.Friends.AreFriends(c.PlayerUuid, req.To)
+	if err != nil {
+		log.Printf("查詢好友關係失敗: %v", err)
+		c.sendError(protocol.Errorf(protocol.ErrInternal, "邀請送出失敗，請稍後再試"))
+		return
+	}
+	if !friends {
+		c.sendError(protocol.Errorf(protocol.ErrNotFriends, "只能邀請好友"))
+		return
+	}
+
+	c.ChatHub.userMu.RLock()
+	inLobby := len(c.ChatHub.lobby[req.To]) > 0
+	c.ChatHub.userMu.RUnlock()
+	if !inLobby {
+		c.sendError(protocol.Errorf(protocol.ErrUserOffline, "對方不在大廳"))
+		return
+	}
+
+	invite, err := c.ChatHub.Friends.CreateInvite(c.RoomID, c.PlayerUuid, c.PlayerName, req.To)
+	if err != nil {
+		log.Printf("建立遊戲邀請失敗: %v", err)
+		c.sendError(protocol.Errorf(protocol.ErrInternal, "邀請送出失敗，請稍後再試"))
+		return
+	}
+
+	event := protocol.InviteEvent{
+		Token:     invite.Token,
+		GameId:    invite.GameID,
+		FromUuid:  invite.FromUuid,
+		From:      invite.FromName,
+		ExpiresAt: invite.ExpiresAt.Format(time.RFC3339),
+	}
+	delivered := c.ChatHub.NotifyLobby(req.To, &models.GameMessage{
+		Type:       models.EventGameInvite,
+		GameId:     invite.GameID,
+		Message:    fmt.Sprintf("%s 邀請你加入遊戲", c.PlayerName),
+		From:       c.PlayerName,
+		PlayerName: c.PlayerName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"token":     event.Token,
+			"fromUuid":  event.FromUuid,
+			"expiresAt": event.ExpiresAt,
+		},
+		Payload: event,
+	})
+	if !delivered {
+		c.sendError(protocol.Errorf(protocol.ErrUserOffline, "對方不在大廳"))
+		return
+	}
+	// 大廳已收到 game_invite，通知中心只留存紀錄
+	if c.ChatHub.Notifier != nil {
+		c.ChatHub.Notifier.Record(req.To, models.NotifyGameInvite, "遊戲邀請", fmt.Sprintf("%s 邀請你加入遊戲", c.PlayerName), map[string]interface{}{
+			"token":     event.Token,
+			"gameId":    event.GameId,
+			"fromUuid":  event.FromUuid,
+			"from":      event.From,
+			"expiresAt": event.ExpiresAt,
+		})
+	}
+
+	// 邀請人只收到確認，不會拿到受邀者的 token
+	c.ChatHub.SendToClient(c, &models.GameMessage{
+		Type:      models.EventInviteSent,
+		GameId:    c.RoomID,
+		Message:   "邀請已送出",
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"to":        req.To,
+			"expiresAt": event.ExpiresAt,
+		},
+		Payload: protocol.InviteSentEvent{To: req.To, ExpiresAt: event.ExpiresAt},
+	})
+}
@@ -0,0 +1,72 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 使用道具：向房間公告使用紀錄，偷看的結果只傳給使用者
+func (c *Client) handleUseItem(body json.RawMessage) {
+	req, err := protocol.DecodeUseItem(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	item := req.Item
+
+	result, game, err := c.ChatHub.GameManager.UseItem(c.RoomID, c.PlayerUuid, item)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	log.Printf("玩家 %s 在房間 %s 使用道具 %s", c.PlayerName, c.RoomID, item)
+
+	remaining := 0
+	for _, player := range game.Players {
+		if player.Uuid == c.PlayerUuid {
+			remaining = player.Items[item]
+			break
+		}
+	}
+
+	c.ChatHub.BroadcastGameMessage(c.RoomID, &models.GameMessage{
+		Type:       models.EventItemUsed,
+		GameId:     c.RoomID,
+		Message:    result.Message,
+		From:       "系統",
+		PlayerName: c.PlayerName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"item":      item,
+			"remaining": remaining,
+		},
+		Payload: protocol.ItemUsedEvent{PlayerName: c.PlayerName, Item: item, Remaining: remaining},
+	})
+
+	if result.Private != "" {
+		c.ChatHub.SendToPlayers(c.RoomID, []string{c.PlayerUuid}, &models.GameMessage{
+			Type:      models.EventItemUsed,
+			GameId:    c.RoomID,
+			Message:   result.Private,
+			From:      "系統",
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			GameInfo: map[string]interface{}{
+				"item":    item,
+				"private": true,
+			},
+			Payload: protocol.ItemUsedEvent{PlayerName: c.PlayerName, Item: item, Remaining: remaining, Private: true, Result: result.Private},
+		})
+	}
+
+	if item == models.ItemSkip {
+		if game.Mode == models.ModeTeam {
+			c.ChatHub.broadcastTurn(c.RoomID, protocol.TurnEvent{CurrentTurn: game.CurrentTurn, Team: game.CurrentTurn + 1})
+		} else if current := game.GetCurrentPlayer(); current != nil {
+			c.ChatHub.broadcastTurn(c.RoomID, protocol.TurnEvent{CurrentTurn: game.CurrentTurn, PlayerName: current.Name})
+		}
+	}
+}
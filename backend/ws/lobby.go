@@ -0,0 +1,184 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// 好友與在線狀態
+type FriendService interface {
+	AreFriends(userA string, userB string) (bool, error)
+	SetPresence(uuid string, status string, gameID string) error
+	CreateInvite(gameID string, fromUuid string, fromName string, toUuid string) (*models.GameInvite, error)
+}
+
+// 在線狀態的更新間隔，需短於 Redis 中的過期時間
+const presenceRefreshInterval = time.Minute
+
+// LobbyClient 大廳連線：不在任何房間，只接收邀請等通知
+type LobbyClient struct {
+	ChatHub    *ChatHub
+	Send       chan []byte
+	PlayerUuid string
+	PlayerName string
+	SessionID  string
+	Conn       *websocket.Conn
+
+	ProtocolVersion int
+	Encoding        string
+}
+
+// JoinLobby 加入大廳並更新在線狀態
+func (h *ChatHub) JoinLobby(client *LobbyClient) {
+	h.userMu.Lock()
+	if h.lobby[client.PlayerUuid] == nil {
+		h.lobby[client.PlayerUuid] = make(map[*LobbyClient]bool)
+	}
+	h.lobby[client.PlayerUuid][client] = true
+	h.userMu.Unlock()
+
+	log.Printf("玩家 %s 進入大廳", client.PlayerName)
+	h.refreshPresence(client.PlayerUuid)
+}
+
+func (h *ChatHub) leaveLobby(client *LobbyClient) {
+	h.userMu.Lock()
+	if _, ok := h.lobby[client.PlayerUuid][client]; ok {
+		delete(h.lobby[client.PlayerUuid], client)
+		close(client.Send)
+	}
+	if len(h.lobby[client.PlayerUuid]) == 0 {
+		delete(h.lobby, client.PlayerUuid)
+	}
+	h.userMu.Unlock()
+
+	log.Printf("玩家 %s 離開大廳", client.PlayerName)
+	h.refreshPresence(client.PlayerUuid)
+}
+
+// NotifyLobby 傳送訊息到玩家所有的大廳連線，回傳是否至少送達一個連線
+func (h *ChatHub) NotifyLobby(uuid string, gameMsg *models.GameMessage) bool {
+	h.userMu.RLock()
+	defer h.userMu.RUnlock()
+
+	delivered := false
+	for client := range h.lobby[uuid] {
+		data, err := protocol.Encode(gameMsg, client.ProtocolVersion, client.Encoding)
+		if err != nil {
+			log.Printf("序列化訊息失敗: %v", err)
+			continue
+		}
+		select {
+		case client.Send <- data:
+			delivered = true
+		default:
+			log.Printf("玩家 %s 的大廳傳送佇列已滿，丟棄訊息 %s", client.PlayerName, gameMsg.Type)
+		}
+	}
+	return delivered
+}
+
+// 依目前的連線計算在線狀態：在房間中為 in_game，只有大廳連線為 online，都沒有則離線
+func (h *ChatHub) refreshPresence(uuid string) {
+	if h.Friends == nil || uuid == "" {
+		return
+	}
+	status, gameID := models.PresenceOffline, ""
+	h.userMu.RLock()
+	for client := range h.users[uuid] {
+		status, gameID = models.PresenceInGame, client.RoomID
+		break
+	}
+	if status == models.PresenceOffline && len(h.lobby[uuid]) > 0 {
+		status = models.PresenceOnline
+	}
+	h.userMu.RUnlock()
+
+	if err := h.Friends.SetPresence(uuid, status, gameID); err != nil {
+		log.Printf("更新玩家 %s 在線狀態失敗: %v", uuid, err)
+	}
+}
+
+// 定期更新所有在線玩家的狀態，避免 Redis 中的紀錄過期
+func (h *ChatHub) presenceLoop() {
+	ticker := time.NewTicker(presenceRefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.userMu.RLock()
+		uuids := make(map[string]bool, len(h.users)+len(h.lobby))
+		for uuid := range h.users {
+			uuids[uuid] = true
+		}
+		for uuid := range h.lobby {
+			uuids[uuid] = true
+		}
+		h.userMu.RUnlock()
+
+		for uuid := range uuids {
+			h.refreshPresence(uuid)
+		}
+	}
+}
+
+// ReadPump 大廳連線不處理客戶端訊息，只用來偵測斷線
+func (c *LobbyClient) ReadPump() {
+	defer func() {
+		c.ChatHub.leaveLobby(c)
+		c.Conn.Close()
+	}()
+
+	c.Conn.SetReadLimit(512)
+	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.Conn.SetPongHandler(func(string) error {
+		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	for {
+		if _, _, err := c.Conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("error: %v", err)
+			}
+			return
+		}
+	}
+}
+
+func (c *LobbyClient) WritePump() {
+	frameType := websocket.TextMessage
+	if protocol.CodecFor(c.Encoding).Binary() {
+		frameType = websocket.BinaryMessage
+	}
+
+	ticker := time.NewTicker(54 * time.Second)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if !ok {
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.Conn.WriteMessage(frameType, message); err != nil {
+				log.Printf("發送大廳訊息失敗給 %s: %v", c.PlayerName, err)
+				return
+			}
+
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
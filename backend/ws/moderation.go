@@ -0,0 +1,188 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 禁言時間：預設與上限（分鐘）
+const (
+	defaultMuteMinutes = 5
+	maxMuteMinutes     = 60
+)
+
+// 聊天送出前的審核：檢查禁言、長度與禁用詞，並依頻道記錄原文供檢舉使用
+func (c *Client) moderateChat(game *models.Game, text string, channel string) (string, bool, error) {
+	if game != nil {
+		if until, muted := game.MutedUntil(c.PlayerUuid); muted {
+			return "", false, protocol.Errorf(protocol.ErrMuted, "您已被房主禁言，%s 後解除", until.Format("15:04:05"))
+		}
+	}
+
+	cleaned, filtered := text, false
+	if c.ChatHub.Moderator != nil {
+		var err error
+		cleaned, filtered, err = c.ChatHub.Moderator.Moderate(text)
+		if err != nil {
+			return "", false, err
+		}
+	}
+	if cleaned == "" {
+		return "", false, protocol.Errorf(protocol.ErrInvalidPayload, "聊天訊息不可為空")
+	}
+
+	if room := c.ChatHub.room(c.RoomID); room != nil {
+		room.recordChat(models.ChatLine{
+			Uuid:     c.PlayerUuid,
+			Name:     c.PlayerName,
+			Text:     text,
+			Channel:  channel,
+			Filtered: filtered,
+			SentAt:   time.Now(),
+		})
+	}
+	return cleaned, filtered, nil
+}
+
+func (c *Client) handleMute(body json.RawMessage) {
+	req, err := protocol.DecodeMute(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	minutes := req.Minutes
+	if minutes == 0 {
+		minutes = defaultMuteMinutes
+	}
+	if minutes > maxMuteMinutes {
+		minutes = maxMuteMinutes
+	}
+
+	game, err := c.ChatHub.GameManager.MutePlayer(c.RoomID, c.PlayerUuid, req.Target, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	target := game.FindPlayer(req.Target)
+	until := game.Muted[req.Target].Format(time.RFC3339)
+	log.Printf("房主 %s 在房間 %s 禁言了 %s %d 分鐘", c.PlayerName, c.RoomID, target.Name, minutes)
+
+	c.ChatHub.BroadcastGameMessage(c.RoomID, &models.GameMessage{
+		Type:       models.EventPlayerMuted,
+		GameId:     c.RoomID,
+		Message:    fmt.Sprintf("%s 被房主禁言 %d 分鐘", target.Name, minutes),
+		From:       "系統",
+		PlayerName: target.Name,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"uuid":  target.Uuid,
+			"until": until,
+		},
+		Payload: protocol.MuteEvent{Uuid: target.Uuid, PlayerName: target.Name, Until: until},
+	})
+}
+
+func (c *Client) handleUnmute(body json.RawMessage) {
+	req, err := protocol.DecodeUnmute(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	game, err := c.ChatHub.GameManager.UnmutePlayer(c.RoomID, c.PlayerUuid, req.Target)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	name := req.Target
+	if target := game.FindPlayer(req.Target); target != nil {
+		name = target.Name
+	}
+	c.ChatHub.BroadcastGameMessage(c.RoomID, &models.GameMessage{
+		Type:       models.EventPlayerUnmuted,
+		GameId:     c.RoomID,
+		Message:    fmt.Sprintf("%s 已被解除禁言", name),
+		From:       "系統",
+		PlayerName: name,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"uuid": req.Target,
+		},
+		Payload: protocol.MuteEvent{Uuid: req.Target, PlayerName: name},
+	})
+}
+
+// 檢舉玩家：附上被檢舉者最近一則訊息與房間最近的聊天紀錄，存到 MySQL 供管理員審查
+func (c *Client) handleReport(body json.RawMessage) {
+	req, err := protocol.DecodeReport(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	if req.Target == c.PlayerUuid {
+		c.sendError(protocol.Errorf(protocol.ErrInvalidPayload, "不能檢舉自己"))
+		return
+	}
+
+	// 只附上房間公開的聊天，隊伍頻道與悄悄話不列入檢舉上下文
+	var recent []models.ChatLine
+	if room := c.ChatHub.room(c.RoomID); room != nil {
+		recent = room.RecentChat(models.ChatChannelRoom)
+	}
+
+	report := &models.ChatReports{
+		GameID:     c.RoomID,
+		ReporterID: c.PlayerUuid,
+		TargetID:   req.Target,
+		Reason:     req.Reason,
+		Context:    recent,
+	}
+	for i := len(recent) - 1; i >= 0; i-- {
+		if recent[i].Uuid == req.Target {
+			report.TargetName = recent[i].Name
+			report.Message = recent[i].Text
+			break
+		}
+	}
+	if report.TargetName == "" {
+		// 最近沒有發言，仍可檢舉房間內的玩家
+		game, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+		if err != nil {
+			c.sendError(err)
+			return
+		}
+		target := game.FindPlayer(req.Target)
+		if target == nil {
+			c.sendError(protocol.Errorf(protocol.ErrNotInGame, "該玩家不在房間內"))
+			return
+		}
+		report.TargetName = target.Name
+	}
+
+	if err := c.ChatHub.MySQLService.ChatReport(report); err != nil {
+		log.Printf("儲存檢舉失敗: %v", err)
+		c.sendError(protocol.Errorf(protocol.ErrInternal, "檢舉送出失敗，請稍後再試"))
+		return
+	}
+	log.Printf("玩家 %s 在房間 %s 檢舉了 %s", c.PlayerName, c.RoomID, report.TargetName)
+
+	c.ChatHub.SendToClient(c, &models.GameMessage{
+		Type:       models.EventReportReceived,
+		GameId:     c.RoomID,
+		Message:    fmt.Sprintf("已收到您對 %s 的檢舉，管理員將會審查", report.TargetName),
+		From:       "系統",
+		PlayerName: report.TargetName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"reportId": report.ID,
+		},
+		Payload: protocol.ReportReceivedEvent{ReportId: report.ID, TargetName: report.TargetName},
+	})
+}
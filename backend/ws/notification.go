@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 站內通知：Notify 建立後即時推送，Record 只建立（內容已用其他事件送達時使用）
+type Notifier interface {
+	Notify(userID string, kind string, title string, body string, data map[string]interface{}) error
+	Record(userID string, kind string, title string, body string, data map[string]interface{}) error
+}
+
+// PushNotification 將通知推送到玩家所有的房間與大廳連線，回傳玩家是否在線上
+func (h *ChatHub) PushNotification(uuid string, notification *models.Notifications) bool {
+	event := protocol.NotificationEvent{
+		Id:        notification.ID,
+		Kind:      notification.Type,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		Data:      notification.Data,
+		CreatedAt: notification.CreatedAt.Format(time.RFC3339),
+	}
+	msg := func(roomID string) *models.GameMessage {
+		return &models.GameMessage{
+			Type:      models.EventNotification,
+			GameId:    roomID,
+			Message:   notification.Title,
+			From:      "系統",
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			GameInfo: map[string]interface{}{
+				"id":   event.Id,
+				"kind": event.Kind,
+				"body": event.Body,
+				"data": event.Data,
+			},
+			Payload: event,
+		}
+	}
+
+	clients := h.clientsOf(uuid)
+	for _, client := range clients {
+		h.SendToClient(client, msg(client.RoomID))
+	}
+	delivered := h.NotifyLobby(uuid, msg(""))
+	return delivered || len(clients) > 0
+}
+
+// 通知功能未啟用時略過
+func (h *ChatHub) notify(userID string, kind string, title string, body string, data map[string]interface{}) {
+	if h.Notifier == nil || userID == "" {
+		return
+	}
+	h.Notifier.Notify(userID, kind, title, body, data)
+}
+
+// 通知玩家對戰結果，玩家可能已離開房間
+func (h *ChatHub) notifyMatchResult(roomID string, game *models.Game, player models.Player, won bool) {
+	title, body := "對戰落敗", fmt.Sprintf("房間 %s 第 %d 輪結束，答案是 %d", roomID, game.Round, game.Answer)
+	if won {
+		title = "對戰獲勝"
+		body = fmt.Sprintf("你贏得了房間 %s 第 %d 輪，共猜了 %d 次", roomID, game.Round, player.GuessCount)
+	}
+	h.notify(player.Uuid, models.NotifyMatchResult, title, body, map[string]interface{}{
+		"gameId":     roomID,
+		"round":      game.Round,
+		"mode":       game.Mode,
+		"won":        won,
+		"score":      player.Score,
+		"guessCount": player.GuessCount,
+	})
+}
+
+// 更新本輪玩家的積分並通知每位玩家
+func (h *ChatHub) updateRatings(roomID string, round int, players []string, winners map[string]bool) {
+	changes, err := h.MySQLService.UpdateRatings(players, winners)
+	if err != nil {
+		log.Printf("更新房間 %s 第 %d 輪的積分失敗: %v", roomID, round, err)
+		return
+	}
+	for uuid, change := range changes {
+		if change.Delta == 0 {
+			continue
+		}
+		h.notify(uuid, models.NotifyRatingChange, fmt.Sprintf("積分 %+d", change.Delta),
+			fmt.Sprintf("房間 %s 第 %d 輪結束，積分 %d → %d", roomID, round, change.Before, change.After),
+			map[string]interface{}{
+				"gameId": roomID,
+				"round":  round,
+				"before": change.Before,
+				"after":  change.After,
+				"delta":  change.Delta,
+			})
+	}
+}
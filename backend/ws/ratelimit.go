@@ -0,0 +1,265 @@
+package ws
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"game/config"
+)
+
+// 預設限流規則：聊天與準備切換較嚴格，其他事件共用寬鬆的預設值
+const (
+	defaultConnectionLimits = "chat=5/5s,team_chat=5/5s,player_ready=3/5s,choose_team=3/5s,player_guess=5/5s,use_item=3/5s,resync=2/10s,report=3/1m,direct_message=5/5s,invite=3/10s,*=10/1s"
+	defaultUserLimits       = "chat=8/5s,team_chat=8/5s,player_ready=5/5s,choose_team=5/5s,player_guess=8/5s,use_item=5/5s,resync=4/10s,report=5/1m,direct_message=8/5s,invite=5/10s,*=20/1s"
+	defaultMaxViolations    = 10
+	defaultViolationWindow  = 30 * time.Second
+)
+
+// 規則中代表其他事件的鍵
+const anyEvent = "*"
+
+// Rate 令牌桶：最多 Burst 個令牌，每 Per 補滿 Burst 個
+type Rate struct {
+	Burst float64
+	Per   time.Duration
+}
+
+// RateLimits 事件類型對應的限流規則
+type RateLimits map[string]Rate
+
+// ParseRateLimits 解析「事件=次數/時間」以逗號分隔的規則
+func ParseRateLimits(spec string) (RateLimits, error) {
+	limits := RateLimits{}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		eventType, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("限流規則格式錯誤: %s", rule)
+		}
+		count, period, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("限流規則格式錯誤: %s", rule)
+		}
+		burst, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("限流次數必須是正整數: %s", rule)
+		}
+		per, err := time.ParseDuration(strings.TrimSpace(period))
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("限流時間格式錯誤: %s", rule)
+		}
+		limits[strings.TrimSpace(eventType)] = Rate{Burst: float64(burst), Per: per}
+	}
+	return limits, nil
+}
+
+// 找出事件適用的規則與令牌桶的鍵，沒有任何規則時不限流
+func (l RateLimits) rateFor(eventType string) (string, Rate, bool) {
+	if rate, ok := l[eventType]; ok {
+		return eventType, rate, true
+	}
+	rate, ok := l[anyEvent]
+	return anyEvent, rate, ok
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// 依經過的時間補充令牌
+func (b *tokenBucket) refill(rate Rate, now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = rate.Burst
+	} else {
+		refill := now.Sub(b.last).Seconds() / rate.Per.Seconds() * rate.Burst
+		b.tokens = min(rate.Burst, b.tokens+refill)
+	}
+	b.last = now
+}
+
+// 還需等待多久才有一個令牌，已有令牌時為 0；呼叫前需先 refill
+func (b *tokenBucket) wait(rate Rate) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	wait := (1 - b.tokens) / rate.Burst * rate.Per.Seconds()
+	return max(time.Duration(wait*float64(time.Second)), time.Nanosecond)
+}
+
+// 取用一個令牌；不足時回傳還需等待的時間
+func (b *tokenBucket) take(rate Rate, now time.Time) (bool, time.Duration) {
+	b.refill(rate, now)
+	if wait := b.wait(rate); wait > 0 {
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// 同一位玩家所有連線共用的令牌桶，最後一個連線離開時移除
+type userBuckets struct {
+	connections int
+	buckets     map[string]*tokenBucket
+}
+
+// 單一連線的令牌桶與被限流的紀錄，只在該連線的 ReadPump 中使用
+type connectionLimiter struct {
+	buckets    map[string]*tokenBucket
+	violations []time.Time
+}
+
+func newConnectionLimiter() *connectionLimiter {
+	return &connectionLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// 取得鍵對應的令牌桶，不存在時建立
+func bucketFor(buckets map[string]*tokenBucket, key string) *tokenBucket {
+	bucket := buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{}
+		buckets[key] = bucket
+	}
+	return bucket
+}
+
+// RateLimiter WebSocket 事件限流，分別限制每個連線與每位玩家
+type RateLimiter struct {
+	connection      RateLimits
+	user            RateLimits
+	maxViolations   int
+	violationWindow time.Duration
+
+	mu    sync.Mutex
+	users map[string]*userBuckets
+}
+
+// NewRateLimiter 依設定建立限流器，未設定的項目使用預設值
+func NewRateLimiter(cfg config.RateLimit) (*RateLimiter, error) {
+	connectionSpec := cfg.Connection
+	if connectionSpec == "" {
+		connectionSpec = defaultConnectionLimits
+	}
+	userSpec := cfg.User
+	if userSpec == "" {
+		userSpec = defaultUserLimits
+	}
+	connection, err := ParseRateLimits(connectionSpec)
+	if err != nil {
+		return nil, err
+	}
+	user, err := ParseRateLimits(userSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := &RateLimiter{
+		connection:      connection,
+		user:            user,
+		maxViolations:   cfg.MaxViolations,
+		violationWindow: time.Duration(cfg.ViolationWindow) * time.Second,
+		users:           make(map[string]*userBuckets),
+	}
+	if limiter.maxViolations <= 0 {
+		limiter.maxViolations = defaultMaxViolations
+	}
+	if limiter.violationWindow <= 0 {
+		limiter.violationWindow = defaultViolationWindow
+	}
+	return limiter, nil
+}
+
+// DefaultRateLimiter 使用預設規則的限流器
+func DefaultRateLimiter() *RateLimiter {
+	limiter, _ := NewRateLimiter(config.RateLimit{})
+	return limiter
+}
+
+// 連線加入時登記，讓同一位玩家的連線共用令牌桶
+func (l *RateLimiter) attach(client *Client) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	user, ok := l.users[client.PlayerUuid]
+	if !ok {
+		user = &userBuckets{buckets: make(map[string]*tokenBucket)}
+		l.users[client.PlayerUuid] = user
+	}
+	user.connections++
+}
+
+func (l *RateLimiter) detach(client *Client) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if user, ok := l.users[client.PlayerUuid]; ok {
+		user.connections--
+		if user.connections <= 0 {
+			delete(l.users, client.PlayerUuid)
+		}
+	}
+}
+
+// allow 檢查事件是否超過限制；被限流時回傳建議的等待時間，以及是否因多次違規應斷線。
+// 連線與玩家的令牌桶都有令牌時才一起扣除，任一邊不足時兩邊都不扣
+func (l *RateLimiter) allow(client *Client, eventType string) (bool, time.Duration, bool) {
+	now := time.Now()
+
+	var connectionBucket, userBucket *tokenBucket
+	var connectionRate, userRate Rate
+	wait := time.Duration(0)
+
+	l.mu.Lock()
+	if key, rate, ok := l.connection.rateFor(eventType); ok {
+		connectionBucket, connectionRate = bucketFor(client.limiter.buckets, key), rate
+		connectionBucket.refill(rate, now)
+		wait = max(wait, connectionBucket.wait(rate))
+	}
+	if key, rate, ok := l.user.rateFor(eventType); ok {
+		if user, exists := l.users[client.PlayerUuid]; exists {
+			userBucket, userRate = bucketFor(user.buckets, key), rate
+			userBucket.refill(rate, now)
+			wait = max(wait, userBucket.wait(rate))
+		}
+	}
+	if wait == 0 {
+		if connectionBucket != nil {
+			connectionBucket.take(connectionRate, now)
+		}
+		if userBucket != nil {
+			userBucket.take(userRate, now)
+		}
+	}
+	l.mu.Unlock()
+	if wait == 0 {
+		return true, 0, false
+	}
+	return false, wait, l.recordViolation(client, now)
+}
+
+// allowMalformed 無法解析的訊框扣除 * 的令牌，並且一律記為一次違規。
+// 回傳是否還能回覆錯誤（被限流時不回覆，避免被用來放大流量），以及是否應斷線
+func (l *RateLimiter) allowMalformed(client *Client) (bool, bool) {
+	allowed, _, disconnect := l.allow(client, anyEvent)
+	if !allowed {
+		return false, disconnect
+	}
+	return true, l.recordViolation(client, time.Now())
+}
+
+// 記錄一次違規，回傳時間窗內的違規次數是否已達斷線門檻
+func (l *RateLimiter) recordViolation(client *Client, now time.Time) bool {
+	// 只保留時間窗內的違規紀錄
+	violations := client.limiter.violations[:0]
+	for _, at := range client.limiter.violations {
+		if now.Sub(at) < l.violationWindow {
+			violations = append(violations, at)
+		}
+	}
+	client.limiter.violations = append(violations, now)
+	return len(client.limiter.violations) >= l.maxViolations
+}
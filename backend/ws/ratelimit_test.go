@@ -0,0 +1,148 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"game/config"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	rate := Rate{Burst: 2, Per: 2 * time.Second}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := &tokenBucket{}
+
+	tests := []struct {
+		name    string
+		at      time.Duration
+		allowed bool
+		wait    time.Duration
+	}{
+		{"第一次取用時令牌是滿的", 0, true, 0},
+		{"用掉第二個令牌", 0, true, 0},
+		{"令牌用完", 0, false, time.Second},
+		{"半秒只補了 0.5 個", 500 * time.Millisecond, false, 500 * time.Millisecond},
+		{"一秒後補滿一個", time.Second, true, 0},
+		{"閒置很久也不會超過上限", time.Hour, true, 0},
+		{"上限內的第二個", time.Hour, true, 0},
+		{"再取用就不足", time.Hour, false, time.Second},
+	}
+	for _, tt := range tests {
+		allowed, wait := bucket.take(rate, start.Add(tt.at))
+		if allowed != tt.allowed || wait != tt.wait {
+			t.Errorf("%s: take = (%v, %v)，預期 (%v, %v)", tt.name, allowed, wait, tt.allowed, tt.wait)
+		}
+	}
+}
+
+func newTestLimiter(t *testing.T, connection string, user string, maxViolations int) *RateLimiter {
+	t.Helper()
+	limiter, err := NewRateLimiter(config.RateLimit{Connection: connection, User: user, MaxViolations: maxViolations})
+	if err != nil {
+		t.Fatalf("NewRateLimiter: %v", err)
+	}
+	return limiter
+}
+
+func newTestClient(limiter *RateLimiter, playerUuid string) *Client {
+	client := NewClient(nil, nil, "room", playerUuid, playerUuid, "", 0, "")
+	limiter.attach(client)
+	return client
+}
+
+func TestRateLimiterAllowConnection(t *testing.T) {
+	limiter := newTestLimiter(t, "chat=2/1m,*=100/1s", "*=100/1s", 10)
+	client := newTestClient(limiter, "p1")
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := limiter.allow(client, "chat"); !allowed {
+			t.Fatalf("第 %d 則聊天不應被限流", i+1)
+		}
+	}
+	allowed, wait, disconnect := limiter.allow(client, "chat")
+	if allowed || wait <= 0 || disconnect {
+		t.Errorf("第 3 則聊天 = (%v, %v, %v)，預期被限流但不斷線", allowed, wait, disconnect)
+	}
+	// 沒有專屬規則的事件使用 * 的規則，不受 chat 影響
+	if allowed, _, _ := limiter.allow(client, "player_guess"); !allowed {
+		t.Error("其他事件不應被 chat 的規則限流")
+	}
+	// 新連線有自己的令牌桶
+	if allowed, _, _ := limiter.allow(newTestClient(limiter, "p2"), "chat"); !allowed {
+		t.Error("其他玩家的連線不應被限流")
+	}
+}
+
+func TestRateLimiterAllowUserSharedAcrossConnections(t *testing.T) {
+	limiter := newTestLimiter(t, "chat=5/1m", "chat=3/1m", 10)
+	first := newTestClient(limiter, "p1")
+	second := newTestClient(limiter, "p1")
+
+	for _, client := range []*Client{first, first, second} {
+		if allowed, _, _ := limiter.allow(client, "chat"); !allowed {
+			t.Fatal("玩家額度內不應被限流")
+		}
+	}
+	if allowed, _, _ := limiter.allow(second, "chat"); allowed {
+		t.Fatal("同一位玩家的連線應共用玩家額度")
+	}
+
+	// 被玩家額度擋下時不應扣到連線的令牌：second 只成功過 1 次，還剩 4 個
+	if tokens := second.limiter.buckets["chat"].tokens; tokens < 4 {
+		t.Errorf("被玩家額度擋下後連線令牌剩 %.2f，預期至少 4", tokens)
+	}
+}
+
+func TestRateLimiterAllowDisconnectAfterViolations(t *testing.T) {
+	limiter := newTestLimiter(t, "chat=1/1m", "*=100/1s", 3)
+	client := newTestClient(limiter, "p1")
+
+	limiter.allow(client, "chat")
+	for i := 1; i <= 3; i++ {
+		_, _, disconnect := limiter.allow(client, "chat")
+		if want := i >= 3; disconnect != want {
+			t.Errorf("第 %d 次違規 disconnect = %v，預期 %v", i, disconnect, want)
+		}
+	}
+}
+
+func TestRateLimiterDetach(t *testing.T) {
+	limiter := newTestLimiter(t, "*=100/1s", "chat=1/1m", 10)
+	client := newTestClient(limiter, "p1")
+	limiter.allow(client, "chat")
+	limiter.detach(client)
+
+	// 最後一個連線離開後玩家額度會被移除，重新連線時重新計算
+	if allowed, _, _ := limiter.allow(newTestClient(limiter, "p1"), "chat"); !allowed {
+		t.Error("重新連線後不應沿用舊的玩家額度")
+	}
+}
+
+func TestRateLimiterAllowMalformed(t *testing.T) {
+	limiter := newTestLimiter(t, "chat=5/1m,*=2/1m", "*=100/1s", 3)
+	client := newTestClient(limiter, "p1")
+
+	tests := []struct {
+		name       string
+		reply      bool
+		disconnect bool
+	}{
+		{"第一個錯誤訊框仍回覆錯誤", true, false},
+		{"第二個錯誤訊框用掉最後的令牌", true, false},
+		{"令牌用完後不再回覆，達到違規上限", false, true},
+	}
+	for _, tt := range tests {
+		reply, disconnect := limiter.allowMalformed(client)
+		if reply != tt.reply || disconnect != tt.disconnect {
+			t.Errorf("%s: allowMalformed = (%v, %v)，預期 (%v, %v)", tt.name, reply, disconnect, tt.reply, tt.disconnect)
+		}
+	}
+
+	// 錯誤訊框與沒有專屬規則的事件共用 * 的令牌桶
+	if allowed, _, _ := limiter.allow(client, "player_guess"); allowed {
+		t.Error("錯誤訊框應扣除 * 的令牌")
+	}
+	if allowed, _, _ := limiter.allow(client, "chat"); !allowed {
+		t.Error("有專屬規則的事件不應受錯誤訊框影響")
+	}
+}
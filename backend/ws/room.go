@@ -1,11 +1,27 @@
 package ws
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"game/models"
+)
+
+// 每個房間保留的最近聊天則數
+const recentChatSize = 20
 
 type Room struct {
 	ID        string
 	Clients   map[*Client]bool
 	CreatedAt time.Time
+
+	// 房間廣播的序號，每則廣播遞增，客戶端可藉此發現漏收的訊息
+	seqMu   sync.Mutex
+	lastSeq int64
+
+	// 最近的聊天紀錄（原文），檢舉時作為上下文
+	chatMu     sync.Mutex
+	recentChat []models.ChatLine
 }
 
 // NewRoom 創建一個新的房間
@@ -16,3 +32,33 @@ func NewRoom(roomID string) *Room {
 		CreatedAt: time.Now(),
 	}
 }
+
+// LastSeq 最後一則房間廣播的序號
+func (r *Room) LastSeq() int64 {
+	r.seqMu.Lock()
+	defer r.seqMu.Unlock()
+	return r.lastSeq
+}
+
+// 記錄一則聊天，超過上限時丟掉最舊的
+func (r *Room) recordChat(line models.ChatLine) {
+	r.chatMu.Lock()
+	defer r.chatMu.Unlock()
+	r.recentChat = append(r.recentChat, line)
+	if len(r.recentChat) > recentChatSize {
+		r.recentChat = r.recentChat[len(r.recentChat)-recentChatSize:]
+	}
+}
+
+// RecentChat 指定頻道最近聊天紀錄的複本
+func (r *Room) RecentChat(channel string) []models.ChatLine {
+	r.chatMu.Lock()
+	defer r.chatMu.Unlock()
+	var lines []models.ChatLine
+	for _, line := range r.recentChat {
+		if line.Channel == channel {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
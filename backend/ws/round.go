@@ -0,0 +1,154 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 同時猜測模式：提交密封的猜測，只公告提交進度，不公開數字
+func (c *Client) handleSealedGuess(guessNum int) {
+	game, allGuessed, err := c.ChatHub.GameManager.SubmitSealedGuess(c.RoomID, c.PlayerUuid, guessNum)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	submittedMsg := models.GameMessage{
+		Type:       models.EventGuessSubmitted,
+		GameId:     c.RoomID,
+		Message:    fmt.Sprintf("玩家 %s 已提交猜測 (%d/%d)", c.PlayerName, len(game.PlayersGuessed), len(game.Players)),
+		From:       "系統",
+		PlayerName: c.PlayerName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"guessRound": game.GuessRound,
+			"submitted":  len(game.PlayersGuessed),
+			"total":      len(game.Players),
+		},
+		Payload: protocol.GuessSubmittedEvent{
+			PlayerName: c.PlayerName,
+			GuessRound: game.GuessRound,
+			Submitted:  len(game.PlayersGuessed),
+			Total:      len(game.Players),
+		},
+	}
+	c.ChatHub.BroadcastGameMessage(c.RoomID, &submittedMsg)
+
+	if allGuessed {
+		c.ChatHub.revealRound(c.RoomID, game.GuessRound)
+	}
+}
+
+// 回合截止時自動揭曉
+func (h *ChatHub) scheduleRoundReveal(roomID string, guessRound int, deadline time.Time) {
+	h.timerMu.Lock()
+	defer h.timerMu.Unlock()
+
+	if timer, ok := h.roundTimers[roomID]; ok {
+		timer.Stop()
+	}
+	h.roundTimers[roomID] = time.AfterFunc(time.Until(deadline), func() {
+		h.revealRound(roomID, guessRound)
+	})
+}
+
+func (h *ChatHub) stopRoundTimer(roomID string) {
+	h.timerMu.Lock()
+	defer h.timerMu.Unlock()
+
+	if timer, ok := h.roundTimers[roomID]; ok {
+		timer.Stop()
+		delete(h.roundTimers, roomID)
+	}
+}
+
+// 揭曉回合結果；所有人提交或計時器到期都會呼叫，重複呼叫時只有第一次生效
+func (h *ChatHub) revealRound(roomID string, guessRound int) {
+	h.roundMu.Lock()
+	result, game, err := h.GameManager.RevealRound(roomID, guessRound)
+	h.roundMu.Unlock()
+	if err != nil {
+		log.Printf("揭曉房間 %s 第 %d 回合失敗: %v", roomID, guessRound, err)
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	revealMsg := models.GameMessage{
+		Type:      models.EventRoundReveal,
+		GameId:    roomID,
+		Message:   fmt.Sprintf("第 %d 回合揭曉", result.GuessRound),
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"guessRound": result.GuessRound,
+			"guesses":    result.Guesses,
+		},
+		Payload: protocol.RoundRevealEvent{GuessRound: result.GuessRound, Guesses: result.Guesses},
+	}
+	h.BroadcastGameMessage(roomID, &revealMsg)
+
+	if result.Finished {
+		h.stopRoundTimer(roomID)
+
+		winnerName := ""
+		for _, player := range game.Players {
+			if player.Uuid == result.WinnerUuid {
+				winnerName = player.Name
+				break
+			}
+		}
+
+		h.persistGameResult(roomID, game, result.WinnerUuid)
+
+		overMsg := models.GameMessage{
+			Type:       models.EventGameOver,
+			GameId:     roomID,
+			Message:    fmt.Sprintf("遊戲結束！答案是 %d，%s 以最高分獲勝", game.Answer, winnerName),
+			From:       "系統",
+			PlayerName: winnerName,
+			Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+			GameInfo: map[string]interface{}{
+				"answer":     game.Answer,
+				"salt":       game.Salt,
+				"commitment": game.Commitment,
+				"round":      game.Round,
+				"guesses":    result.Guesses,
+			},
+			Payload: protocol.GameOverEvent{
+				WinnerName: winnerName,
+				Answer:     game.Answer,
+				Salt:       game.Salt,
+				Commitment: game.Commitment,
+				Round:      game.Round,
+				Guesses:    result.Guesses,
+			},
+		}
+		h.BroadcastGameMessage(roomID, &overMsg)
+		return
+	}
+
+	h.scheduleRoundReveal(roomID, game.GuessRound, game.RoundDeadline)
+
+	roundMsg := models.GameMessage{
+		Type:      models.EventRoundStarted,
+		GameId:    roomID,
+		Message:   fmt.Sprintf("第 %d 回合開始，請在 %d 秒內提交猜測", game.GuessRound, game.RoundSeconds),
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"guessRound":    game.GuessRound,
+			"roundDeadline": game.RoundDeadline.Format(time.RFC3339),
+		},
+		Payload: protocol.RoundStartedEvent{
+			GuessRound:    game.GuessRound,
+			RoundDeadline: game.RoundDeadline.Format(time.RFC3339),
+		},
+	}
+	h.BroadcastGameMessage(roomID, &roundMsg)
+}
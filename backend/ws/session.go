@@ -0,0 +1,44 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 關閉連線時回傳給客戶端的原因，客戶端收到後應回到登入畫面
+const closeReasonSessionRevoked = "session_revoked"
+
+// CloseSession 中斷玩家在某個 session 的所有房間與大廳連線，回傳中斷的連線數；
+// 斷線後照一般離線流程離開房間
+func (h *ChatHub) CloseSession(uuid string, sessionID string) int {
+	if sessionID == "" {
+		return 0
+	}
+
+	var conns []*websocket.Conn
+	h.userMu.RLock()
+	for client := range h.users[uuid] {
+		if client.SessionID == sessionID {
+			conns = append(conns, client.Conn)
+		}
+	}
+	for client := range h.lobby[uuid] {
+		if client.SessionID == sessionID {
+			conns = append(conns, client.Conn)
+		}
+	}
+	h.userMu.RUnlock()
+
+	for _, conn := range conns {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, closeReasonSessionRevoked),
+			time.Now().Add(time.Second))
+		conn.Close()
+	}
+	if len(conns) > 0 {
+		log.Printf("session %s 已撤銷，中斷玩家 %s 的 %d 個連線", sessionID, uuid, len(conns))
+	}
+	return len(conns)
+}
@@ -0,0 +1,124 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	gamepkg "game/game"
+	"game/models"
+	"game/protocol"
+)
+
+// 傳送完整的房間狀態給單一連線：剛連線或客戶端發現序號不連續時使用
+func (h *ChatHub) sendSync(client *Client) {
+	// 先取序號再讀狀態，快照之後的變動一定會以更大的序號廣播，客戶端不會漏掉
+	lastSeq := h.LastSeq(client.RoomID)
+	game, err := h.GameManager.GetAGameStatus(client.RoomID)
+	if err != nil {
+		log.Printf("同步房間 %s 狀態給 %s 失敗: %v", client.RoomID, client.PlayerName, err)
+		return
+	}
+
+	sync := buildSync(game, client.PlayerUuid, lastSeq)
+	h.SendToClient(client, &models.GameMessage{
+		Type:      protocol.EventSync,
+		GameId:    client.RoomID,
+		Message:   "房間狀態同步",
+		From:      "系統",
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"lastSeq": sync.LastSeq,
+			"game":    sync.Game,
+			"you":     sync.You,
+		},
+		Payload: sync,
+	})
+}
+
+// PublicGame 給 REST API 回傳的房間狀態，與 sync 事件相同，遊戲結束前不含答案與鹽值
+func PublicGame(game *models.Game) protocol.GameSnapshot {
+	return buildSync(game, "", 0).Game
+}
+
+// 建立公開的房間快照，答案、鹽值與尚未揭曉的密封猜測都不會放進去
+func buildSync(game *models.Game, uuid string, lastSeq int64) protocol.SyncEvent {
+	snapshot := protocol.GameSnapshot{
+		Status:       game.Status,
+		Mode:         game.Mode,
+		FeedbackMode: game.FeedbackMode,
+		PowerUps:     game.PowerUps,
+		NumOfPeople:  game.NumOfPeople,
+		MinRange:     game.MinRange,
+		MaxRange:     game.MaxRange,
+		Round:        game.Round,
+		Rotation:     game.Rotation,
+		CurrentTurn:  game.CurrentTurn,
+		TeamCount:    game.TeamCount,
+		WinningTeam:  game.WinningTeam,
+		Submitted:    make([]string, 0, len(game.PlayersGuessed)),
+		Players:      make([]protocol.PlayerSnapshot, 0, len(game.Players)),
+		Guesses:      game.GuessHistory,
+		Items:        game.ItemLog,
+	}
+	if snapshot.Guesses == nil {
+		snapshot.Guesses = []models.GuessRecord{}
+	}
+	if snapshot.Items == nil {
+		snapshot.Items = []models.ItemUsage{}
+	}
+
+	if game.Status != "waiting" {
+		snapshot.Commitment = game.Commitment
+		snapshot.CommitmentScheme = gamepkg.CommitmentScheme
+	}
+	if game.Status == "finished" {
+		// 遊戲結束時答案與鹽值已經公開
+		answer := game.Answer
+		snapshot.Answer = &answer
+		snapshot.Salt = game.Salt
+	}
+	if game.Status == "playing" {
+		if game.Mode == models.ModeSimultaneous {
+			snapshot.GuessRound = game.GuessRound
+			snapshot.RoundDeadline = game.RoundDeadline.Format(time.RFC3339)
+		} else if game.Mode != models.ModeTeam && game.CurrentTurn < len(game.Players) {
+			snapshot.CurrentPlayer = game.Players[game.CurrentTurn].Name
+		}
+	}
+
+	var self *protocol.SelfSnapshot
+	for _, player := range game.Players {
+		if game.PlayersGuessed[player.Uuid] {
+			snapshot.Submitted = append(snapshot.Submitted, player.Uuid)
+		}
+		playerSnapshot := protocol.PlayerSnapshot{
+			Uuid:       player.Uuid,
+			Name:       player.Name,
+			Avatar:     player.Avatar,
+			IsReady:    player.Ready,
+			Team:       player.Team,
+			Eliminated: player.Eliminated,
+			Score:      player.Score,
+			GuessCount: player.GuessCount,
+			TurnOrder:  player.TurnOrder,
+		}
+		if until, muted := game.MutedUntil(player.Uuid); muted {
+			playerSnapshot.MutedUntil = until.Format(time.RFC3339)
+		}
+		snapshot.Players = append(snapshot.Players, playerSnapshot)
+		if player.Uuid == uuid {
+			items := player.Items
+			if items == nil {
+				items = map[string]int{}
+			}
+			self = &protocol.SelfSnapshot{
+				Uuid:         player.Uuid,
+				Items:        items,
+				ExtraGuesses: player.ExtraGuesses,
+				Shielded:     player.Shielded,
+			}
+		}
+	}
+
+	return protocol.SyncEvent{LastSeq: lastSeq, Game: snapshot, You: self}
+}
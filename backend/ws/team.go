@@ -0,0 +1,107 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"game/models"
+	"game/protocol"
+)
+
+// 隊伍模式：玩家選擇隊伍
+func (c *Client) handleChooseTeam(body json.RawMessage) {
+	req, err := protocol.DecodeChooseTeam(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	game, err := c.ChatHub.GameManager.ChooseTeam(c.RoomID, c.PlayerUuid, req.Team)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	c.ChatHub.broadcastTeams(c.RoomID, game, fmt.Sprintf("玩家 %s 加入第 %d 隊", c.PlayerName, req.Team))
+}
+
+// 隊伍模式：房主自動平均分隊
+func (c *Client) handleBalanceTeams() {
+	game, err := c.ChatHub.GameManager.BalanceTeams(c.RoomID, c.PlayerUuid)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	c.ChatHub.broadcastTeams(c.RoomID, game, "房主已自動分配隊伍")
+}
+
+// 隊伍頻道：只有同隊成員收得到
+func (c *Client) handleTeamChat(body json.RawMessage) {
+	req, err := protocol.DecodeChat(body)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	game, err := c.ChatHub.GameManager.GetAGameStatus(c.RoomID)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	team := 0
+	for _, player := range game.Players {
+		if player.Uuid == c.PlayerUuid {
+			team = player.Team
+			break
+		}
+	}
+	if team == 0 {
+		c.sendError(protocol.Errorf(protocol.ErrNoTeam, "您尚未加入隊伍"))
+		return
+	}
+
+	text, filtered, err := c.moderateChat(game, req.Text, models.ChatChannelTeam)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	c.ChatHub.SendToPlayers(c.RoomID, game.TeamMembers(team), &models.GameMessage{
+		Type:       models.EventTeamChat,
+		GameId:     c.RoomID,
+		Message:    text,
+		From:       c.PlayerName,
+		PlayerName: c.PlayerName,
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"team": team,
+		},
+		Payload: protocol.TeamChatEvent{From: c.PlayerName, Text: text, Team: team, Filtered: filtered},
+	})
+}
+
+// 廣播目前的分隊結果
+func (h *ChatHub) broadcastTeams(roomID string, game *models.Game, message string) {
+	teams := make(map[string][]string)
+	for team := 1; team <= game.TeamCount; team++ {
+		teams[strconv.Itoa(team)] = game.TeamMembers(team)
+	}
+
+	infos, players, _ := playerList(game)
+
+	h.BroadcastGameMessage(roomID, &models.GameMessage{
+		Type:      models.EventTeamsUpdated,
+		GameId:    roomID,
+		Message:   message,
+		From:      "系統",
+		Players:   players,
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		GameInfo: map[string]interface{}{
+			"teamCount": game.TeamCount,
+			"teams":     teams,
+		},
+		Payload: protocol.TeamsUpdatedEvent{TeamCount: game.TeamCount, Teams: teams, Players: infos},
+	})
+}